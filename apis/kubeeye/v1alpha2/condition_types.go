@@ -0,0 +1,63 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+// Condition types of the plan, task, result and rule statuses.
+const (
+	// ConditionScheduled is true when the plan is scheduling tasks
+	ConditionScheduled = "Scheduled"
+	// ConditionRunning is true while an inspection is running
+	ConditionRunning = "Running"
+	// ConditionCompleted is true when the inspection or the import has completed
+	ConditionCompleted = "Completed"
+	// ConditionDegraded is true when some part of the inspection has failed
+	ConditionDegraded = "Degraded"
+)
+
+// Condition and event reasons.
+const (
+	ReasonScheduled        = "Scheduled"
+	ReasonSuspended        = "Suspended"
+	ReasonInvalidSchedule  = "InvalidSchedule"
+	ReasonTaskCreated      = "TaskCreated"
+	ReasonTaskCreateFailed = "TaskCreateFailed"
+	ReasonTaskDeleted      = "TaskDeleted"
+	ReasonMissedSchedule   = "MissedSchedule"
+
+	ReasonStarted            = "Started"
+	ReasonClusterFailed      = "ClusterUnavailable"
+	ReasonNodeUnavailable    = "NodeUnavailable"
+	ReasonJobCreateFailed    = "JobCreateFailed"
+	ReasonJobFailed          = "JobFailed"
+	ReasonJobTimeout         = "JobTimeout"
+	ReasonResultParseFailed  = "ResultParseFailed"
+	ReasonImagePullBackOff   = "ImagePullBackOff"
+	ReasonUnschedulable      = "Unschedulable"
+	ReasonOOMKilled          = "OOMKilled"
+	ReasonSucceeded          = "Succeeded"
+	ReasonCancelled          = "Cancelled"
+	ReasonPartiallySucceeded = "PartiallySucceeded"
+	ReasonRetrying           = "Retrying"
+	ReasonFailed             = "Failed"
+	ReasonHealthy            = "Healthy"
+
+	ReasonResultReadFailed = "ResultReadFailed"
+	ReasonResultCounted    = "ResultCounted"
+
+	ReasonImporting = "Importing"
+	ReasonImported  = "Imported"
+)
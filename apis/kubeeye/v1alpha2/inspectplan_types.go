@@ -36,8 +36,38 @@ type InspectPlanSpec struct {
 	ClusterName []Cluster          `json:"clusterName,omitempty"`
 	KubeConfig  string             `json:"kubeConfig,omitempty"`
 	Once        *metav1.Time       `json:"one,omitempty"`
+	// ReportTemplate is the name of a report template ConfigMap used to render the notifications of this plan.
+	ReportTemplate string `json:"reportTemplate,omitempty"`
+	// RetryPolicy retries the jobs of the tasks that failed for a transient reason
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+	// ConcurrencyPolicy is what happens when a scheduled task is due while a task of the plan is still running,
+	// Allow (default) runs them concurrently, Forbid skips the new task and Replace deletes the running task first
+	// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+	// StartingDeadlineSeconds is how late a task may start after its scheduled time, e.g. when the manager was down.
+	// Missed schedules are not started past this deadline, without it the latest missed schedule always starts.
+	// +kubebuilder:validation:Minimum=0
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+	// SuccessfulTasksHistoryLimit is how many succeeded tasks are kept, all when not set
+	// +kubebuilder:validation:Minimum=0
+	SuccessfulTasksHistoryLimit *int32 `json:"successfulTasksHistoryLimit,omitempty"`
+	// FailedTasksHistoryLimit is how many failed, partially succeeded or cancelled tasks are kept, all when not set
+	// +kubebuilder:validation:Minimum=0
+	FailedTasksHistoryLimit *int32 `json:"failedTasksHistoryLimit,omitempty"`
 }
 
+// ConcurrencyPolicy describes how the tasks of a plan run concurrently, as the policy of a CronJob
+type ConcurrencyPolicy string
+
+const (
+	// AllowConcurrent starts the scheduled tasks while other tasks are running
+	AllowConcurrent ConcurrencyPolicy = "Allow"
+	// ForbidConcurrent skips a scheduled task while the previous one is running
+	ForbidConcurrent ConcurrencyPolicy = "Forbid"
+	// ReplaceConcurrent deletes the running tasks to start the scheduled one
+	ReplaceConcurrent ConcurrencyPolicy = "Replace"
+)
+
 type TaskNames struct {
 	Name       string `json:"name,omitempty"`
 	TaskStatus Phase  `json:"taskStatus,omitempty"`
@@ -55,6 +85,11 @@ type InspectPlanStatus struct {
 	TaskNames         []TaskNames  `json:"TaskNames,omitempty"`
 	LastTaskStatus    Phase        `json:"lastTaskStatus,omitempty"`
 	NextScheduleTime  *metav1.Time `json:"nextScheduleTime,omitempty"`
+	// Conditions are the latest observations of the state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
@@ -55,6 +55,11 @@ type InspectResultStatus struct {
 	TaskStartTime string         `json:"taskStartTime,omitempty"`
 	TaskEndTime   string         `json:"taskEndTime,omitempty"`
 	Level         map[Level]*int `json:"level,omitempty"`
+	// Conditions are the latest observations of the state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 type Level string
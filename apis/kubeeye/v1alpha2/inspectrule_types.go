@@ -112,6 +112,11 @@ type InspectRuleStatus struct {
 	EndImportTime   *metav1.Time   `json:"endImportTime,omitempty"`
 	State           State          `json:"state,omitempty"`
 	LevelCount      map[Level]*int `json:"levelCount,omitempty"`
+	// Conditions are the latest observations of the state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
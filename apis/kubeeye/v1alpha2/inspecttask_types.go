@@ -32,6 +32,30 @@ type InspectTaskSpec struct {
 	RuleNames     []InspectRuleNames `json:"ruleNames,omitempty"`
 	Timeout       string             `json:"timeout,omitempty"`
 	InspectPolicy Policy             `json:"inspectPolicy,omitempty"`
+	// Cancel stops the inspection, the outstanding jobs are deleted and the results of the finished jobs are kept
+	Cancel bool `json:"cancel,omitempty"`
+	// Jobs limits the inspection to the matching jobs, e.g. the failed jobs of the task a task reruns
+	Jobs []JobSelector `json:"jobs,omitempty"`
+	// RetryPolicy retries the jobs that failed for a transient reason
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+}
+
+// RetryPolicy defines how the failed jobs of a task are retried
+type RetryPolicy struct {
+	// MaxAttempts is how many times a job is started at most, 3 by default
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// Backoff is the delay before the first retry of a job, doubled for every further retry up to 5m, 10s by default
+	Backoff string `json:"backoff,omitempty"`
+	// RetryableReasons are the failure reasons retried, NodeUnavailable, JobCreateFailed, JobFailed,
+	// BackoffLimitExceeded and Evicted by default
+	RetryableReasons []string `json:"retryableReasons,omitempty"`
+}
+
+// JobSelector matches the jobs of a cluster, rule type and node, an empty field matches any
+type JobSelector struct {
+	Cluster  string `json:"cluster,omitempty"`
+	RuleType string `json:"ruleType,omitempty"`
+	NodeName string `json:"nodeName,omitempty"`
 }
 
 // InspectTaskStatus defines the observed state of InspectTask
@@ -46,6 +70,13 @@ type InspectTaskStatus struct {
 	Duration        string       `json:"duration,omitempty" yaml:"duration"`
 	Status          Phase        `json:"status,omitempty" yaml:"status,omitempty"`
 	InspectRuleType []string     `json:"inspectRuleType,omitempty" yaml:"inspectRuleType"`
+	// Progress counts the jobs of the task by phase, it is updated as the jobs finish
+	Progress *TaskProgress `json:"progress,omitempty" yaml:"progress,omitempty"`
+	// Conditions are the latest observations of the state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
 }
 
 type InspectRuleNames struct {
@@ -57,6 +88,40 @@ type InspectRuleNames struct {
 type JobPhase struct {
 	JobName string `json:"jobName,omitempty"`
 	Phase   Phase  `json:"phase,omitempty"`
+	// Cluster is the cluster the job runs in
+	Cluster  string `json:"cluster,omitempty"`
+	RuleType string `json:"ruleType,omitempty"`
+	NodeName string `json:"nodeName,omitempty"`
+	// Reason and Message tell why the job failed or is not progressing, e.g. ImagePullBackOff or Unschedulable
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Attempts is how many times the job has been started
+	Attempts int `json:"attempts,omitempty"`
+	// NextAttemptTime is when a job waiting for a retry is started again
+	NextAttemptTime *metav1.Time `json:"nextAttemptTime,omitempty"`
+}
+
+// JobCounts counts jobs by phase
+type JobCounts struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Running   int `json:"running"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Cancelled int `json:"cancelled,omitempty"`
+}
+
+type TaskProgress struct {
+	JobCounts `json:",inline"`
+	// RuleTypes is the progress of the jobs of each rule type
+	RuleTypes []RuleTypeProgress `json:"ruleTypes,omitempty"`
+	// EstimatedCompletionTime is when the jobs are expected to finish at the pace of the finished ones
+	EstimatedCompletionTime *metav1.Time `json:"estimatedCompletionTime,omitempty"`
+}
+
+type RuleTypeProgress struct {
+	RuleType  string `json:"ruleType"`
+	JobCounts `json:",inline"`
 }
 
 type JobRule struct {
@@ -152,6 +217,9 @@ const (
 	PhaseSucceeded Phase = "Succeeded"
 	PhaseFailed    Phase = "Failed"
 	PhaseUnknown   Phase = "Unknown"
+	PhaseCancelled Phase = "Cancelled"
+	// PhasePartiallySucceeded is a task with some failed jobs
+	PhasePartiallySucceeded Phase = "PartiallySucceeded"
 )
 
 func (p Phase) IsEmpty() bool {
@@ -172,3 +240,14 @@ func (p Phase) IsFailed() bool {
 func (p Phase) IsUnknown() bool {
 	return p == PhaseUnknown
 }
+func (p Phase) IsCancelled() bool {
+	return p == PhaseCancelled
+}
+func (p Phase) IsPartiallySucceeded() bool {
+	return p == PhasePartiallySucceeded
+}
+
+// IsFinished returns whether a task or job in this phase has finished.
+func (p Phase) IsFinished() bool {
+	return p.IsSucceeded() || p.IsFailed() || p.IsCancelled() || p.IsPartiallySucceeded()
+}
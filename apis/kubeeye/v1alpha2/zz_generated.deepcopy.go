@@ -22,6 +22,7 @@ limitations under the License.
 package v1alpha2
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -277,6 +278,26 @@ func (in *InspectPlanSpec) DeepCopyInto(out *InspectPlanSpec) {
 		in, out := &in.Once, &out.Once
 		*out = (*in).DeepCopy()
 	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartingDeadlineSeconds != nil {
+		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.SuccessfulTasksHistoryLimit != nil {
+		in, out := &in.SuccessfulTasksHistoryLimit, &out.SuccessfulTasksHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailedTasksHistoryLimit != nil {
+		in, out := &in.FailedTasksHistoryLimit, &out.FailedTasksHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InspectPlanSpec.
@@ -313,6 +334,13 @@ func (in *InspectPlanStatus) DeepCopyInto(out *InspectPlanStatus) {
 		in, out := &in.NextScheduleTime, &out.NextScheduleTime
 		*out = (*in).DeepCopy()
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InspectPlanStatus.
@@ -479,6 +507,13 @@ func (in *InspectResultStatus) DeepCopyInto(out *InspectResultStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InspectResultStatus.
@@ -675,6 +710,13 @@ func (in *InspectRuleStatus) DeepCopyInto(out *InspectRuleStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InspectRuleStatus.
@@ -761,6 +803,16 @@ func (in *InspectTaskSpec) DeepCopyInto(out *InspectTaskSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = make([]JobSelector, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InspectTaskSpec.
@@ -780,7 +832,9 @@ func (in *InspectTaskStatus) DeepCopyInto(out *InspectTaskStatus) {
 	if in.JobPhase != nil {
 		in, out := &in.JobPhase, &out.JobPhase
 		*out = make([]JobPhase, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.StartTimestamp != nil {
 		in, out := &in.StartTimestamp, &out.StartTimestamp
@@ -795,6 +849,18 @@ func (in *InspectTaskStatus) DeepCopyInto(out *InspectTaskStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(TaskProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InspectTaskStatus.
@@ -807,9 +873,28 @@ func (in *InspectTaskStatus) DeepCopy() *InspectTaskStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobCounts) DeepCopyInto(out *JobCounts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobCounts.
+func (in *JobCounts) DeepCopy() *JobCounts {
+	if in == nil {
+		return nil
+	}
+	out := new(JobCounts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JobPhase) DeepCopyInto(out *JobPhase) {
 	*out = *in
+	if in.NextAttemptTime != nil {
+		in, out := &in.NextAttemptTime, &out.NextAttemptTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobPhase.
@@ -842,6 +927,21 @@ func (in *JobRule) DeepCopy() *JobRule {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobSelector) DeepCopyInto(out *JobSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobSelector.
+func (in *JobSelector) DeepCopy() *JobSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(JobSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeeyeOpaResult) DeepCopyInto(out *KubeeyeOpaResult) {
 	*out = *in
@@ -1046,6 +1146,26 @@ func (in *ResultItem) DeepCopy() *ResultItem {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
+	*out = *in
+	if in.RetryableReasons != nil {
+		in, out := &in.RetryableReasons, &out.RetryableReasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
+func (in *RetryPolicy) DeepCopy() *RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RuleItemBases) DeepCopyInto(out *RuleItemBases) {
 	*out = *in
@@ -1061,6 +1181,22 @@ func (in *RuleItemBases) DeepCopy() *RuleItemBases {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleTypeProgress) DeepCopyInto(out *RuleTypeProgress) {
+	*out = *in
+	out.JobCounts = in.JobCounts
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleTypeProgress.
+func (in *RuleTypeProgress) DeepCopy() *RuleTypeProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleTypeProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScoreInfo) DeepCopyInto(out *ScoreInfo) {
 	*out = *in
@@ -1139,3 +1275,28 @@ func (in *TaskNames) DeepCopy() *TaskNames {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskProgress) DeepCopyInto(out *TaskProgress) {
+	*out = *in
+	out.JobCounts = in.JobCounts
+	if in.RuleTypes != nil {
+		in, out := &in.RuleTypes, &out.RuleTypes
+		*out = make([]RuleTypeProgress, len(*in))
+		copy(*out, *in)
+	}
+	if in.EstimatedCompletionTime != nil {
+		in, out := &in.EstimatedCompletionTime, &out.EstimatedCompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskProgress.
+func (in *TaskProgress) DeepCopy() *TaskProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskProgress)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -18,6 +18,7 @@ import (
 	controllers2 "github.com/kubesphere/kubeeye/pkg/controllers"
 	"github.com/kubesphere/kubeeye/pkg/informers"
 	"github.com/kubesphere/kubeeye/pkg/kube"
+	"github.com/kubesphere/kubeeye/pkg/metrics"
 	"go.uber.org/zap/zapcore"
 	"os"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -72,6 +73,7 @@ func main() {
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
+		Cache:  controllers2.CacheOptions(),
 		Metrics: server.Options{
 			BindAddress: metricsAddr,
 		},
@@ -106,6 +108,7 @@ func main() {
 		Scheme:         mgr.GetScheme(),
 		K8sClient:      clients,
 		KubeEyeFactory: factory.KubeEyeInformerFactory().Kubeeye(),
+		Recorder:       mgr.GetEventRecorderFor("inspectplan-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "InspectPlan")
 		os.Exit(1)
@@ -116,28 +119,46 @@ func main() {
 		K8sClients:     clients,
 		KubeEyeFactory: factory.KubeEyeInformerFactory().Kubeeye(),
 		K8sFactory:     factory.KubernetesInformerFactory(),
+		Recorder:       mgr.GetEventRecorderFor("inspecttask-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "InspectTask")
 		os.Exit(1)
 	}
 	if err = (&controllers2.InspectResultReconciler{
 		Client:         mgr.GetClient(),
+		APIReader:      mgr.GetAPIReader(),
 		Scheme:         mgr.GetScheme(),
 		KubeEyeFactory: factory.KubeEyeInformerFactory().Kubeeye(),
 		K8sFactory:     factory.KubernetesInformerFactory(),
+		Recorder:       mgr.GetEventRecorderFor("inspectresult-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "InspectResult")
 		os.Exit(1)
 	}
 	if err = (&controllers2.InspectRulesReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("inspectrule-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "InspectRule")
 		os.Exit(1)
 	}
+	if err = mgr.Add(&controllers2.NotificationDigest{
+		Client:     mgr.GetClient(),
+		APIReader:  mgr.GetAPIReader(),
+		K8sFactory: factory.KubernetesInformerFactory(),
+	}); err != nil {
+		setupLog.Error(err, "unable to add notification digest")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
+	kubeEyeInformers := factory.KubeEyeInformerFactory().Kubeeye().V1alpha2()
+	if err = metrics.Register(kubeEyeInformers.InspectResults().Lister(), kubeEyeInformers.InspectTasks().Lister()); err != nil {
+		setupLog.Error(err, "unable to register metrics")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -0,0 +1,151 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/kube"
+	"github.com/kubesphere/kubeeye/pkg/output"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+)
+
+type Options struct {
+	KubeConfig string
+	Files      []string
+	Task       string
+	Format     string
+	Output     string
+	Namespace  string
+	Service    string
+	Port       string
+}
+
+func NewCmdExport() *cobra.Command {
+	o := &Options{}
+	exportCmd := &cobra.Command{
+		Use:   "export [inspectresult name]",
+		Short: "export an inspect result as csv/tsv rows or as sarif/junit for CI pipelines",
+		Example: `  ke export -f result.json --format tsv
+  ke export inspect-task-1700000000 --format csv -o result.csv
+  ke export inspect-task-1700000000 --format sarif -o kubeeye.sarif
+  ke export --task inspect-task-1700000000 --format html -o report.html
+  ke export -f member1.json -f member2.json --format markdown`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.KubeConfig == "" {
+				o.KubeConfig, _ = cmd.Flags().GetString("kube-config")
+			}
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			return o.Run(cmd.Context(), name)
+		},
+	}
+	o.addFlags(exportCmd)
+	return exportCmd
+}
+
+func (o *Options) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.KubeConfig, "kubeconfig", "", "path to the kubeconfig of the cluster running kubeeye")
+	cmd.Flags().StringArrayVarP(&o.Files, "file", "f", nil, "inspect result json file, - reads stdin. Several files are merged into one multi-cluster report")
+	cmd.Flags().StringVar(&o.Task, "task", "", "export the multi-cluster report of this inspect task, with several --file it is only the report title")
+	cmd.Flags().StringVar(&o.Format, "format", output.FormatCSV, "export format: csv, tsv, xlsx, sarif, junit, pdf, report, markdown, text, json, table or html")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "write to this file instead of stdout")
+	cmd.Flags().StringVar(&o.Namespace, "namespace", "kubeeye-system", "namespace of the kubeeye apiserver")
+	cmd.Flags().StringVar(&o.Service, "service", "kubeeye-apiserver", "service name of the kubeeye apiserver")
+	cmd.Flags().StringVar(&o.Port, "port", "9090", "service port of the kubeeye apiserver")
+}
+
+func (o *Options) Run(ctx context.Context, name string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	switch {
+	case name != "" && (len(o.Files) > 0 || o.Task != ""):
+		return fmt.Errorf("an inspectresult name can not be combined with --file or --task")
+	case name == "" && len(o.Files) == 0 && o.Task == "":
+		return fmt.Errorf("either an inspectresult name, --file or --task is required")
+	}
+
+	w := io.Writer(os.Stdout)
+	if o.Output != "" {
+		file, err := os.Create(o.Output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if len(o.Files) == 0 && o.Task != "" {
+		// the apiserver renders the report, it reads the result files of every cluster
+		data, err := o.fetch(ctx, "/kapis/kubeeye.kubesphere.io/v1alpha2/inspecttasks/"+o.Task+"/report", map[string]string{"format": o.Format})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	var results []*v1alpha2.InspectResult
+	for _, file := range o.Files {
+		result, err := readResult(file)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+	if len(results) > 1 {
+		task := o.Task
+		if task == "" {
+			task = "kubeeye"
+		}
+		return output.RenderAggregate(w, o.Format, output.Aggregate(task, results))
+	}
+	if len(results) == 0 {
+		data, err := o.fetch(ctx, "/kapis/kubeeye.kubesphere.io/v1alpha2/inspectresults/"+name, map[string]string{"type": "json"})
+		if err != nil {
+			return err
+		}
+		var result v1alpha2.InspectResult
+		if err = json.Unmarshal(data, &result); err != nil {
+			return fmt.Errorf("failed to parse inspect result: %s", err)
+		}
+		results = append(results, &result)
+	}
+	return output.Render(w, o.Format, results[0])
+}
+
+func readResult(file string) (*v1alpha2.InspectResult, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var result v1alpha2.InspectResult
+	if err = json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse inspect result %s: %s", file, err)
+	}
+	return &result, nil
+}
+
+// fetch reads from the kubeeye apiserver through its service proxy, the apiserver owns the result files.
+func (o *Options) fetch(ctx context.Context, path string, params map[string]string) ([]byte, error) {
+	clients, err := kube.GetK8SClients(o.KubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	return clients.ClientSet.CoreV1().Services(o.Namespace).
+		ProxyGet("http", o.Service, o.Port, path, params).
+		DoRaw(ctx)
+}
@@ -2,6 +2,11 @@ package ctl
 
 import (
 	"github.com/kubesphere/kubeeye/cmd/ke/ctl/create"
+	"github.com/kubesphere/kubeeye/cmd/ke/ctl/export"
+	"github.com/kubesphere/kubeeye/cmd/ke/ctl/inspect"
+	"github.com/kubesphere/kubeeye/cmd/ke/ctl/rule"
+	"github.com/kubesphere/kubeeye/cmd/ke/ctl/scan"
+	"github.com/kubesphere/kubeeye/cmd/ke/ctl/task"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +20,11 @@ func Execute() error {
 	}
 
 	rootCmd.AddCommand(create.NewCmdCreate())
+	rootCmd.AddCommand(rule.NewCmdRule())
+	rootCmd.AddCommand(inspect.NewCmdInspect())
+	rootCmd.AddCommand(scan.NewCmdScan())
+	rootCmd.AddCommand(export.NewCmdExport())
+	rootCmd.AddCommand(task.NewCmdTask())
 
 	addFlags(rootCmd)
 
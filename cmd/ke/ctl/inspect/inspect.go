@@ -0,0 +1,135 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	kubeeyeinspect "github.com/kubesphere/kubeeye/pkg/inspect"
+	"github.com/kubesphere/kubeeye/pkg/kube"
+	"github.com/kubesphere/kubeeye/pkg/output"
+	"github.com/kubesphere/kubeeye/pkg/rules"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"os"
+	"time"
+)
+
+// clusterRuleTypes are the rule types that only talk to the apiserver and run in the ke process.
+var clusterRuleTypes = []string{constant.Opa, constant.Prometheus, constant.ServiceConnect, constant.Component}
+
+type Options struct {
+	KubeConfig      string
+	ClusterName     string
+	Files           []string
+	Output          string
+	NodeInspect     bool
+	Namespace       string
+	Image           string
+	ImagePullPolicy string
+	Timeout         time.Duration
+
+	clients *kube.KubernetesClient
+}
+
+func NewCmdInspect() *cobra.Command {
+	o := &Options{}
+	inspectCmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "run inspect rules against a cluster without installing kubeeye",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.KubeConfig == "" {
+				o.KubeConfig, _ = cmd.Flags().GetString("kube-config")
+			}
+			return o.Run(cmd.Context())
+		},
+	}
+	o.addFlags(inspectCmd)
+	return inspectCmd
+}
+
+func (o *Options) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.KubeConfig, "kubeconfig", "", "path to the kubeconfig of the cluster to inspect")
+	cmd.Flags().StringVar(&o.ClusterName, "cluster-name", "default", "cluster name shown in the report")
+	cmd.Flags().StringArrayVarP(&o.Files, "filename", "f", nil, "InspectRule file or directory, can be repeated")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", output.FormatTable, "output format: table, json, html, csv, tsv, sarif or junit")
+	cmd.Flags().BoolVar(&o.NodeInspect, "node-inspect", false, "run node level rules through temporary privileged pods")
+	cmd.Flags().StringVar(&o.Namespace, "namespace", constant.DefaultNamespace, "namespace of the temporary inspect pods")
+	cmd.Flags().StringVar(&o.Image, "image", "kubespheredev/kubeeye-job:latest", "image of the temporary inspect pods")
+	cmd.Flags().StringVar(&o.ImagePullPolicy, "image-pull-policy", "IfNotPresent", "image pull policy of the temporary inspect pods")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", constant.DefaultTimeout, "how long to wait for the temporary inspect pods")
+	_ = cmd.MarkFlagRequired("filename")
+}
+
+func (o *Options) Run(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	inspectRules, err := rules.LoadRuleFiles(o.Files...)
+	if err != nil {
+		return err
+	}
+	o.clients, err = kube.GetK8SClients(o.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	startTime := time.Now()
+	task := &kubeeyev1alpha2.InspectTask{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("ke-local-%s", startTime.Format("20060102-150405"))}}
+	for _, r := range inspectRules {
+		task.Spec.RuleNames = append(task.Spec.RuleNames, kubeeyev1alpha2.InspectRuleNames{Name: r.Name})
+	}
+	executeRule := rules.NewExecuteRuleOptions(o.clients, task)
+	ruleSpec, err := executeRule.MergeRule(inspectRules)
+	if err != nil {
+		return err
+	}
+
+	result := &kubeeyev1alpha2.InspectResult{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%s-result", o.ClusterName, task.Name),
+			Annotations: map[string]string{constant.AnnotationStartTime: startTime.Format("2006-01-02 15:04:05")},
+		},
+		Spec: kubeeyev1alpha2.InspectResultSpec{
+			InspectCluster: kubeeyev1alpha2.Cluster{Name: o.ClusterName},
+		},
+	}
+
+	var nodeJobRules []kubeeyev1alpha2.JobRule
+	for _, jobRule := range executeRule.GenerateJob(ctx, ruleSpec) {
+		if !isClusterRule(jobRule.RuleType) {
+			nodeJobRules = append(nodeJobRules, jobRule)
+			continue
+		}
+		klog.Infof("running %s rules", jobRule.RuleType)
+		result, err = kubeeyeinspect.RunLocalInspect(ctx, o.clients, jobRule, "", kubeeyeinspect.DefaultHostPaths(), result)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(nodeJobRules) > 0 {
+		if o.NodeInspect {
+			if err = o.runNodeInspect(ctx, executeRule, task, nodeJobRules, result); err != nil {
+				return err
+			}
+		} else {
+			klog.Warningf("skipped %d node level rule jobs, use --node-inspect to run them", len(nodeJobRules))
+		}
+	}
+
+	result.Spec.InspectRuleTotal = executeRule.GetRuleTotal()
+	result.Annotations[constant.AnnotationEndTime] = time.Now().Format("2006-01-02 15:04:05")
+	result.Status.Duration = time.Since(startTime).String()
+	return output.Render(os.Stdout, o.Output, result)
+}
+
+func isClusterRule(ruleType string) bool {
+	for _, t := range clusterRuleTypes {
+		if t == ruleType {
+			return true
+		}
+	}
+	return false
+}
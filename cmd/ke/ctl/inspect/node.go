@@ -0,0 +1,183 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	kubeeyeinspect "github.com/kubesphere/kubeeye/pkg/inspect"
+	"github.com/kubesphere/kubeeye/pkg/rules"
+	"github.com/kubesphere/kubeeye/pkg/template"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeErr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"os"
+	"time"
+)
+
+// runNodeInspect runs the node level rules through the same inspect jobs the operator creates,
+// and removes every object it created once the results are collected.
+func (o *Options) runNodeInspect(ctx context.Context, executeRule *rules.ExecuteRule, task *kubeeyev1alpha2.InspectTask, jobRules []kubeeyev1alpha2.JobRule, result *kubeeyev1alpha2.InspectResult) error {
+	// the templates and the job pods find their namespace through this variable
+	if err := os.Setenv("KUBERNETES_POD_NAMESPACE", o.Namespace); err != nil {
+		return err
+	}
+	cleanup, err := o.initInspectConfig(ctx)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	if _, err = executeRule.CreateInspectRule(ctx, jobRules); err != nil {
+		return err
+	}
+	defer func() {
+		err := o.clients.ClientSet.CoreV1().ConfigMaps(o.Namespace).Delete(context.Background(), task.Name, metav1.DeleteOptions{})
+		if err != nil && !kubeErr.IsNotFound(err) {
+			klog.Error("failed to delete temp inspect rule", err)
+		}
+	}()
+
+	var backLimit int32
+	jobConfig := &conf.JobConfig{
+		ImageConfig: conf.ImageConfig{Image: o.Image, ImagePullPolicy: o.ImagePullPolicy},
+		BackLimit:   &backLimit,
+	}
+	var created []kubeeyev1alpha2.JobRule
+	for _, jobRule := range jobRules {
+		nodeName, err := rules.GetDeploySchedule(jobRule.RunRule)
+		if err != nil {
+			klog.Errorf("%s:%s", jobRule.RuleType, err)
+			continue
+		}
+		job := template.GeneratorJobTemplate(template.JobTemplateOptions{
+			JobConfig: jobConfig,
+			JobName:   jobRule.JobName,
+			Task:      task,
+			NodeName:  nodeName,
+			RuleType:  jobRule.RuleType,
+		})
+		// there is no InspectTask owning the job, it is deleted explicitly
+		job.OwnerReferences = nil
+		if _, err = o.clients.ClientSet.BatchV1().Jobs(o.Namespace).Create(ctx, job, metav1.CreateOptions{}); err != nil {
+			klog.Errorf("failed to create job %s, err:%s", jobRule.JobName, err)
+			continue
+		}
+		created = append(created, jobRule)
+	}
+	defer o.cleanJobs(created)
+
+	deadline := time.Now().Add(o.Timeout)
+	for _, jobRule := range created {
+		if err = o.waitForJob(ctx, jobRule.JobName, time.Until(deadline)); err != nil {
+			klog.Errorf("job %s did not complete, err:%s", jobRule.JobName, err)
+			continue
+		}
+		resultCm, err := o.clients.ClientSet.CoreV1().ConfigMaps(o.Namespace).Get(ctx, jobRule.JobName, metav1.GetOptions{})
+		if err != nil {
+			klog.Errorf("failed to get result of job %s, err:%s", jobRule.JobName, err)
+			continue
+		}
+		if _, err = kubeeyeinspect.RuleOperatorMap[jobRule.RuleType].GetResult(resultCm.Labels[constant.LabelNodeName], resultCm, result); err != nil {
+			klog.Errorf("failed to parse result of job %s, err:%s", jobRule.JobName, err)
+		}
+	}
+	return nil
+}
+
+func (o *Options) waitForJob(ctx context.Context, jobName string, timeout time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("timed out")
+	}
+	return wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		job, err := o.clients.ClientSet.BatchV1().Jobs(o.Namespace).Get(ctx, jobName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, condition := range job.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case batchv1.JobComplete:
+				return true, nil
+			case batchv1.JobFailed:
+				return false, fmt.Errorf("%s: %s", condition.Reason, condition.Message)
+			}
+		}
+		return false, nil
+	})
+}
+
+func (o *Options) cleanJobs(jobRules []kubeeyev1alpha2.JobRule) {
+	ctx := context.Background()
+	background := metav1.DeletePropagationBackground
+	for _, jobRule := range jobRules {
+		err := o.clients.ClientSet.BatchV1().Jobs(o.Namespace).Delete(ctx, jobRule.JobName, metav1.DeleteOptions{PropagationPolicy: &background})
+		if err != nil && !kubeErr.IsNotFound(err) {
+			klog.Errorf("failed to delete job %s, err:%s", jobRule.JobName, err)
+		}
+		err = o.clients.ClientSet.CoreV1().ConfigMaps(o.Namespace).Delete(ctx, jobRule.JobName, metav1.DeleteOptions{})
+		if err != nil && !kubeErr.IsNotFound(err) {
+			klog.Errorf("failed to delete result %s, err:%s", jobRule.JobName, err)
+		}
+	}
+}
+
+// initInspectConfig creates the namespace, service account and role the inspect jobs need.
+// The returned cleanup only removes the objects that did not exist before.
+func (o *Options) initInspectConfig(ctx context.Context) (func(), error) {
+	var cleanups []func(ctx context.Context) error
+	cleanup := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			if err := cleanups[i](context.Background()); err != nil && !kubeErr.IsNotFound(err) {
+				klog.Error("failed to clean temporary inspect config", err)
+			}
+		}
+	}
+
+	clientSet := o.clients.ClientSet
+	_, err := clientSet.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: o.Namespace}}, metav1.CreateOptions{})
+	if err == nil {
+		cleanups = append(cleanups, func(ctx context.Context) error {
+			return clientSet.CoreV1().Namespaces().Delete(ctx, o.Namespace, metav1.DeleteOptions{})
+		})
+	} else if !kubeErr.IsAlreadyExists(err) {
+		return cleanup, err
+	}
+
+	clusterRole := template.GetClusterRoleTemplate()
+	_, err = clientSet.RbacV1().ClusterRoles().Create(ctx, clusterRole, metav1.CreateOptions{})
+	if err == nil {
+		cleanups = append(cleanups, func(ctx context.Context) error {
+			return clientSet.RbacV1().ClusterRoles().Delete(ctx, clusterRole.Name, metav1.DeleteOptions{})
+		})
+	} else if !kubeErr.IsAlreadyExists(err) {
+		return cleanup, err
+	}
+
+	clusterRoleBinding := template.GetClusterRoleBindingTemplate()
+	_, err = clientSet.RbacV1().ClusterRoleBindings().Create(ctx, clusterRoleBinding, metav1.CreateOptions{})
+	if err == nil {
+		cleanups = append(cleanups, func(ctx context.Context) error {
+			return clientSet.RbacV1().ClusterRoleBindings().Delete(ctx, clusterRoleBinding.Name, metav1.DeleteOptions{})
+		})
+	} else if !kubeErr.IsAlreadyExists(err) {
+		return cleanup, err
+	}
+
+	serviceAccount := template.GetServiceAccountTemplate()
+	_, err = clientSet.CoreV1().ServiceAccounts(o.Namespace).Create(ctx, serviceAccount, metav1.CreateOptions{})
+	if err == nil {
+		cleanups = append(cleanups, func(ctx context.Context) error {
+			return clientSet.CoreV1().ServiceAccounts(o.Namespace).Delete(ctx, serviceAccount.Name, metav1.DeleteOptions{})
+		})
+	} else if !kubeErr.IsAlreadyExists(err) {
+		return cleanup, err
+	}
+	return cleanup, nil
+}
@@ -0,0 +1,15 @@
+package rule
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewCmdRule() *cobra.Command {
+	var ruleCmd = &cobra.Command{
+		Use:   "rule",
+		Short: "work with inspect rules.",
+	}
+
+	ruleCmd.AddCommand(NewTestCmd())
+	return ruleCmd
+}
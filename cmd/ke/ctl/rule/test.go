@@ -0,0 +1,76 @@
+package rule
+
+import (
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/ruletest"
+	"github.com/spf13/cobra"
+	"os"
+	"regexp"
+)
+
+type TestOptions struct {
+	Filter string
+	Update bool
+}
+
+func NewTestCmd() *cobra.Command {
+	o := &TestOptions{}
+	testCmd := &cobra.Command{
+		Use:   "test SUITE...",
+		Short: "test inspect rules against fixtures without a cluster",
+		Args:  cobra.MinimumNArgs(1),
+		// a failing case is reported per case, the usage text would only hide it
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run(cmd.Context(), args)
+		},
+	}
+	o.addFlags(testCmd)
+	return testCmd
+}
+
+func (o *TestOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Filter, "run", "", "only run cases whose name matches the regular expression")
+	cmd.Flags().BoolVar(&o.Update, "update", false, "rewrite expected files from the actual findings")
+}
+
+func (o *TestOptions) Run(ctx context.Context, suites []string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var filter *regexp.Regexp
+	if o.Filter != "" {
+		var err error
+		filter, err = regexp.Compile(o.Filter)
+		if err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+	for _, file := range suites {
+		suite, err := ruletest.LoadSuite(file)
+		if err != nil {
+			return err
+		}
+		for _, result := range suite.Run(ctx, filter, o.Update) {
+			switch {
+			case result.Err != nil:
+				failed++
+				fmt.Fprintf(os.Stdout, "ERROR %s: %s\n", result.Name, result.Err)
+			case !result.Passed:
+				failed++
+				fmt.Fprintf(os.Stdout, "FAIL  %s\n%s", result.Name, result.Diff)
+			case o.Update:
+				fmt.Fprintf(os.Stdout, "UPDATED %s\n", result.Name)
+			default:
+				fmt.Fprintf(os.Stdout, "PASS  %s\n", result.Name)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d rule test case(s) failed", failed)
+	}
+	return nil
+}
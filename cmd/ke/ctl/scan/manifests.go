@@ -0,0 +1,80 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/output"
+	"github.com/kubesphere/kubeeye/pkg/rules"
+	"github.com/kubesphere/kubeeye/pkg/scan"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+)
+
+type ManifestsOptions struct {
+	Rules  []string
+	Output string
+	FailOn string
+	Out    io.Writer
+}
+
+func NewManifestsCmd() *cobra.Command {
+	o := &ManifestsOptions{}
+	manifestsCmd := &cobra.Command{
+		Use:   "manifests <dir|file|->...",
+		Short: "run opa rules against Kubernetes manifests, kustomize or helm output (- reads stdin)",
+		Example: `  ke scan manifests -r deploy/rule deploy/
+  helm template ./chart | ke scan manifests -r deploy/rule -o sarif -`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Out = cmd.OutOrStdout()
+			return o.Run(cmd.Context(), args)
+		},
+	}
+	o.addFlags(manifestsCmd)
+	return manifestsCmd
+}
+
+func (o *ManifestsOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVarP(&o.Rules, "rules", "r", nil, "InspectRule file or directory with opa rules, can be repeated")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", scan.FormatJSON, "output format: json, sarif or junit")
+	cmd.Flags().StringVar(&o.FailOn, "fail-on", "danger", "exit with an error when a finding is at least this level: danger, warning, ignore or none")
+	_ = cmd.MarkFlagRequired("rules")
+}
+
+func (o *ManifestsOptions) Run(ctx context.Context, paths []string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	if o.FailOn != "none" && output.LevelRank(o.FailOn) == 0 {
+		return fmt.Errorf("unknown level %q for --fail-on", o.FailOn)
+	}
+	inspectRules, err := rules.LoadRuleFiles(o.Rules...)
+	if err != nil {
+		return err
+	}
+	regoRules := scan.RegoRules(inspectRules)
+	if len(regoRules) == 0 {
+		return fmt.Errorf("no opa rules found in %v", o.Rules)
+	}
+	manifests, err := scan.ReadManifests(paths, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	report := scan.Scan(ctx, manifests, regoRules)
+	if err = report.Write(o.Out, o.Output); err != nil {
+		return err
+	}
+	if o.FailOn == "none" {
+		return nil
+	}
+	if count := report.CountAtLeast(o.FailOn); count > 0 {
+		return fmt.Errorf("found %d finding(s) at or above level %s", count, o.FailOn)
+	}
+	return nil
+}
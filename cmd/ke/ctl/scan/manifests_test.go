@@ -0,0 +1,35 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestManifestsFailOn(t *testing.T) {
+	tests := []struct {
+		failOn  string
+		wantErr bool
+	}{
+		{failOn: "danger"},
+		{failOn: "warning", wantErr: true},
+		{failOn: "ignore", wantErr: true},
+		{failOn: "none"},
+		{failOn: "critical-ish", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.failOn, func(t *testing.T) {
+			var out bytes.Buffer
+			o := &ManifestsOptions{
+				Rules:  []string{"../../../../examples/ruletest/rules/opa.yaml"},
+				Output: "json",
+				FailOn: tt.failOn,
+				Out:    &out,
+			}
+			err := o.Run(context.TODO(), []string{"../../../../examples/ruletest/fixtures/pods.yaml"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
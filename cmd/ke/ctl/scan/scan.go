@@ -0,0 +1,15 @@
+package scan
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewCmdScan() *cobra.Command {
+	var scanCmd = &cobra.Command{
+		Use:   "scan",
+		Short: "scan local files with inspect rules, no cluster needed.",
+	}
+
+	scanCmd.AddCommand(NewManifestsCmd())
+	return scanCmd
+}
@@ -0,0 +1,135 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/clients/clientset/versioned"
+	"github.com/kubesphere/kubeeye/pkg/kube"
+	"github.com/kubesphere/kubeeye/pkg/template"
+	"github.com/spf13/cobra"
+	"io"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"os"
+)
+
+type Options struct {
+	KubeConfig string
+	FailedOnly bool
+	Out        io.Writer
+}
+
+func NewCmdTask() *cobra.Command {
+	var taskCmd = &cobra.Command{
+		Use:   "task",
+		Short: "work with inspect tasks.",
+	}
+
+	taskCmd.AddCommand(NewCancelCmd())
+	taskCmd.AddCommand(NewRerunCmd())
+	return taskCmd
+}
+
+func NewCancelCmd() *cobra.Command {
+	o := &Options{}
+	cancelCmd := &cobra.Command{
+		Use:          "cancel [inspecttask name]",
+		Short:        "stop an inspect task, the results of its finished jobs are kept",
+		Example:      `  ke task cancel inspectplan-20240101-10-30`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.KubeConfig == "" {
+				o.KubeConfig, _ = cmd.Flags().GetString("kube-config")
+			}
+			o.Out = cmd.OutOrStdout()
+			return o.Cancel(cmd.Context(), args[0])
+		},
+	}
+	cancelCmd.Flags().StringVar(&o.KubeConfig, "kubeconfig", "", "path to the kubeconfig of the cluster running kubeeye")
+	return cancelCmd
+}
+
+func NewRerunCmd() *cobra.Command {
+	o := &Options{}
+	rerunCmd := &cobra.Command{
+		Use:   "rerun [inspecttask name]",
+		Short: "create a new inspect task with the spec of a task, under the same plan",
+		Example: `  ke task rerun inspectplan-20240101-10-30
+  ke task rerun inspectplan-20240101-10-30 --failed-only`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.KubeConfig == "" {
+				o.KubeConfig, _ = cmd.Flags().GetString("kube-config")
+			}
+			o.Out = cmd.OutOrStdout()
+			return o.Rerun(cmd.Context(), args[0])
+		},
+	}
+	rerunCmd.Flags().StringVar(&o.KubeConfig, "kubeconfig", "", "path to the kubeconfig of the cluster running kubeeye")
+	rerunCmd.Flags().BoolVar(&o.FailedOnly, "failed-only", false, "only rerun the jobs that failed")
+	return rerunCmd
+}
+
+func (o *Options) Cancel(ctx context.Context, name string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	clients, err := kube.GetK8SClients(o.KubeConfig)
+	if err != nil {
+		return err
+	}
+	return o.cancel(ctx, clients.VersionClientSet, name)
+}
+
+// cancel marks the task to be cancelled, a finished task can not be cancelled.
+func (o *Options) cancel(ctx context.Context, clientSet versioned.Interface, name string) error {
+	task, err := clientSet.KubeeyeV1alpha2().InspectTasks().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if task.Status.Status.IsFinished() {
+		return fmt.Errorf("inspecttask %s has already finished", name)
+	}
+	_, err = clientSet.KubeeyeV1alpha2().InspectTasks().Patch(ctx, name, types.MergePatchType, []byte(`{"spec":{"cancel":true}}`), metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "inspecttask %s cancelled\n", name)
+	return nil
+}
+
+func (o *Options) Rerun(ctx context.Context, name string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	clients, err := kube.GetK8SClients(o.KubeConfig)
+	if err != nil {
+		return err
+	}
+	return o.rerun(ctx, clients.VersionClientSet, name)
+}
+
+func (o *Options) rerun(ctx context.Context, clientSet versioned.Interface, name string) error {
+	task, err := clientSet.KubeeyeV1alpha2().InspectTasks().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	rerun, err := template.RerunTaskTemplate(task, o.FailedOnly)
+	if err != nil {
+		return err
+	}
+	rerun, err = clientSet.KubeeyeV1alpha2().InspectTasks().Create(ctx, rerun, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "inspecttask %s created\n", rerun.Name)
+	return nil
+}
@@ -0,0 +1,101 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	versionedfake "github.com/kubesphere/kubeeye/clients/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testTasks returns a clientset serving a running and a finished task. The tasks are created through the clientset,
+// the generated fake tracks them under its own group.
+func testTasks(t *testing.T) *versionedfake.Clientset {
+	clientSet := versionedfake.NewSimpleClientset()
+	for _, task := range []*v1alpha2.InspectTask{
+		{ObjectMeta: metav1.ObjectMeta{Name: "running"}, Status: v1alpha2.InspectTaskStatus{Status: v1alpha2.PhaseRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "finished"}, Status: v1alpha2.InspectTaskStatus{
+			Status:   v1alpha2.PhaseFailed,
+			JobPhase: []v1alpha2.JobPhase{{JobName: "sysctl-node1", Cluster: "default", RuleType: "sysctl", NodeName: "node1", Phase: v1alpha2.PhaseFailed}},
+		}},
+	} {
+		if _, err := clientSet.KubeeyeV1alpha2().InspectTasks().Create(context.TODO(), task, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return clientSet
+}
+
+func TestCancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "running"},
+		{name: "finished", wantErr: true},
+		{name: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientSet := testTasks(t)
+			var out bytes.Buffer
+			o := &Options{Out: &out}
+			err := o.cancel(context.TODO(), clientSet, tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cancel() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			task, err := clientSet.KubeeyeV1alpha2().InspectTasks().Get(context.TODO(), tt.name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !task.Spec.Cancel || out.String() != "inspecttask running cancelled\n" {
+				t.Errorf("cancel = %v, output %q", task.Spec.Cancel, out.String())
+			}
+		})
+	}
+}
+
+func TestRerun(t *testing.T) {
+	tests := []struct {
+		name       string
+		failedOnly bool
+		wantJobs   int
+		wantErr    bool
+	}{
+		{name: "finished"},
+		{name: "finished", failedOnly: true, wantJobs: 1},
+		{name: "running", failedOnly: true, wantErr: true},
+		{name: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientSet := testTasks(t)
+			var out bytes.Buffer
+			o := &Options{FailedOnly: tt.failedOnly, Out: &out}
+			err := o.rerun(context.TODO(), clientSet, tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rerun() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			name := strings.TrimSuffix(strings.TrimPrefix(out.String(), "inspecttask "), " created\n")
+			if !strings.HasPrefix(name, "finished-") {
+				t.Fatalf("output %q should name the rerun task, named after the task", out.String())
+			}
+			task, err := clientSet.KubeeyeV1alpha2().InspectTasks().Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("the rerun task should be created: %s", err)
+			}
+			if len(task.Spec.Jobs) != tt.wantJobs {
+				t.Errorf("rerun task %s has %d jobs, want %d", task.Name, len(task.Spec.Jobs), tt.wantJobs)
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package conf
 
 import (
+	"fmt"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"time"
 )
 
@@ -40,8 +42,16 @@ type KubeEyeConfig struct {
 type MessageType string
 
 const (
-	AlarmMessage MessageType = "alarm"
-	EmailMessage MessageType = "email"
+	AlarmMessage    MessageType = "alarm"
+	EmailMessage    MessageType = "email"
+	WebhookMessage  MessageType = "webhook"
+	SlackMessage    MessageType = "slack"
+	DingTalkMessage MessageType = "dingtalk"
+	WeComMessage    MessageType = "wecom"
+	FeishuMessage   MessageType = "feishu"
+	TeamsMessage    MessageType = "teams"
+	// AlertmanagerMessage posts every danger and warning finding as an alert
+	AlertmanagerMessage MessageType = "alertmanager"
 )
 
 type Mode string
@@ -55,14 +65,207 @@ type MessageConfig struct {
 	Enable bool        `json:"enable,omitempty"`
 	Type   MessageType `json:"type,omitempty"`
 	Mode   Mode        `json:"mode,omitempty"`
-	Email  EmailConfig `json:"email,omitempty"`
+	// Format is the body format of notifications: html (default), markdown or text
+	Format string `json:"format,omitempty"`
+	// Language of the notification titles: zh (default) or en
+	Language string        `json:"language,omitempty"`
+	Email    EmailConfig   `json:"email,omitempty"`
+	Webhook  WebhookConfig `json:"webhook,omitempty"`
+	// ReportURL is the external address of kubeeye-apiserver, chat notifications link the report through it
+	ReportURL string `json:"reportURL,omitempty"`
+	// Channels are notified side by side, when it is empty Type, Email and Webhook make up the only channel
+	Channels []ChannelConfig `json:"channels,omitempty"`
+	// Routes choose the channels of a result by plan, cluster, level and rule type, every channel is notified when it is empty
+	Routes []RouteConfig `json:"routes,omitempty"`
 }
+
+// RouteConfig matches results like an Alertmanager route. The empty matchers match everything. A matching route hands
+// the result to its child routes and notifies its own receivers only when no child matches. The routes after a
+// matching one are skipped unless it sets Continue.
+type RouteConfig struct {
+	// Receivers are the names of the channels notified
+	Receivers []string `json:"receivers,omitempty"`
+	// Plans are the plan names matched
+	Plans []string `json:"plans,omitempty"`
+	// PlanSelector matches the labels of the plan
+	PlanSelector *metav1.LabelSelector `json:"planSelector,omitempty"`
+	Clusters     []string              `json:"clusters,omitempty"`
+	// MinLevel keeps the findings at this level or more severe: danger, warning or ignore
+	MinLevel string `json:"minLevel,omitempty"`
+	// RuleTypes keeps the findings of these rule types, e.g. opa or sysctl
+	RuleTypes []string `json:"ruleTypes,omitempty"`
+	// NewFindingsOnly keeps the findings missing from the previous result of the same plan and cluster
+	NewFindingsOnly bool `json:"newFindingsOnly,omitempty"`
+	// Continue evaluates the next routes after this one matched
+	Continue bool          `json:"continue,omitempty"`
+	Routes   []RouteConfig `json:"routes,omitempty"`
+}
+
+type ChannelConfig struct {
+	Name    string         `json:"name,omitempty"`
+	Type    MessageType    `json:"type,omitempty"`
+	Email   *EmailConfig   `json:"email,omitempty"`
+	Webhook *WebhookConfig `json:"webhook,omitempty"`
+	// Chat configures the slack, dingtalk, wecom, feishu and teams robots
+	Chat         *ChatConfig         `json:"chat,omitempty"`
+	Alertmanager *AlertmanagerConfig `json:"alertmanager,omitempty"`
+	Throttle     *ThrottleConfig     `json:"throttle,omitempty"`
+	// Digest replaces the notification of every result with a summary of the results sent on a schedule
+	Digest *DigestConfig `json:"digest,omitempty"`
+}
+
+type ThrottleConfig struct {
+	// SuppressUnchanged skips a result with the same findings as the last one sent for its plan and cluster
+	SuppressUnchanged bool `json:"suppressUnchanged,omitempty"`
+	// MinInterval is the least time between two notifications, e.g. 1h
+	MinInterval string `json:"minInterval,omitempty"`
+}
+
+type DigestConfig struct {
+	// Schedule is a cron expression or a descriptor such as @daily or @weekly
+	Schedule string `json:"schedule,omitempty"`
+}
+
+type AlertmanagerConfig struct {
+	// URL is the address of Alertmanager, alerts are posted to its /api/v2/alerts
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// ResolveTimeout is how long an alert lasts after an inspection, it should be longer than the plan interval. Default 1h
+	ResolveTimeout string `json:"resolveTimeout,omitempty"`
+	// Labels are added to every alert
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type ChatConfig struct {
+	// URL is the incoming webhook of the robot
+	URL string `json:"url,omitempty"`
+	// SecretKey is the name of a Secret holding the robot sign secret in signSecret, only used by dingtalk and feishu
+	SecretKey string `json:"secretKey,omitempty"`
+	// Top is the number of findings listed in the message, default 5
+	Top int `json:"top,omitempty"`
+}
+
+// GetChannels returns the configured channels, or the legacy single channel when none is configured. A channel
+// without a name is named after its type and zero-based position among all channels, e.g. the first channel of
+// [email, slack] is email-0 and the second slack-1, since deliveries are keyed by the name. Such a name changes
+// when the list is reordered, so channels with notification state (throttle or digest) must be named.
+func (m *MessageConfig) GetChannels() []ChannelConfig {
+	if len(m.Channels) > 0 {
+		channels := make([]ChannelConfig, len(m.Channels))
+		for i, channel := range m.Channels {
+			if channel.Name == "" {
+				channelType := channel.Type
+				if channelType == "" {
+					channelType = EmailMessage
+				}
+				channel.Name = fmt.Sprintf("%s-%d", channelType, i)
+			}
+			channels[i] = channel
+		}
+		return channels
+	}
+	channel := ChannelConfig{Name: string(m.Type), Type: m.Type}
+	switch m.Type {
+	case WebhookMessage, AlarmMessage:
+		channel.Webhook = &m.Webhook
+	case SlackMessage, DingTalkMessage, WeComMessage, FeishuMessage, TeamsMessage:
+		channel.Chat = &ChatConfig{URL: m.Webhook.URL, SecretKey: m.Webhook.SecretKey}
+	case AlertmanagerMessage:
+		channel.Alertmanager = &AlertmanagerConfig{URL: m.Webhook.URL, Headers: m.Webhook.Headers}
+	default:
+		channel.Type = EmailMessage
+		channel.Email = &m.Email
+	}
+	if channel.Name == "" {
+		channel.Name = string(channel.Type)
+	}
+	return []ChannelConfig{channel}
+}
+
+// Validate rejects channels sharing a name, they would receive each other's notifications, and unnamed
+// channels with throttle or digest, whose state would move to another channel when the list is reordered.
+func (m *MessageConfig) Validate() error {
+	for i, channel := range m.Channels {
+		if channel.Name == "" && (channel.Throttle != nil || channel.Digest != nil) {
+			return fmt.Errorf("message channel %d sets throttle or digest and needs a name", i)
+		}
+	}
+	names := map[string]bool{}
+	for _, channel := range m.GetChannels() {
+		if names[channel.Name] {
+			return fmt.Errorf("duplicate message channel name %s", channel.Name)
+		}
+		names[channel.Name] = true
+	}
+	return nil
+}
+
 type EmailConfig struct {
-	Address   string   `json:"address,omitempty"`
-	Port      int32    `json:"port,omitempty"`
-	Fo        string   `json:"fo,omitempty"`
-	To        []string `json:"to,omitempty"`
-	SecretKey string   `json:"secretKey,omitempty"`
+	Address string   `json:"address,omitempty"`
+	Port    int32    `json:"port,omitempty"`
+	Fo      string   `json:"fo,omitempty"`
+	To      []string `json:"to,omitempty"`
+	Cc      []string `json:"cc,omitempty"`
+	// Bcc receive the email without being listed in its headers
+	Bcc []string `json:"bcc,omitempty"`
+	// SecretKey is the name of a Secret in the kubeeye namespace holding username and password.
+	// Deprecated: use CredentialsRef
+	SecretKey string `json:"secretKey,omitempty"`
+	// CredentialsRef is the Secret holding the SMTP credentials, no authentication is done when both it and SecretKey are empty
+	CredentialsRef *CredentialsRef `json:"credentialsRef,omitempty"`
+	// TLS is tls (implicit TLS, the default for port 465), starttls or none. When it is empty on other ports,
+	// STARTTLS is used if the server offers it
+	TLS string `json:"tls,omitempty"`
+	// ServerName verifies the server certificate, default Address
+	ServerName string `json:"serverName,omitempty"`
+	// CARef is a Secret key holding the PEM CA certificates that verify the server, default the system roots
+	CARef              *SecretKeyRef `json:"caRef,omitempty"`
+	InsecureSkipVerify bool          `json:"insecureSkipVerify,omitempty"`
+	// Subject is a Go template of the subject, executed with .Title, .Cluster, .Plan, .Score, .Total and .Levels
+	Subject string `json:"subject,omitempty"`
+	// Attachments are report formats attached to the email, e.g. pdf, xlsx or csv
+	Attachments []string `json:"attachments,omitempty"`
+}
+
+const (
+	EmailTLS      = "tls"
+	EmailStartTLS = "starttls"
+	EmailNoTLS    = "none"
+)
+
+type CredentialsRef struct {
+	Name string `json:"name"`
+	// Namespace defaults to the kubeeye namespace
+	Namespace string `json:"namespace,omitempty"`
+	// UsernameKey defaults to username
+	UsernameKey string `json:"usernameKey,omitempty"`
+	// PasswordKey defaults to password
+	PasswordKey string `json:"passwordKey,omitempty"`
+}
+
+type SecretKeyRef struct {
+	Name string `json:"name"`
+	// Namespace defaults to the kubeeye namespace
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
+type WebhookConfig struct {
+	URL string `json:"url,omitempty"`
+	// Method defaults to POST
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is a Go template of the request body, executed with the title, summary and top findings of the result.
+	// The summary is sent as JSON when it is empty.
+	Body string `json:"body,omitempty"`
+	// SecretKey is the name of a Secret holding the HMAC-SHA256 signing key in hmacKey, the body is not signed when it is empty
+	SecretKey string `json:"secretKey,omitempty"`
+	// Timeout of a request, default 10s
+	Timeout string `json:"timeout,omitempty"`
+	// Retries after a failed request, default 3. Connection errors, 429 and 5xx responses are retried
+	Retries *int `json:"retries,omitempty"`
+	// Backoff is the wait before the first retry, doubled for every next one, default 1s
+	Backoff string `json:"backoff,omitempty"`
 }
 
 type JobConfig struct {
@@ -98,11 +301,50 @@ func (j *JobConfig) DeepCopy() *JobConfig {
 }
 
 type MessageEvent struct {
-	Title     string
-	Content   []byte
-	Timestamp time.Time
+	Title   string
+	Content []byte
+	// ContentType is the MIME type of Content, text/html when empty
+	ContentType string
+	Timestamp   time.Time
+	Attachments []Attachment
+	// Summary and Findings describe the result for notifiers that build their own payload, Findings are all the
+	// findings routed to the channel, the most severe first
+	Summary  *MessageSummary
+	Findings []MessageFinding
+	// ReportURL links the report of the result, empty when MessageConfig.ReportURL is not set
+	ReportURL string
+}
+
+// MessageSummary is the summary of an inspect result sent with notifications.
+type MessageSummary struct {
+	Cluster string `json:"cluster"`
+	Result  string `json:"result"`
+	Plan    string `json:"plan,omitempty"`
+	Task    string `json:"task,omitempty"`
+	Score   int    `json:"score"`
+	Total   int    `json:"total"`
+	// Levels counts the findings by level, RuleTypes by rule type
+	Levels    map[string]int `json:"levels"`
+	RuleTypes map[string]int `json:"ruleTypes"`
+}
+
+type MessageFinding struct {
+	RuleType  string `json:"ruleType"`
+	Name      string `json:"name"`
+	Level     string `json:"level"`
+	Node      string `json:"node,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Resource  string `json:"resource,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
 }
 
 type EventHandler interface {
-	HandleMessageEvent(event *MessageEvent)
+	// HandleMessageEvent sends the event and returns the error of a failed delivery.
+	HandleMessageEvent(event *MessageEvent) error
 }
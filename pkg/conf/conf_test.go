@@ -0,0 +1,103 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func channelNames(channels []ChannelConfig) []string {
+	var names []string
+	for _, channel := range channels {
+		names = append(names, channel.Name)
+	}
+	return names
+}
+
+func TestGetChannels(t *testing.T) {
+	tests := []struct {
+		name    string
+		message MessageConfig
+		want    []string
+		wantErr bool
+	}{{
+		name:    "legacy channel",
+		message: MessageConfig{Type: SlackMessage},
+		want:    []string{"slack"},
+	}, {
+		name:    "legacy email channel",
+		message: MessageConfig{},
+		want:    []string{"email"},
+	}, {
+		name: "unnamed channels",
+		message: MessageConfig{Channels: []ChannelConfig{
+			{Type: SlackMessage},
+			{Type: EmailMessage},
+			{},
+			{Type: SlackMessage},
+		}},
+		want: []string{"slack-0", "email-1", "email-2", "slack-3"},
+	}, {
+		name: "named channels",
+		message: MessageConfig{Channels: []ChannelConfig{
+			{Name: "ops", Type: SlackMessage},
+			{Type: EmailMessage},
+		}},
+		want: []string{"ops", "email-1"},
+	}, {
+		name: "duplicate names",
+		message: MessageConfig{Channels: []ChannelConfig{
+			{Name: "ops", Type: SlackMessage},
+			{Name: "ops", Type: EmailMessage},
+		}},
+		want:    []string{"ops", "ops"},
+		wantErr: true,
+	}, {
+		name: "a name taken by an unnamed channel",
+		message: MessageConfig{Channels: []ChannelConfig{
+			{Type: SlackMessage},
+			{Name: "slack-0", Type: SlackMessage},
+		}},
+		want:    []string{"slack-0", "slack-0"},
+		wantErr: true,
+	}, {
+		name: "unnamed channel with throttle",
+		message: MessageConfig{Channels: []ChannelConfig{
+			{Type: SlackMessage, Throttle: &ThrottleConfig{MinInterval: "1h"}},
+		}},
+		want:    []string{"slack-0"},
+		wantErr: true,
+	}, {
+		name: "unnamed channel with digest",
+		message: MessageConfig{Channels: []ChannelConfig{
+			{Name: "ops", Type: SlackMessage},
+			{Type: EmailMessage, Digest: &DigestConfig{Schedule: "@daily"}},
+		}},
+		want:    []string{"ops", "email-1"},
+		wantErr: true,
+	}, {
+		name: "named channels with throttle and digest",
+		message: MessageConfig{Channels: []ChannelConfig{
+			{Name: "ops", Type: SlackMessage, Throttle: &ThrottleConfig{SuppressUnchanged: true}},
+			{Name: "weekly", Type: EmailMessage, Digest: &DigestConfig{Schedule: "@weekly"}},
+		}},
+		want: []string{"ops", "weekly"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := channelNames(tt.message.GetChannels()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetChannels() names = %v, want %v", got, tt.want)
+			}
+			if err := tt.message.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetChannelsKeepsConfig(t *testing.T) {
+	message := MessageConfig{Channels: []ChannelConfig{{Type: SlackMessage}}}
+	message.GetChannels()
+	if message.Channels[0].Name != "" {
+		t.Errorf("GetChannels() changed the configured channel to %q", message.Channels[0].Name)
+	}
+}
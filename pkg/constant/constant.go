@@ -15,6 +15,9 @@ const (
 var SystemNamespaces = []string{"kubeeye-system", "kubesphere-system", "kubesphere-logging-system", "kubesphere-monitoring-system", "openpitrix-system", "kube-system", "istio-system", "kubesphere-devops-system", "porter-system"}
 
 const BaseFilePrefix = "kubeeye-base-file"
+
+// ReportTemplate is the config-type label value of ConfigMaps holding report templates.
+const ReportTemplate = "report-template"
 const (
 	Opa            = "opa"
 	FileChange     = "filechange"
@@ -61,6 +64,10 @@ const (
 	AnnotationDescription   = "kubeeye.kubesphere.io/description"
 	AnnotationInspectType   = "kubeeye.kubesphere.io/inspect-type"
 	AnnotationInspectIgnore = "kubeeye.kubesphere.io/inspect-ignore"
+	// AnnotationRerunOf is the task a task reruns
+	AnnotationRerunOf = "kubeeye.kubesphere.io/rerun-of"
+	// AnnotationMergedJobs lists the jobs merged into a result file, so that a job is merged once
+	AnnotationMergedJobs = "kubeeye.kubesphere.io/merged-jobs"
 )
 
 const (
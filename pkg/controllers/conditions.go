@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"fmt"
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// setCondition sets the condition, its transition time only changes with its status. It returns whether the
+// condition has changed.
+func setCondition(conditions *[]metav1.Condition, generation int64, conditionType string, status metav1.ConditionStatus, reason string, message string) bool {
+	return meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// setTaskConditions sets the Running, Completed and Degraded conditions of a task from its phase and jobs.
+func setTaskConditions(conditions *[]metav1.Condition, generation int64, phase kubeeyev1alpha2.Phase, jobs []kubeeyev1alpha2.JobPhase) {
+	if phase.IsRunning() || phase.IsPending() {
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionRunning, metav1.ConditionTrue, kubeeyev1alpha2.ReasonStarted, "the inspection is running")
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionCompleted, metav1.ConditionFalse, kubeeyev1alpha2.ReasonStarted, "the inspection is running")
+		return
+	}
+	failed := 0
+	for _, job := range jobs {
+		if job.Phase.IsFailed() {
+			failed++
+		}
+	}
+	reason := kubeeyev1alpha2.ReasonSucceeded
+	if phase.IsFailed() {
+		reason = kubeeyev1alpha2.ReasonFailed
+	} else if phase.IsCancelled() {
+		reason = kubeeyev1alpha2.ReasonCancelled
+	} else if phase.IsPartiallySucceeded() {
+		reason = kubeeyev1alpha2.ReasonPartiallySucceeded
+	}
+	message := fmt.Sprintf("%d of %d jobs failed", failed, len(jobs))
+	setCondition(conditions, generation, kubeeyev1alpha2.ConditionRunning, metav1.ConditionFalse, reason, "the inspection has finished")
+	setCondition(conditions, generation, kubeeyev1alpha2.ConditionCompleted, metav1.ConditionTrue, reason, message)
+	switch {
+	case failed > 0:
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionDegraded, metav1.ConditionTrue, kubeeyev1alpha2.ReasonJobFailed, message)
+	case len(jobs) == 0:
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionDegraded, metav1.ConditionTrue, kubeeyev1alpha2.ReasonFailed, "no inspection job was run")
+	default:
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionDegraded, metav1.ConditionFalse, kubeeyev1alpha2.ReasonHealthy, message)
+	}
+}
+
+// setPlanTaskConditions mirrors the phase of the last task of a plan in its Running, Completed and Degraded conditions.
+func setPlanTaskConditions(plan *kubeeyev1alpha2.InspectPlan, phase kubeeyev1alpha2.Phase, taskName string) {
+	conditions, generation := &plan.Status.Conditions, plan.Generation
+	switch {
+	case phase.IsRunning():
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionRunning, metav1.ConditionTrue, kubeeyev1alpha2.ReasonStarted, fmt.Sprintf("task %s is running", taskName))
+	case phase.IsSucceeded():
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionRunning, metav1.ConditionFalse, kubeeyev1alpha2.ReasonSucceeded, fmt.Sprintf("task %s has finished", taskName))
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionCompleted, metav1.ConditionTrue, kubeeyev1alpha2.ReasonSucceeded, fmt.Sprintf("task %s succeeded", taskName))
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionDegraded, metav1.ConditionFalse, kubeeyev1alpha2.ReasonHealthy, fmt.Sprintf("task %s succeeded", taskName))
+	case phase.IsFailed():
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionRunning, metav1.ConditionFalse, kubeeyev1alpha2.ReasonFailed, fmt.Sprintf("task %s has finished", taskName))
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionCompleted, metav1.ConditionTrue, kubeeyev1alpha2.ReasonFailed, fmt.Sprintf("task %s failed", taskName))
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionDegraded, metav1.ConditionTrue, kubeeyev1alpha2.ReasonFailed, fmt.Sprintf("task %s failed, see its events", taskName))
+	case phase.IsPartiallySucceeded():
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionRunning, metav1.ConditionFalse, kubeeyev1alpha2.ReasonPartiallySucceeded, fmt.Sprintf("task %s has finished", taskName))
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionCompleted, metav1.ConditionTrue, kubeeyev1alpha2.ReasonPartiallySucceeded, fmt.Sprintf("task %s partially succeeded", taskName))
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionDegraded, metav1.ConditionTrue, kubeeyev1alpha2.ReasonJobFailed, fmt.Sprintf("some jobs of task %s failed, see its events", taskName))
+	case phase.IsCancelled():
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionRunning, metav1.ConditionFalse, kubeeyev1alpha2.ReasonCancelled, fmt.Sprintf("task %s has finished", taskName))
+		setCondition(conditions, generation, kubeeyev1alpha2.ConditionCompleted, metav1.ConditionTrue, kubeeyev1alpha2.ReasonCancelled, fmt.Sprintf("task %s was cancelled", taskName))
+	}
+}
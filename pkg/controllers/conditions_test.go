@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// conditionStates maps each condition type to its status, reason and message.
+func conditionStates(conditions []metav1.Condition) map[string][3]string {
+	states := map[string][3]string{}
+	for _, c := range conditions {
+		states[c.Type] = [3]string{string(c.Status), c.Reason, c.Message}
+	}
+	return states
+}
+
+func TestSetCondition(t *testing.T) {
+	var conditions []metav1.Condition
+	if !setCondition(&conditions, 1, kubeeyev1alpha2.ConditionRunning, metav1.ConditionTrue, kubeeyev1alpha2.ReasonStarted, "running") {
+		t.Fatal("adding a condition should report a change")
+	}
+	transition := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	conditions[0].LastTransitionTime = transition
+
+	if setCondition(&conditions, 1, kubeeyev1alpha2.ConditionRunning, metav1.ConditionTrue, kubeeyev1alpha2.ReasonStarted, "running") {
+		t.Error("setting the same condition should not report a change")
+	}
+	if !setCondition(&conditions, 2, kubeeyev1alpha2.ConditionRunning, metav1.ConditionTrue, kubeeyev1alpha2.ReasonStarted, "still running") {
+		t.Error("a new message should report a change")
+	}
+	if c := conditions[0]; !c.LastTransitionTime.Equal(&transition) || c.ObservedGeneration != 2 || c.Message != "still running" {
+		t.Errorf("condition %+v should keep its transition time while its status is unchanged", c)
+	}
+
+	if !setCondition(&conditions, 2, kubeeyev1alpha2.ConditionRunning, metav1.ConditionFalse, kubeeyev1alpha2.ReasonSucceeded, "done") {
+		t.Error("a new status should report a change")
+	}
+	if c := conditions[0]; c.LastTransitionTime.Equal(&transition) {
+		t.Errorf("condition %+v should move its transition time with its status", c)
+	}
+	if len(conditions) != 1 {
+		t.Errorf("got %d conditions, want the condition updated in place", len(conditions))
+	}
+}
+
+func TestSetTaskConditions(t *testing.T) {
+	jobs := func(phases ...kubeeyev1alpha2.Phase) []kubeeyev1alpha2.JobPhase {
+		var list []kubeeyev1alpha2.JobPhase
+		for _, phase := range phases {
+			list = append(list, kubeeyev1alpha2.JobPhase{Phase: phase})
+		}
+		return list
+	}
+	running := [3]string{"True", kubeeyev1alpha2.ReasonStarted, "the inspection is running"}
+	notCompleted := [3]string{"False", kubeeyev1alpha2.ReasonStarted, "the inspection is running"}
+	tests := []struct {
+		name  string
+		phase kubeeyev1alpha2.Phase
+		jobs  []kubeeyev1alpha2.JobPhase
+		want  map[string][3]string
+	}{{
+		name:  "pending",
+		phase: kubeeyev1alpha2.PhasePending,
+		want:  map[string][3]string{kubeeyev1alpha2.ConditionRunning: running, kubeeyev1alpha2.ConditionCompleted: notCompleted},
+	}, {
+		name:  "running",
+		phase: kubeeyev1alpha2.PhaseRunning,
+		jobs:  jobs(kubeeyev1alpha2.PhaseRunning),
+		want:  map[string][3]string{kubeeyev1alpha2.ConditionRunning: running, kubeeyev1alpha2.ConditionCompleted: notCompleted},
+	}, {
+		name:  "succeeded",
+		phase: kubeeyev1alpha2.PhaseSucceeded,
+		jobs:  jobs(kubeeyev1alpha2.PhaseSucceeded, kubeeyev1alpha2.PhaseSucceeded),
+		want: map[string][3]string{
+			kubeeyev1alpha2.ConditionRunning:   {"False", kubeeyev1alpha2.ReasonSucceeded, "the inspection has finished"},
+			kubeeyev1alpha2.ConditionCompleted: {"True", kubeeyev1alpha2.ReasonSucceeded, "0 of 2 jobs failed"},
+			kubeeyev1alpha2.ConditionDegraded:  {"False", kubeeyev1alpha2.ReasonHealthy, "0 of 2 jobs failed"},
+		},
+	}, {
+		name:  "partially succeeded",
+		phase: kubeeyev1alpha2.PhasePartiallySucceeded,
+		jobs:  jobs(kubeeyev1alpha2.PhaseSucceeded, kubeeyev1alpha2.PhaseFailed),
+		want: map[string][3]string{
+			kubeeyev1alpha2.ConditionRunning:   {"False", kubeeyev1alpha2.ReasonPartiallySucceeded, "the inspection has finished"},
+			kubeeyev1alpha2.ConditionCompleted: {"True", kubeeyev1alpha2.ReasonPartiallySucceeded, "1 of 2 jobs failed"},
+			kubeeyev1alpha2.ConditionDegraded:  {"True", kubeeyev1alpha2.ReasonJobFailed, "1 of 2 jobs failed"},
+		},
+	}, {
+		name:  "failed",
+		phase: kubeeyev1alpha2.PhaseFailed,
+		jobs:  jobs(kubeeyev1alpha2.PhaseFailed),
+		want: map[string][3]string{
+			kubeeyev1alpha2.ConditionRunning:   {"False", kubeeyev1alpha2.ReasonFailed, "the inspection has finished"},
+			kubeeyev1alpha2.ConditionCompleted: {"True", kubeeyev1alpha2.ReasonFailed, "1 of 1 jobs failed"},
+			kubeeyev1alpha2.ConditionDegraded:  {"True", kubeeyev1alpha2.ReasonJobFailed, "1 of 1 jobs failed"},
+		},
+	}, {
+		name:  "failed without jobs",
+		phase: kubeeyev1alpha2.PhaseFailed,
+		want: map[string][3]string{
+			kubeeyev1alpha2.ConditionRunning:   {"False", kubeeyev1alpha2.ReasonFailed, "the inspection has finished"},
+			kubeeyev1alpha2.ConditionCompleted: {"True", kubeeyev1alpha2.ReasonFailed, "0 of 0 jobs failed"},
+			kubeeyev1alpha2.ConditionDegraded:  {"True", kubeeyev1alpha2.ReasonFailed, "no inspection job was run"},
+		},
+	}, {
+		name:  "cancelled",
+		phase: kubeeyev1alpha2.PhaseCancelled,
+		jobs:  jobs(kubeeyev1alpha2.PhaseSucceeded, kubeeyev1alpha2.PhaseCancelled),
+		want: map[string][3]string{
+			kubeeyev1alpha2.ConditionRunning:   {"False", kubeeyev1alpha2.ReasonCancelled, "the inspection has finished"},
+			kubeeyev1alpha2.ConditionCompleted: {"True", kubeeyev1alpha2.ReasonCancelled, "0 of 2 jobs failed"},
+			kubeeyev1alpha2.ConditionDegraded:  {"False", kubeeyev1alpha2.ReasonHealthy, "0 of 2 jobs failed"},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conditions []metav1.Condition
+			setTaskConditions(&conditions, 3, tt.phase, tt.jobs)
+			if got := conditionStates(conditions); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("conditions = %v, want %v", got, tt.want)
+			}
+			for _, c := range conditions {
+				if c.ObservedGeneration != 3 {
+					t.Errorf("condition %s observed generation %d, want 3", c.Type, c.ObservedGeneration)
+				}
+			}
+		})
+	}
+}
+
+func TestSetTaskConditionsAfterRunning(t *testing.T) {
+	var conditions []metav1.Condition
+	setTaskConditions(&conditions, 1, kubeeyev1alpha2.PhaseRunning, nil)
+	setTaskConditions(&conditions, 1, kubeeyev1alpha2.PhaseSucceeded, []kubeeyev1alpha2.JobPhase{{Phase: kubeeyev1alpha2.PhaseSucceeded}})
+	if !meta.IsStatusConditionFalse(conditions, kubeeyev1alpha2.ConditionRunning) || !meta.IsStatusConditionTrue(conditions, kubeeyev1alpha2.ConditionCompleted) {
+		t.Errorf("a finished task should not be running: %+v", conditions)
+	}
+	if len(conditions) != 3 {
+		t.Errorf("got %d conditions, want Running, Completed and Degraded", len(conditions))
+	}
+}
+
+func TestSetPlanTaskConditions(t *testing.T) {
+	tests := []struct {
+		name  string
+		phase kubeeyev1alpha2.Phase
+		want  map[string][3]string
+	}{{
+		name:  "running",
+		phase: kubeeyev1alpha2.PhaseRunning,
+		want: map[string][3]string{
+			kubeeyev1alpha2.ConditionRunning:   {"True", kubeeyev1alpha2.ReasonStarted, "task daily-1 is running"},
+			kubeeyev1alpha2.ConditionCompleted: {"True", kubeeyev1alpha2.ReasonSucceeded, "task daily-0 succeeded"},
+			kubeeyev1alpha2.ConditionDegraded:  {"False", kubeeyev1alpha2.ReasonHealthy, "task daily-0 succeeded"},
+		},
+	}, {
+		name:  "pending keeps the previous task",
+		phase: kubeeyev1alpha2.PhasePending,
+		want: map[string][3]string{
+			kubeeyev1alpha2.ConditionRunning:   {"False", kubeeyev1alpha2.ReasonSucceeded, "task daily-0 has finished"},
+			kubeeyev1alpha2.ConditionCompleted: {"True", kubeeyev1alpha2.ReasonSucceeded, "task daily-0 succeeded"},
+			kubeeyev1alpha2.ConditionDegraded:  {"False", kubeeyev1alpha2.ReasonHealthy, "task daily-0 succeeded"},
+		},
+	}, {
+		name:  "succeeded",
+		phase: kubeeyev1alpha2.PhaseSucceeded,
+		want: map[string][3]string{
+			kubeeyev1alpha2.ConditionRunning:   {"False", kubeeyev1alpha2.ReasonSucceeded, "task daily-1 has finished"},
+			kubeeyev1alpha2.ConditionCompleted: {"True", kubeeyev1alpha2.ReasonSucceeded, "task daily-1 succeeded"},
+			kubeeyev1alpha2.ConditionDegraded:  {"False", kubeeyev1alpha2.ReasonHealthy, "task daily-1 succeeded"},
+		},
+	}, {
+		name:  "failed",
+		phase: kubeeyev1alpha2.PhaseFailed,
+		want: map[string][3]string{
+			kubeeyev1alpha2.ConditionRunning:   {"False", kubeeyev1alpha2.ReasonFailed, "task daily-1 has finished"},
+			kubeeyev1alpha2.ConditionCompleted: {"True", kubeeyev1alpha2.ReasonFailed, "task daily-1 failed"},
+			kubeeyev1alpha2.ConditionDegraded:  {"True", kubeeyev1alpha2.ReasonFailed, "task daily-1 failed, see its events"},
+		},
+	}, {
+		name:  "partially succeeded",
+		phase: kubeeyev1alpha2.PhasePartiallySucceeded,
+		want: map[string][3]string{
+			kubeeyev1alpha2.ConditionRunning:   {"False", kubeeyev1alpha2.ReasonPartiallySucceeded, "task daily-1 has finished"},
+			kubeeyev1alpha2.ConditionCompleted: {"True", kubeeyev1alpha2.ReasonPartiallySucceeded, "task daily-1 partially succeeded"},
+			kubeeyev1alpha2.ConditionDegraded:  {"True", kubeeyev1alpha2.ReasonJobFailed, "some jobs of task daily-1 failed, see its events"},
+		},
+	}, {
+		name:  "cancelled keeps the degraded condition",
+		phase: kubeeyev1alpha2.PhaseCancelled,
+		want: map[string][3]string{
+			kubeeyev1alpha2.ConditionRunning:   {"False", kubeeyev1alpha2.ReasonCancelled, "task daily-1 has finished"},
+			kubeeyev1alpha2.ConditionCompleted: {"True", kubeeyev1alpha2.ReasonCancelled, "task daily-1 was cancelled"},
+			kubeeyev1alpha2.ConditionDegraded:  {"False", kubeeyev1alpha2.ReasonHealthy, "task daily-0 succeeded"},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &kubeeyev1alpha2.InspectPlan{ObjectMeta: metav1.ObjectMeta{Name: "daily", Generation: 2}}
+			// the previous task of the plan succeeded
+			setPlanTaskConditions(plan, kubeeyev1alpha2.PhaseSucceeded, "daily-0")
+			setPlanTaskConditions(plan, tt.phase, "daily-1")
+			if got := conditionStates(plan.Status.Conditions); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("conditions = %v, want %v", got, tt.want)
+			}
+			for _, c := range plan.Status.Conditions {
+				if c.ObservedGeneration != 2 {
+					t.Errorf("condition %s observed generation %d, want the plan generation", c.Type, c.ObservedGeneration)
+				}
+			}
+		})
+	}
+}
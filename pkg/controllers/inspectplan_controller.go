@@ -23,8 +23,12 @@ import (
 	"github.com/kubesphere/kubeeye/pkg/constant"
 	"github.com/kubesphere/kubeeye/pkg/kube"
 	"github.com/kubesphere/kubeeye/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -46,6 +50,9 @@ type InspectPlanReconciler struct {
 	K8sClient      *kube.KubernetesClient
 	Scheme         *runtime.Scheme
 	KubeEyeFactory kubeeyeInformers.Interface
+	Recorder       record.EventRecorder
+	// Clock is the time of the schedules, the real time when not set
+	Clock clock.PassiveClock
 }
 
 const Finalizers = "kubeeye.finalizers.kubesphere.io"
@@ -53,6 +60,7 @@ const Finalizers = "kubeeye.finalizers.kubesphere.io"
 //+kubebuilder:rbac:groups=kubeeye.kubesphere.io,resources=inspectplans,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=kubeeye.kubesphere.io,resources=inspectplans/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=kubeeye.kubesphere.io,resources=inspectplans/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -127,6 +135,13 @@ func (r *InspectPlanReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	if plan.Spec.Suspend {
 		klog.Info("inspect plan suspend")
+		if setCondition(&plan.Status.Conditions, plan.Generation, kubeeyev1alpha2.ConditionScheduled, metav1.ConditionFalse, kubeeyev1alpha2.ReasonSuspended, "the plan is suspended") {
+			r.Recorder.Event(plan, corev1.EventTypeNormal, kubeeyev1alpha2.ReasonSuspended, "the plan is suspended")
+			if err = r.Status().Update(ctx, plan); err != nil {
+				klog.Error("failed to update inspect plan.", err)
+				return ctrl.Result{}, err
+			}
+		}
 		return ctrl.Result{}, nil
 	}
 
@@ -134,20 +149,21 @@ func (r *InspectPlanReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		if !utils.IsEmptyValue(plan.Status.LastTaskName) {
 			return ctrl.Result{}, nil
 		}
-		if !plan.Spec.Once.After(time.Now()) {
-			taskName, err := r.createInspectTask(plan, ctx)
+		if !plan.Spec.Once.After(r.now()) {
+			taskName, err := r.createInspectTask(plan, ctx, r.now())
 			if err != nil {
 				klog.Error("failed to create InspectTask.", err)
+				r.Recorder.Eventf(plan, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonTaskCreateFailed, "failed to create inspect task: %s", err)
 				return ctrl.Result{}, err
 			}
 
-			if err = r.updateStatus(ctx, plan, time.Now(), taskName); err != nil {
+			if err = r.updateStatus(ctx, plan, r.now(), taskName); err != nil {
 				return ctrl.Result{}, err
 			}
 			return ctrl.Result{}, nil
 		}
 		//nextScheduledTime := inspectPlan.Spec.Once.Sub(time.Now())
-		nextScheduledTime := time.Until(plan.Spec.Once.Time)
+		nextScheduledTime := plan.Spec.Once.Sub(r.now())
 
 		return ctrl.Result{RequeueAfter: nextScheduledTime}, nil
 	}
@@ -156,12 +172,13 @@ func (r *InspectPlanReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		if !utils.IsEmptyValue(plan.Status.LastTaskName) {
 			return ctrl.Result{}, nil
 		}
-		taskName, err := r.createInspectTask(plan, ctx)
+		taskName, err := r.createInspectTask(plan, ctx, r.now())
 		if err != nil {
 			klog.Error("failed to create InspectTask.", err)
+			r.Recorder.Eventf(plan, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonTaskCreateFailed, "failed to create inspect task: %s", err)
 			return ctrl.Result{}, err
 		}
-		if err = r.updateStatus(ctx, plan, time.Now(), taskName); err != nil {
+		if err = r.updateStatus(ctx, plan, r.now(), taskName); err != nil {
 			return ctrl.Result{}, err
 		}
 
@@ -171,51 +188,154 @@ func (r *InspectPlanReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	schedule, err := cron.ParseStandard(*plan.Spec.Schedule)
 	if err != nil {
 		klog.Error("Unparseable schedule.\n", err)
+		message := fmt.Sprintf("unparseable schedule %q: %s", *plan.Spec.Schedule, err)
+		if setCondition(&plan.Status.Conditions, plan.Generation, kubeeyev1alpha2.ConditionScheduled, metav1.ConditionFalse, kubeeyev1alpha2.ReasonInvalidSchedule, message) {
+			r.Recorder.Event(plan, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonInvalidSchedule, message)
+			if err = r.Status().Update(ctx, plan); err != nil {
+				klog.Error("failed to update inspect plan.", err)
+				return ctrl.Result{}, err
+			}
+		}
 		return ctrl.Result{}, nil
 	}
-	now := time.Now()
-	scheduledTime := nextScheduledTimeDuration(schedule, plan.Status.LastScheduleTime)
-	if plan.Status.LastScheduleTime == nil || plan.Status.LastScheduleTime.Add(*scheduledTime).Before(now) {
-		taskName, err := r.createInspectTask(plan, ctx)
-		if err != nil {
-			klog.Error("failed to create InspectTask.", err)
+	if setCondition(&plan.Status.Conditions, plan.Generation, kubeeyev1alpha2.ConditionScheduled, metav1.ConditionTrue, kubeeyev1alpha2.ReasonScheduled, scheduledMessage(plan, "")) {
+		if err = r.Status().Update(ctx, plan); err != nil {
+			klog.Error("failed to update inspect plan.", err)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+	now := r.now()
+	tasks, err := r.listPlanTasks(ctx, plan.Name)
+	if err != nil {
+		klog.Error("failed to list the inspect tasks of the plan.", err)
+		return ctrl.Result{}, err
+	}
+	result, err := r.scheduleTask(ctx, plan, schedule, activeTasks(tasks), now)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if r.deleteTasks(ctx, plan, tasksOverHistoryLimits(plan, tasks), "the task is over the history limits of the plan") {
+		if err = r.Status().Update(ctx, plan); err != nil {
+			klog.Error("failed to update inspect plan.", err)
 			return ctrl.Result{}, err
 		}
+	}
+	return result, nil
+}
 
-		plan.Status.NextScheduleTime = &metav1.Time{Time: schedule.Next(now)}
-		r.Status().Patch(ctx, plan, client.MergeFrom(plan))
-		if err = r.updateStatus(ctx, plan, now, taskName); err != nil {
+// scheduleTask creates the task of the latest schedule time of a cron plan that has passed, as the concurrency
+// policy and the starting deadline of the plan allow. A schedule time skipped because a task is running is started
+// when the task finishes, if still before its deadline.
+func (r *InspectPlanReconciler) scheduleTask(ctx context.Context, plan *kubeeyev1alpha2.InspectPlan, schedule cron.Schedule, active []kubeeyev1alpha2.InspectTask, now time.Time) (ctrl.Result, error) {
+	next := schedule.Next(now)
+	// 100ms later for the time skews
+	result := ctrl.Result{RequeueAfter: next.Sub(now) + 100*time.Millisecond}
+
+	scheduledTime, missed, err := mostRecentScheduleTime(plan, schedule, now)
+	if err != nil {
+		klog.Errorf("inspect plan %s: %s", plan.Name, err)
+		r.Recorder.Eventf(plan, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonMissedSchedule, "%s, the missed schedules are skipped", err)
+		if err = r.skipSchedule(ctx, plan, now, next); err != nil {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
-	} else {
-		nextScheduledTime := nextScheduledTimeDuration(schedule, &metav1.Time{Time: now})
-		return ctrl.Result{RequeueAfter: *nextScheduledTime}, nil
+		return result, nil
+	}
+	if scheduledTime == nil {
+		return result, nil
 	}
+	if isTooLate(plan, *scheduledTime, now) {
+		r.Recorder.Eventf(plan, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonMissedSchedule, "missed the schedule at %s, its starting deadline has passed", scheduledTime.Format(time.RFC3339))
+		if err = r.skipSchedule(ctx, plan, *scheduledTime, next); err != nil {
+			return ctrl.Result{}, err
+		}
+		return result, nil
+	}
+	if missed > 1 {
+		klog.Infof("inspect plan %s missed %d schedules, starting the latest one", plan.Name, missed-1)
+	}
+	if len(active) > 0 {
+		switch plan.Spec.ConcurrencyPolicy {
+		case kubeeyev1alpha2.ForbidConcurrent:
+			klog.Infof("not starting the task of inspect plan %s scheduled at %s, task %s is still running", plan.Name, scheduledTime, active[0].Name)
+			return result, nil
+		case kubeeyev1alpha2.ReplaceConcurrent:
+			r.deleteTasks(ctx, plan, active, fmt.Sprintf("the task is replaced by the task scheduled at %s", scheduledTime.Format(time.RFC3339)))
+		}
+	}
+
+	taskName, err := r.createInspectTask(plan, ctx, *scheduledTime)
+	if err != nil {
+		klog.Error("failed to create InspectTask.", err)
+		r.Recorder.Eventf(plan, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonTaskCreateFailed, "failed to create inspect task: %s", err)
+		return ctrl.Result{}, err
+	}
+	plan.Status.NextScheduleTime = &metav1.Time{Time: next}
+	if err = r.updateStatus(ctx, plan, *scheduledTime, taskName); err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// skipSchedule records the schedule times up to scheduledTime as handled without starting a task, so that a missed
+// schedule is reported once.
+func (r *InspectPlanReconciler) skipSchedule(ctx context.Context, plan *kubeeyev1alpha2.InspectPlan, scheduledTime time.Time, next time.Time) error {
+	plan.Status.LastScheduleTime = &metav1.Time{Time: scheduledTime}
+	plan.Status.NextScheduleTime = &metav1.Time{Time: next}
+	err := r.Status().Update(ctx, plan)
+	if err != nil {
+		klog.Error("failed to update inspect plan.", err)
+		return err
+	}
+	return nil
+}
+
+// deleteTasks deletes the tasks of the plan and removes them from its status, it returns whether any was removed.
+func (r *InspectPlanReconciler) deleteTasks(ctx context.Context, plan *kubeeyev1alpha2.InspectPlan, tasks []kubeeyev1alpha2.InspectTask, reason string) bool {
+	removed := false
+	for i := range tasks {
+		err := r.Delete(ctx, &tasks[i])
+		if err != nil && !kubeErr.IsNotFound(err) {
+			klog.Error("Failed to delete inspect task", err)
+			continue
+		}
+		klog.Info("delete inspect task", tasks[i].Name)
+		r.Recorder.Eventf(plan, corev1.EventTypeNormal, kubeeyev1alpha2.ReasonTaskDeleted, "deleted inspect task %s: %s", tasks[i].Name, reason)
+		n := len(plan.Status.TaskNames)
+		plan.Status.TaskNames = slices.DeleteFunc(plan.Status.TaskNames, func(t kubeeyev1alpha2.TaskNames) bool { return t.Name == tasks[i].Name })
+		removed = removed || n != len(plan.Status.TaskNames)
+	}
+	return removed
+}
+
+func (r *InspectPlanReconciler) listPlanTasks(ctx context.Context, planName string) ([]kubeeyev1alpha2.InspectTask, error) {
+	list := &kubeeyev1alpha2.InspectTaskList{}
+	err := r.List(ctx, list, client.MatchingLabels{constant.LabelPlanName: planName})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (r *InspectPlanReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *InspectPlanReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&kubeeyev1alpha2.InspectPlan{}).
+		// the concurrency policy starts a skipped task when the running one finishes
+		Owns(&kubeeyev1alpha2.InspectTask{}).
 		Complete(r)
 }
 
-// nextScheduledTimeDuration returns the time duration to requeue based on
-// the schedule and given time. It adds a 100ms padding to the next requeue to account
-// for Network Time Protocol(NTP) time skews.
-func nextScheduledTimeDuration(sched cron.Schedule, now *metav1.Time) *time.Duration {
-	LastScheduleTime := time.Time{}
-	if now != nil {
-		LastScheduleTime = now.Time
-	}
-	nextTime := sched.Next(LastScheduleTime).Add(100 * time.Millisecond).Sub(LastScheduleTime)
-	return &nextTime
-}
-
-func (r *InspectPlanReconciler) createInspectTask(plan *kubeeyev1alpha2.InspectPlan, ctx context.Context) (string, error) {
+func (r *InspectPlanReconciler) createInspectTask(plan *kubeeyev1alpha2.InspectPlan, ctx context.Context, scheduledTime time.Time) (string, error) {
 
-	inspectTaskName := fmt.Sprintf("%s-%s", plan.Name, time.Now().Format("20060102-15-04"))
+	inspectTaskName := fmt.Sprintf("%s-%s", plan.Name, scheduledTime.Format("20060102-15-04"))
 
 	err := r.Client.Get(ctx, client.ObjectKey{Name: inspectTaskName}, &kubeeyev1alpha2.InspectTask{})
 	if err == nil {
@@ -246,6 +366,7 @@ func (r *InspectPlanReconciler) createInspectTask(plan *kubeeyev1alpha2.InspectP
 		Spec: kubeeyev1alpha2.InspectTaskSpec{
 			RuleNames:   plan.Spec.RuleNames,
 			ClusterName: plan.Spec.ClusterName,
+			RetryPolicy: plan.Spec.RetryPolicy,
 			Timeout: func() string {
 				if plan.Spec.Timeout == "" {
 					return "10m"
@@ -266,6 +387,7 @@ func (r *InspectPlanReconciler) createInspectTask(plan *kubeeyev1alpha2.InspectP
 		return "", err
 	}
 	klog.Info("create a new inspect task.", inspectTask.Name)
+	r.Recorder.Eventf(plan, corev1.EventTypeNormal, kubeeyev1alpha2.ReasonTaskCreated, "created inspect task %s", inspectTask.Name)
 	r.removeTask(ctx, plan)
 	return inspectTask.Name, nil
 }
@@ -298,6 +420,7 @@ func (r *InspectPlanReconciler) updateStatus(ctx context.Context, plan *kubeeyev
 		Name:       taskName,
 		TaskStatus: kubeeyev1alpha2.PhasePending,
 	})
+	setCondition(&plan.Status.Conditions, plan.Generation, kubeeyev1alpha2.ConditionScheduled, metav1.ConditionTrue, kubeeyev1alpha2.ReasonScheduled, scheduledMessage(plan, taskName))
 	err := r.Status().Update(ctx, plan)
 	if err != nil {
 		klog.Error("failed to update inspect plan.", err)
@@ -306,6 +429,14 @@ func (r *InspectPlanReconciler) updateStatus(ctx context.Context, plan *kubeeyev
 	return nil
 }
 
+// scheduledMessage is the message of the Scheduled condition, the schedule of a cycle plan or the task of a single one.
+func scheduledMessage(plan *kubeeyev1alpha2.InspectPlan, taskName string) string {
+	if plan.Spec.Schedule != nil && plan.Spec.Once == nil {
+		return fmt.Sprintf("tasks are scheduled by %q", *plan.Spec.Schedule)
+	}
+	return fmt.Sprintf("created inspect task %s", taskName)
+}
+
 func (r *InspectPlanReconciler) getInspectTaskForLabel(planName string) ([]*kubeeyev1alpha2.InspectTask, error) {
 	list, err := r.KubeEyeFactory.V1alpha2().InspectTasks().Lister().List(labels.SelectorFromSet(map[string]string{constant.LabelPlanName: planName}))
 
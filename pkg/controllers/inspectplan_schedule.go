@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"fmt"
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/robfig/cron/v3"
+	"sort"
+	"time"
+)
+
+// maxMissedSchedules caps the schedule times counted since the last task, as for a CronJob.
+const maxMissedSchedules = 100
+
+// mostRecentScheduleTime returns the latest schedule time of a cron plan that has passed and has not started a task
+// yet, nil when there is none, and how many schedule times were missed since the last task. As for a CronJob, the
+// starting deadline is not applied here, so that isTooLate can tell a schedule missed past its deadline. A plan that
+// never scheduled a task starts one at once. It fails when more than maxMissedSchedules were missed.
+func mostRecentScheduleTime(plan *kubeeyev1alpha2.InspectPlan, schedule cron.Schedule, now time.Time) (*time.Time, int, error) {
+	if plan.Status.LastScheduleTime == nil {
+		return &now, 1, nil
+	}
+
+	var recent *time.Time
+	missed := 0
+	for t := schedule.Next(plan.Status.LastScheduleTime.Time); !t.After(now); t = schedule.Next(t) {
+		missed++
+		if missed > maxMissedSchedules {
+			return nil, missed, fmt.Errorf("missed more than %d schedules since %s, check the clock skew", maxMissedSchedules, plan.Status.LastScheduleTime.Format(time.RFC3339))
+		}
+		scheduled := t
+		recent = &scheduled
+	}
+	return recent, missed, nil
+}
+
+// isTooLate returns whether a task scheduled at this time can no longer start.
+func isTooLate(plan *kubeeyev1alpha2.InspectPlan, scheduledTime time.Time, now time.Time) bool {
+	if plan.Spec.StartingDeadlineSeconds == nil {
+		return false
+	}
+	return scheduledTime.Add(time.Duration(*plan.Spec.StartingDeadlineSeconds) * time.Second).Before(now)
+}
+
+// activeTasks returns the tasks that have not finished.
+func activeTasks(tasks []kubeeyev1alpha2.InspectTask) (active []kubeeyev1alpha2.InspectTask) {
+	for _, task := range tasks {
+		if task.DeletionTimestamp.IsZero() && !task.Status.Status.IsFinished() {
+			active = append(active, task)
+		}
+	}
+	return active
+}
+
+// tasksOverHistoryLimits returns the oldest finished tasks beyond the history limits of the plan. Partially
+// succeeded and cancelled tasks count as failed ones.
+func tasksOverHistoryLimits(plan *kubeeyev1alpha2.InspectPlan, tasks []kubeeyev1alpha2.InspectTask) []kubeeyev1alpha2.InspectTask {
+	var succeeded, failed []kubeeyev1alpha2.InspectTask
+	for _, task := range tasks {
+		switch {
+		case !task.DeletionTimestamp.IsZero():
+		case task.Status.Status.IsSucceeded():
+			succeeded = append(succeeded, task)
+		case task.Status.Status.IsFinished():
+			failed = append(failed, task)
+		}
+	}
+	return append(oldestTasks(succeeded, plan.Spec.SuccessfulTasksHistoryLimit), oldestTasks(failed, plan.Spec.FailedTasksHistoryLimit)...)
+}
+
+func oldestTasks(tasks []kubeeyev1alpha2.InspectTask, limit *int32) []kubeeyev1alpha2.InspectTask {
+	if limit == nil || len(tasks) <= int(*limit) {
+		return nil
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].CreationTimestamp.Equal(&tasks[j].CreationTimestamp) {
+			return tasks[i].Name < tasks[j].Name
+		}
+		return tasks[i].CreationTimestamp.Before(&tasks[j].CreationTimestamp)
+	})
+	return tasks[:len(tasks)-int(*limit)]
+}
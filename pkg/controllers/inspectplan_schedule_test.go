@@ -0,0 +1,387 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	testingclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var scheduleStart = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func newSchedulePlan(t *testing.T, spec kubeeyev1alpha2.InspectPlanSpec, lastSchedule *time.Time) (*kubeeyev1alpha2.InspectPlan, cron.Schedule) {
+	if spec.Schedule == nil {
+		spec.Schedule = ptr.To("*/10 * * * *")
+	}
+	schedule, err := cron.ParseStandard(*spec.Schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := &kubeeyev1alpha2.InspectPlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "plan", CreationTimestamp: metav1.NewTime(scheduleStart.Add(-time.Hour))},
+		Spec:       spec,
+	}
+	if lastSchedule != nil {
+		plan.Status.LastScheduleTime = &metav1.Time{Time: *lastSchedule}
+	}
+	return plan, schedule
+}
+
+func newPlanTask(name string, phase kubeeyev1alpha2.Phase, created time.Time) kubeeyev1alpha2.InspectTask {
+	return kubeeyev1alpha2.InspectTask{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Labels:            map[string]string{constant.LabelPlanName: "plan"},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Status: kubeeyev1alpha2.InspectTaskStatus{Status: phase},
+	}
+}
+
+func newScheduleReconciler(t *testing.T, clock *testingclock.FakeClock, objects ...client.Object) *InspectPlanReconciler {
+	scheme := runtime.NewScheme()
+	if err := kubeeyev1alpha2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&kubeeyev1alpha2.InspectPlan{}, &kubeeyev1alpha2.InspectTask{}).
+		Build()
+	return &InspectPlanReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(100), Clock: clock}
+}
+
+func taskNames(t *testing.T, r *InspectPlanReconciler) []string {
+	tasks, err := r.listPlanTasks(context.TODO(), "plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+	return names
+}
+
+func TestMostRecentScheduleTime(t *testing.T) {
+	last := scheduleStart
+	tests := []struct {
+		name         string
+		lastSchedule *time.Time
+		deadline     *int64
+		step         time.Duration
+		want         *time.Time
+		wantMissed   int
+	}{{
+		name:       "never scheduled starts at once",
+		step:       time.Minute,
+		want:       ptr.To(scheduleStart.Add(time.Minute)),
+		wantMissed: 1,
+	}, {
+		name:         "before the next schedule",
+		lastSchedule: &last,
+		step:         9 * time.Minute,
+	}, {
+		name:         "at the next schedule",
+		lastSchedule: &last,
+		step:         10 * time.Minute,
+		want:         ptr.To(scheduleStart.Add(10 * time.Minute)),
+		wantMissed:   1,
+	}, {
+		name:         "the latest of the schedules missed while down",
+		lastSchedule: &last,
+		step:         35 * time.Minute,
+		want:         ptr.To(scheduleStart.Add(30 * time.Minute)),
+		wantMissed:   3,
+	}, {
+		name:         "the starting deadline is left to isTooLate",
+		lastSchedule: &last,
+		deadline:     ptr.To(int64(60)),
+		step:         35 * time.Minute,
+		want:         ptr.To(scheduleStart.Add(30 * time.Minute)),
+		wantMissed:   3,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := testingclock.NewFakeClock(scheduleStart)
+			plan, schedule := newSchedulePlan(t, kubeeyev1alpha2.InspectPlanSpec{StartingDeadlineSeconds: tt.deadline}, tt.lastSchedule)
+			clock.Step(tt.step)
+
+			got, missed, err := mostRecentScheduleTime(plan, schedule, clock.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("mostRecentScheduleTime() = %v, want %v", got, tt.want)
+			}
+			if got != nil && missed != tt.wantMissed {
+				t.Errorf("mostRecentScheduleTime() missed %d, want %d", missed, tt.wantMissed)
+			}
+		})
+	}
+}
+
+func TestMostRecentScheduleTimeTooManyMissed(t *testing.T) {
+	last := scheduleStart
+	plan, schedule := newSchedulePlan(t, kubeeyev1alpha2.InspectPlanSpec{}, &last)
+
+	// every 10 minutes, the 100th schedule missed is still counted
+	got, missed, err := mostRecentScheduleTime(plan, schedule, scheduleStart.Add(1000*time.Minute))
+	if err != nil || missed != maxMissedSchedules || !got.Equal(scheduleStart.Add(1000*time.Minute)) {
+		t.Errorf("mostRecentScheduleTime() = %v, %d, %v, want the 100th schedule", got, missed, err)
+	}
+	if _, _, err = mostRecentScheduleTime(plan, schedule, scheduleStart.Add(1010*time.Minute)); err == nil {
+		t.Error("more than 100 missed schedules should fail")
+	}
+}
+
+func TestIsTooLate(t *testing.T) {
+	clock := testingclock.NewFakeClock(scheduleStart)
+	plan, _ := newSchedulePlan(t, kubeeyev1alpha2.InspectPlanSpec{StartingDeadlineSeconds: ptr.To(int64(30))}, nil)
+
+	clock.Step(30 * time.Second)
+	if isTooLate(plan, scheduleStart, clock.Now()) {
+		t.Error("a task is not late at its starting deadline")
+	}
+	clock.Step(time.Second)
+	if !isTooLate(plan, scheduleStart, clock.Now()) {
+		t.Error("a task is late after its starting deadline")
+	}
+	plan.Spec.StartingDeadlineSeconds = nil
+	clock.Step(time.Hour)
+	if isTooLate(plan, scheduleStart, clock.Now()) {
+		t.Error("a task is never late without a starting deadline")
+	}
+}
+
+func TestScheduleTaskConcurrencyPolicy(t *testing.T) {
+	last := scheduleStart
+	tests := []struct {
+		name   string
+		policy kubeeyev1alpha2.ConcurrencyPolicy
+		want   []string
+	}{{
+		name: "allow runs the tasks concurrently",
+		want: []string{"plan-20240101-10-00", "plan-20240101-10-10"},
+	}, {
+		name:   "forbid skips the task",
+		policy: kubeeyev1alpha2.ForbidConcurrent,
+		want:   []string{"plan-20240101-10-00"},
+	}, {
+		name:   "replace deletes the running task",
+		policy: kubeeyev1alpha2.ReplaceConcurrent,
+		want:   []string{"plan-20240101-10-10"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := testingclock.NewFakeClock(scheduleStart)
+			plan, schedule := newSchedulePlan(t, kubeeyev1alpha2.InspectPlanSpec{ConcurrencyPolicy: tt.policy}, &last)
+			running := newPlanTask("plan-20240101-10-00", kubeeyev1alpha2.PhaseRunning, scheduleStart)
+			r := newScheduleReconciler(t, clock, plan, &running)
+			clock.Step(10 * time.Minute)
+
+			result, err := r.scheduleTask(context.TODO(), plan, schedule, []kubeeyev1alpha2.InspectTask{running}, clock.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := taskNames(t, r); !equalNames(got, tt.want) {
+				t.Errorf("tasks = %v, want %v", got, tt.want)
+			}
+			if want := 10*time.Minute + 100*time.Millisecond; result.RequeueAfter != want {
+				t.Errorf("requeue after %s, want %s", result.RequeueAfter, want)
+			}
+		})
+	}
+}
+
+func TestScheduleTaskForbidStartsSkippedTaskWithinDeadline(t *testing.T) {
+	last := scheduleStart
+	clock := testingclock.NewFakeClock(scheduleStart)
+	plan, schedule := newSchedulePlan(t, kubeeyev1alpha2.InspectPlanSpec{
+		ConcurrencyPolicy:       kubeeyev1alpha2.ForbidConcurrent,
+		StartingDeadlineSeconds: ptr.To(int64(120)),
+	}, &last)
+	running := newPlanTask("plan-20240101-10-00", kubeeyev1alpha2.PhaseRunning, scheduleStart)
+	r := newScheduleReconciler(t, clock, plan, &running)
+
+	clock.Step(10 * time.Minute)
+	if _, err := r.scheduleTask(context.TODO(), plan, schedule, []kubeeyev1alpha2.InspectTask{running}, clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(t, r); len(got) != 1 {
+		t.Fatalf("tasks = %v, the scheduled task must wait for the running one", got)
+	}
+
+	// the running task finishes within the starting deadline of the skipped schedule
+	clock.Step(time.Minute)
+	if _, err := r.scheduleTask(context.TODO(), plan, schedule, nil, clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"plan-20240101-10-00", "plan-20240101-10-10"}
+	if got := taskNames(t, r); !equalNames(got, want) {
+		t.Fatalf("tasks = %v, want %v", got, want)
+	}
+	if !plan.Status.LastScheduleTime.Equal(&metav1.Time{Time: scheduleStart.Add(10 * time.Minute)}) {
+		t.Errorf("last schedule time = %s, want the skipped schedule time", plan.Status.LastScheduleTime)
+	}
+}
+
+func TestScheduleTaskMissedStartingDeadline(t *testing.T) {
+	last := scheduleStart
+	clock := testingclock.NewFakeClock(scheduleStart)
+	plan, schedule := newSchedulePlan(t, kubeeyev1alpha2.InspectPlanSpec{
+		ConcurrencyPolicy:       kubeeyev1alpha2.ForbidConcurrent,
+		StartingDeadlineSeconds: ptr.To(int64(120)),
+	}, &last)
+	r := newScheduleReconciler(t, clock, plan)
+
+	// the manager was down over the schedule and its deadline
+	clock.Step(15 * time.Minute)
+	result, err := r.scheduleTask(context.TODO(), plan, schedule, nil, clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(t, r); len(got) != 0 {
+		t.Errorf("tasks = %v, a schedule past its starting deadline must not start", got)
+	}
+	select {
+	case event := <-r.Recorder.(*record.FakeRecorder).Events:
+		if want := "Warning MissedSchedule missed the schedule at 2024-01-01T10:10:00Z"; !strings.HasPrefix(event, want) {
+			t.Errorf("event = %q, want %q", event, want)
+		}
+	default:
+		t.Error("no MissedSchedule event recorded")
+	}
+	if want := 5*time.Minute + 100*time.Millisecond; result.RequeueAfter != want {
+		t.Errorf("requeue after %s, want %s", result.RequeueAfter, want)
+	}
+	if !plan.Status.LastScheduleTime.Equal(&metav1.Time{Time: scheduleStart.Add(10 * time.Minute)}) {
+		t.Errorf("last schedule time = %s, want the missed schedule time", plan.Status.LastScheduleTime)
+	}
+
+	// the missed schedule is reported once
+	clock.Step(time.Minute)
+	if _, err = r.scheduleTask(context.TODO(), plan, schedule, nil, clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-r.Recorder.(*record.FakeRecorder).Events:
+		t.Errorf("unexpected event %q", event)
+	default:
+	}
+
+	clock.Step(4 * time.Minute)
+	if _, err = r.scheduleTask(context.TODO(), plan, schedule, nil, clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := taskNames(t, r), []string{"plan-20240101-10-20"}; !equalNames(got, want) {
+		t.Errorf("tasks = %v, want %v", got, want)
+	}
+}
+
+func TestScheduleTaskTooManyMissed(t *testing.T) {
+	last := scheduleStart
+	clock := testingclock.NewFakeClock(scheduleStart)
+	plan, schedule := newSchedulePlan(t, kubeeyev1alpha2.InspectPlanSpec{}, &last)
+	r := newScheduleReconciler(t, clock, plan)
+
+	// the manager was down for a week
+	clock.Step(7 * 24 * time.Hour)
+	if _, err := r.scheduleTask(context.TODO(), plan, schedule, nil, clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(t, r); len(got) != 0 {
+		t.Errorf("tasks = %v, the missed schedules should be skipped", got)
+	}
+	select {
+	case event := <-r.Recorder.(*record.FakeRecorder).Events:
+		if want := "Warning MissedSchedule missed more than 100 schedules"; !strings.HasPrefix(event, want) {
+			t.Errorf("event = %q, want %q", event, want)
+		}
+	default:
+		t.Error("no MissedSchedule event recorded")
+	}
+	if !plan.Status.LastScheduleTime.Equal(&metav1.Time{Time: clock.Now()}) {
+		t.Errorf("last schedule time = %s, want the time the schedules were skipped", plan.Status.LastScheduleTime)
+	}
+
+	clock.Step(10 * time.Minute)
+	if _, err := r.scheduleTask(context.TODO(), plan, schedule, nil, clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(t, r); len(got) != 1 {
+		t.Errorf("tasks = %v, want the next schedule started", got)
+	}
+}
+
+func TestTasksOverHistoryLimits(t *testing.T) {
+	var tasks []kubeeyev1alpha2.InspectTask
+	phases := []kubeeyev1alpha2.Phase{
+		kubeeyev1alpha2.PhaseSucceeded,
+		kubeeyev1alpha2.PhaseFailed,
+		kubeeyev1alpha2.PhaseSucceeded,
+		kubeeyev1alpha2.PhasePartiallySucceeded,
+		kubeeyev1alpha2.PhaseSucceeded,
+		kubeeyev1alpha2.PhaseCancelled,
+		kubeeyev1alpha2.PhaseRunning,
+	}
+	for i, phase := range phases {
+		tasks = append(tasks, newPlanTask(string(rune('a'+i)), phase, scheduleStart.Add(time.Duration(i)*time.Minute)))
+	}
+
+	tests := []struct {
+		name      string
+		succeeded *int32
+		failed    *int32
+		want      []string
+	}{{
+		name: "no limits keep all tasks",
+	}, {
+		name:      "the oldest tasks beyond the limits",
+		succeeded: ptr.To(int32(1)),
+		failed:    ptr.To(int32(2)),
+		want:      []string{"a", "c", "b"},
+	}, {
+		name:   "no failed tasks",
+		failed: ptr.To(int32(0)),
+		want:   []string{"b", "d", "f"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, _ := newSchedulePlan(t, kubeeyev1alpha2.InspectPlanSpec{
+				SuccessfulTasksHistoryLimit: tt.succeeded,
+				FailedTasksHistoryLimit:     tt.failed,
+			}, nil)
+			var got []string
+			for _, task := range tasksOverHistoryLimits(plan, append([]kubeeyev1alpha2.InspectTask(nil), tasks...)) {
+				got = append(got, task.Name)
+			}
+			if !equalNames(got, tt.want) {
+				t.Errorf("tasksOverHistoryLimits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func equalNames(got []string, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
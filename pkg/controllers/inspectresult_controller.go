@@ -30,11 +30,15 @@ import (
 	"github.com/kubesphere/kubeeye/pkg/output"
 	"github.com/kubesphere/kubeeye/pkg/template"
 	"github.com/kubesphere/kubeeye/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
 	kubeErr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"os"
-	"path"
+	"strings"
 	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -45,14 +49,22 @@ import (
 // InspectResultReconciler reconciles a InspectResult object
 type InspectResultReconciler struct {
 	client.Client
+	// APIReader reads the notification state without the cache
+	APIReader      client.Reader
 	Scheme         *runtime.Scheme
 	KubeEyeFactory kubeeyeInformers.Interface
 	K8sFactory     informers.SharedInformerFactory
+	Recorder       record.EventRecorder
+
+	// resultDir holds the result files, constant.ResultPathPrefix when empty
+	resultDir string
 }
 
 //+kubebuilder:rbac:groups=kubeeye.kubesphere.io,resources=inspectresults,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=kubeeye.kubesphere.io,resources=inspectresults/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=kubeeye.kubesphere.io,resources=inspectresults/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=create;update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -89,11 +101,11 @@ func (r *InspectResultReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		newFinalizers := utils.SliceRemove(Finalizers, result.Finalizers)
 		result.Finalizers = newFinalizers.([]string)
 		klog.Infof("inspect task is being deleted")
-		err = os.Remove(path.Join(constant.ResultPathPrefix, result.Name))
+		err = os.Remove(r.resultFile(result.Name))
 		if err != nil {
 			klog.Error(err, "failed to delete file")
 		}
-		err = os.Remove(fmt.Sprintf("%s.xlsx", path.Join(constant.ResultPathPrefix, result.Name)))
+		err = os.Remove(fmt.Sprintf("%s.xlsx", r.resultFile(result.Name)))
 		if err != nil {
 			klog.Error(err, "failed to delete xlsx file")
 		}
@@ -122,11 +134,13 @@ func (r *InspectResultReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	parseStart, err := time.Parse("2006-01-02 15:04:05", startTime)
 	if err != nil {
 		klog.Error(err)
+		r.Recorder.Eventf(result, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonResultReadFailed, "invalid start time annotation: %s", err)
 		return ctrl.Result{}, err
 	}
 	parseEnd, err := time.Parse("2006-01-02 15:04:05", endTime)
 	if err != nil {
 		klog.Error(err)
+		r.Recorder.Eventf(result, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonResultReadFailed, "invalid end time annotation: %s", err)
 		return ctrl.Result{}, err
 	}
 
@@ -138,9 +152,12 @@ func (r *InspectResultReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	countLevelNum, err := r.CountLevelNum(result.Name)
 	if err != nil {
 		klog.Error("Failed to count level num", err)
+		r.Recorder.Eventf(result, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonResultReadFailed, "failed to read the result file: %s", err)
 		return ctrl.Result{}, err
 	}
 	result.Status.Level = countLevelNum
+	message := fmt.Sprintf("%d danger, %d warning, %d ignore", levelNum(countLevelNum, kubeeyev1alpha2.DangerLevel), levelNum(countLevelNum, kubeeyev1alpha2.WarningLevel), levelNum(countLevelNum, kubeeyev1alpha2.IgnoreLevel))
+	setCondition(&result.Status.Conditions, result.Generation, kubeeyev1alpha2.ConditionCompleted, metav1.ConditionTrue, kubeeyev1alpha2.ReasonResultCounted, message)
 
 	err = r.Client.Status().Update(ctx, result)
 	if err != nil {
@@ -148,10 +165,18 @@ func (r *InspectResultReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, err
 	}
 
+	r.Recorder.Event(result, corev1.EventTypeNormal, kubeeyev1alpha2.ReasonResultCounted, "result counted, "+message)
 	go r.SendMessage(result)
 	return ctrl.Result{}, nil
 }
 
+func levelNum(levels map[kubeeyev1alpha2.Level]*int, level kubeeyev1alpha2.Level) int {
+	if levels[level] == nil {
+		return 0
+	}
+	return *levels[level]
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *InspectResultReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -159,8 +184,13 @@ func (r *InspectResultReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
+// resultFile returns the path of the result file.
+func (r *InspectResultReconciler) resultFile(resultName string) string {
+	return resultPath(r.resultDir, resultName)
+}
+
 func (r *InspectResultReconciler) CountLevelNum(resultName string) (map[kubeeyev1alpha2.Level]*int, error) {
-	file, err := os.ReadFile(path.Join(constant.ResultPathPrefix, resultName))
+	file, err := os.ReadFile(r.resultFile(resultName))
 	if err != nil {
 		return nil, err
 	}
@@ -246,31 +276,293 @@ func (r *InspectResultReconciler) SendMessage(result *kubeeyev1alpha2.InspectRes
 			return
 		}
 	}
-	klog.Info("sending email")
-	htmlTemplate, err := template.GetInspectResultHtmlTemplate()
+	data, err := output.ReadResultFile(r.resultFile(result.Name))
 	if err != nil {
-		klog.Error("GetInspectResultHtmlTemplate error", err)
+		klog.Error("failed to read inspect result for message", err)
 		return
 	}
-	err, m := output.HtmlOut(result.Name)
+	data.ObjectMeta = result.ObjectMeta
+	findings := output.MessageFindings(output.TopFindings(output.ResultRows(data), 0))
+	reportTemplate := r.getReportTemplate(result)
+	event, err := r.messageEvent(kc.Message, reportTemplate, data, n)
 	if err != nil {
-		klog.Error("get html render data error", err)
+		klog.Error("render message content error", err)
 		return
 	}
-	data := bytes.NewBufferString("")
-	err = htmlTemplate.Execute(data, m)
+
+	channels := map[string]conf.ChannelConfig{}
+	var deliveries []message.Delivery
+	for _, channel := range kc.Message.GetChannels() {
+		channels[channel.Name] = channel
+		if len(kc.Message.Routes) == 0 {
+			deliveries = append(deliveries, message.Delivery{Receiver: channel.Name, Findings: findings})
+		}
+	}
+	if len(kc.Message.Routes) > 0 {
+		deliveries = message.Route(kc.Message.Routes, r.routeInput(result, kc.Message.Routes, findings))
+	}
+
+	for _, delivery := range deliveries {
+		channel, ok := channels[delivery.Receiver]
+		if !ok {
+			klog.Errorf("message channel %s not found", delivery.Receiver)
+			continue
+		}
+		view, channelEvent := data, *event
+		if len(delivery.Findings) != len(findings) {
+			// the route kept some of the findings, the title, content and attachments only cover those
+			view = output.FilterResult(data, delivery.Findings)
+			filteredEvent, err := r.messageEvent(kc.Message, reportTemplate, view, len(delivery.Findings))
+			if err != nil {
+				klog.Errorf("failed to render message content for channel %s, err:%s", channel.Name, err)
+				continue
+			}
+			channelEvent = *filteredEvent
+		}
+		if !r.admit(&channel, result, channelEvent.Summary, delivery.Findings) {
+			continue
+		}
+		klog.Infof("sending %s message to channel %s", channel.Type, channel.Name)
+		channelEvent.Findings = delivery.Findings
+		if channel.Type == conf.EmailMessage && channel.Email != nil {
+			channelEvent.Attachments = r.RenderAttachments(view, channel.Email.Attachments, view != data)
+		}
+		dispatcher := message.RegisterHandler(message.NewChannelHandler(&channel, r.Client))
+		if err := dispatcher.DispatchMessageEvent(&channelEvent); err != nil {
+			klog.Errorf("failed to send message to channel %s, err:%s", channel.Name, err)
+			continue
+		}
+		r.markSent(&channel, result, delivery.Findings)
+	}
+}
+
+// messageEvent renders the message of a result with the report template of its plan or in the message format.
+func (r *InspectResultReconciler) messageEvent(config *conf.MessageConfig, reportTemplate string, result *kubeeyev1alpha2.InspectResult, issues int) (*conf.MessageEvent, error) {
+	var content []byte
+	var contentType string
+	var err error
+	if reportTemplate != "" {
+		content, contentType, err = RenderReportTemplate(r.K8sFactory, result, reportTemplate)
+	} else {
+		content, contentType, err = RenderMessageContent(result, config.Format)
+	}
 	if err != nil {
-		klog.Error("render html template error", err)
-		return
+		return nil, err
+	}
+
+	event := &conf.MessageEvent{
+		Title:       message.Title(config.Language, result.Spec.InspectCluster.Name, issues),
+		Timestamp:   time.Now(),
+		Content:     content,
+		ContentType: contentType,
+	}
+	event.Summary, _ = output.MessageSummary(result, 0)
+	if config.ReportURL != "" {
+		event.ReportURL = fmt.Sprintf("%s/kapis/kubeeye.kubesphere.io/v1alpha2/inspectresults/%s?type=html", strings.TrimSuffix(config.ReportURL, "/"), result.Name)
+	}
+	return event, nil
+}
+
+// admit tells whether the result is sent to a throttled or digest channel now, a digest channel collects it in its notification state.
+func (r *InspectResultReconciler) admit(channel *conf.ChannelConfig, result *kubeeyev1alpha2.InspectResult, summary *conf.MessageSummary, findings []conf.MessageFinding) bool {
+	if channel.Throttle == nil && channel.Digest == nil {
+		return true
+	}
+	now := time.Now()
+	entry := message.DigestEntry{
+		Result:  result.Name,
+		Cluster: result.Spec.InspectCluster.Name,
+		Plan:    result.Labels[constant.LabelPlanName],
+		Time:    now,
+	}
+	if summary != nil {
+		entry.Score, entry.Total, entry.Levels = summary.Score, summary.Total, summary.Levels
+	}
+	var send bool
+	var reason string
+	store := message.NewStateStore(r.Client, r.APIReader, os.Getenv("KUBERNETES_POD_NAMESPACE"))
+	err := store.Update(context.TODO(), channel.Name, func(state *message.ReceiverState) error {
+		send, reason = message.Admit(state, channel, notificationSource(result), entry, findings, now)
+		return nil
+	})
+	if err != nil {
+		klog.Errorf("failed to update the notification state of channel %s, err:%s", channel.Name, err)
+		// without the state a result is sent rather than lost, unless it waits for a digest
+		return channel.Digest == nil
+	}
+	if !send {
+		klog.Infof("skip message to channel %s: %s", channel.Name, reason)
 	}
+	return send
+}
 
-	messageHandler := message.NewEmailMessageOptions(&kc.Message.Email, r.Client)
-	dispatcher := message.RegisterHandler(messageHandler)
-	dispatcher.DispatchMessageEvent(&conf.MessageEvent{
-		Title:     fmt.Sprintf("%s集群巡检完成,共发现%d个问题", result.Spec.InspectCluster.Name, n),
-		Timestamp: time.Now(),
-		Content:   data.Bytes(),
+// markSent records a delivered notification in the state of a throttled channel.
+func (r *InspectResultReconciler) markSent(channel *conf.ChannelConfig, result *kubeeyev1alpha2.InspectResult, findings []conf.MessageFinding) {
+	if channel.Throttle == nil {
+		return
+	}
+	store := message.NewStateStore(r.Client, r.APIReader, os.Getenv("KUBERNETES_POD_NAMESPACE"))
+	err := store.Update(context.TODO(), channel.Name, func(state *message.ReceiverState) error {
+		message.MarkSent(state, notificationSource(result), findings, time.Now())
+		return nil
 	})
+	if err != nil {
+		klog.Errorf("failed to record the message sent to channel %s, err:%s", channel.Name, err)
+	}
+}
+
+// notificationSource identifies the plan and cluster of a result in the notification state.
+func notificationSource(result *kubeeyev1alpha2.InspectResult) string {
+	return result.Labels[constant.LabelPlanName] + "/" + result.Spec.InspectCluster.Name
+}
+
+// routeInput describes the result for the message routes, the previous result is only read when a route needs it.
+func (r *InspectResultReconciler) routeInput(result *kubeeyev1alpha2.InspectResult, routes []conf.RouteConfig, findings []conf.MessageFinding) *message.RouteInput {
+	input := &message.RouteInput{
+		Plan:     result.Labels[constant.LabelPlanName],
+		Cluster:  result.Spec.InspectCluster.Name,
+		Findings: findings,
+	}
+	if input.Plan == "" {
+		return input
+	}
+	if plan, err := r.KubeEyeFactory.V1alpha2().InspectPlans().Lister().Get(input.Plan); err == nil {
+		input.PlanLabels = plan.Labels
+	}
+	if needPreviousResult(routes) {
+		if previous := r.previousResult(result); previous != nil {
+			input.Previous = output.MessageFindings(output.ResultRows(previous))
+		}
+	}
+	return input
+}
+
+func needPreviousResult(routes []conf.RouteConfig) bool {
+	for _, route := range routes {
+		if route.NewFindingsOnly || needPreviousResult(route.Routes) {
+			return true
+		}
+	}
+	return false
+}
+
+// previousResult returns the latest result of the same plan and cluster created before the result, nil when there is none.
+func (r *InspectResultReconciler) previousResult(result *kubeeyev1alpha2.InspectResult) *kubeeyev1alpha2.InspectResult {
+	list, err := r.KubeEyeFactory.V1alpha2().InspectResults().Lister().List(labels.SelectorFromSet(map[string]string{constant.LabelPlanName: result.Labels[constant.LabelPlanName]}))
+	if err != nil {
+		klog.Error("failed to list inspect results, err:", err)
+		return nil
+	}
+	var previous *kubeeyev1alpha2.InspectResult
+	for _, item := range list {
+		if item.Spec.InspectCluster.Name != result.Spec.InspectCluster.Name || !item.CreationTimestamp.Before(&result.CreationTimestamp) {
+			continue
+		}
+		if previous == nil || previous.CreationTimestamp.Before(&item.CreationTimestamp) {
+			previous = item
+		}
+	}
+	if previous == nil {
+		return nil
+	}
+	data, err := output.ReadResultFile(r.resultFile(previous.Name))
+	if err != nil {
+		klog.Errorf("failed to read previous inspect result %s, err:%s", previous.Name, err)
+		return nil
+	}
+	return data
+}
+
+// RenderAttachments renders the result in each attachment format, formats that fail are skipped. The saved xlsx
+// is attached unless the result is filtered, which is rendered again.
+func (r *InspectResultReconciler) RenderAttachments(result *kubeeyev1alpha2.InspectResult, formats []string, filtered bool) []conf.Attachment {
+	var attachments []conf.Attachment
+	for _, format := range formats {
+		var data []byte
+		var err error
+		if format == output.FormatExcel && !filtered {
+			data, err = os.ReadFile(fmt.Sprintf("%s.xlsx", r.resultFile(result.Name)))
+		} else {
+			buffer := bytes.NewBufferString("")
+			err = output.Render(buffer, format, result)
+			data = buffer.Bytes()
+		}
+		if err != nil {
+			klog.Errorf("failed to render %s attachment, err:%s", format, err)
+			continue
+		}
+		attachments = append(attachments, conf.Attachment{
+			Name:        output.FormatFileName(result.Name, format),
+			ContentType: output.FormatContentType(format),
+			Data:        data,
+		})
+	}
+	return attachments
+}
+
+// getReportTemplate returns the report template chosen by the plan of the result.
+func (r *InspectResultReconciler) getReportTemplate(result *kubeeyev1alpha2.InspectResult) string {
+	planName := result.Labels[constant.LabelPlanName]
+	if planName == "" {
+		return ""
+	}
+	plan, err := r.KubeEyeFactory.V1alpha2().InspectPlans().Lister().Get(planName)
+	if err != nil {
+		klog.Errorf("failed to get inspect plan %s, err:%s", planName, err)
+		return ""
+	}
+	return plan.Spec.ReportTemplate
+}
+
+// RenderReportTemplate renders the result with a report template ConfigMap.
+func RenderReportTemplate(k8sFactory informers.SharedInformerFactory, result *kubeeyev1alpha2.InspectResult, templateName string) ([]byte, string, error) {
+	cm, err := k8sFactory.Core().V1().ConfigMaps().Lister().ConfigMaps(os.Getenv("KUBERNETES_POD_NAMESPACE")).Get(templateName)
+	if err != nil {
+		return nil, "", err
+	}
+	reportTemplate, err := output.ParseReportTemplate(cm)
+	if err != nil {
+		return nil, "", err
+	}
+	data := bytes.NewBufferString("")
+	if err = reportTemplate.Execute(data, output.NewReportData(result)); err != nil {
+		return nil, "", err
+	}
+	return data.Bytes(), reportTemplate.ContentType, nil
+}
+
+// RenderMessageContent renders the result in the notification format, html by default.
+func RenderMessageContent(result *kubeeyev1alpha2.InspectResult, format string) ([]byte, string, error) {
+	data := bytes.NewBufferString("")
+	switch format {
+	case output.FormatMarkdown, output.FormatText:
+		var err error
+		if format == output.FormatMarkdown {
+			err = output.MarkdownOut(data, result, output.DefaultTopFindings)
+		} else {
+			err = output.TextOut(data, result, output.DefaultTopFindings)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		// markdown is readable as is, mail clients do not render it
+		return data.Bytes(), "text/plain", nil
+	case output.FormatReport:
+		if err := output.HtmlReportOut(data, result, nil); err != nil {
+			return nil, "", err
+		}
+		return data.Bytes(), "text/html", nil
+	case "", output.FormatHTML:
+		htmlTemplate, err := template.GetInspectResultHtmlTemplate()
+		if err != nil {
+			return nil, "", err
+		}
+		if err = htmlTemplate.Execute(data, output.HtmlData(result)); err != nil {
+			return nil, "", err
+		}
+		return data.Bytes(), "text/html", nil
+	}
+	return nil, "", fmt.Errorf("unsupported message format %s", format)
 }
 
 func GetIssuesNumber(result *kubeeyev1alpha2.InspectResult) (n int) {
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"os"
+	"strings"
+	"testing"
+
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"github.com/kubesphere/kubeeye/pkg/message"
+	"github.com/kubesphere/kubeeye/pkg/output"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func messageResult() *kubeeyev1alpha2.InspectResult {
+	return &kubeeyev1alpha2.InspectResult{
+		ObjectMeta: metav1.ObjectMeta{Name: "daily-default"},
+		Spec: kubeeyev1alpha2.InspectResultSpec{
+			InspectCluster: kubeeyev1alpha2.Cluster{Name: "default"},
+			SysctlResult: []kubeeyev1alpha2.NodeMetricsResultItem{
+				{BaseResult: kubeeyev1alpha2.BaseResult{Name: "net.ipv4.ip_forward", Assert: true, Level: kubeeyev1alpha2.DangerLevel}, Value: ptr.To("0"), NodeName: "node1"},
+				{BaseResult: kubeeyev1alpha2.BaseResult{Name: "vm.swappiness", Assert: true, Level: kubeeyev1alpha2.WarningLevel}, Value: ptr.To("60"), NodeName: "node1"},
+			},
+		},
+	}
+}
+
+func TestMessageEventOfFilteredResult(t *testing.T) {
+	r := &InspectResultReconciler{}
+	result := messageResult()
+	findings := output.MessageFindings(output.ResultRows(result))
+	view := output.FilterResult(result, findings[:1])
+
+	for _, format := range []string{output.FormatMarkdown, output.FormatText, output.FormatReport, output.FormatHTML} {
+		event, err := r.messageEvent(&conf.MessageConfig{Format: format, ReportURL: "https://kubeeye.example.com/"}, "", view, len(findings[:1]))
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		if event.Title != message.Title("", "default", 1) {
+			t.Errorf("%s: title %q should count the routed findings", format, event.Title)
+		}
+		if content := string(event.Content); !strings.Contains(content, "net.ipv4.ip_forward") || strings.Contains(content, "vm.swappiness") {
+			t.Errorf("%s: content should only hold the routed findings:\n%s", format, content)
+		}
+		if event.Summary.Total != 1 {
+			t.Errorf("%s: summary total %d, want 1", format, event.Summary.Total)
+		}
+		if event.ReportURL != "https://kubeeye.example.com/kapis/kubeeye.kubesphere.io/v1alpha2/inspectresults/daily-default?type=html" {
+			t.Errorf("%s: report url %s", format, event.ReportURL)
+		}
+	}
+}
+
+func TestRenderAttachmentsOfFilteredResult(t *testing.T) {
+	result := messageResult()
+	findings := output.MessageFindings(output.ResultRows(result))
+	r := &InspectResultReconciler{resultDir: t.TempDir()}
+	attachments := r.RenderAttachments(output.FilterResult(result, findings[1:]), []string{output.FormatCSV, output.FormatExcel}, true)
+	if len(attachments) != 2 {
+		t.Fatalf("got %d attachments, want csv and the xlsx rendered again", len(attachments))
+	}
+	if attachments[0].Name != "daily-default.csv" || attachments[1].Name != "daily-default.xlsx" || len(attachments[1].Data) == 0 {
+		t.Errorf("unexpected attachments %s (%d bytes), %s (%d bytes)", attachments[0].Name, len(attachments[0].Data), attachments[1].Name, len(attachments[1].Data))
+	}
+	records, err := csv.NewReader(strings.NewReader(string(attachments[0].Data))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][2] != "vm.swappiness" {
+		t.Errorf("csv attachment should only hold the routed finding: %v", records)
+	}
+}
+
+func TestRenderAttachmentsReadsSavedExcel(t *testing.T) {
+	r := &InspectResultReconciler{resultDir: t.TempDir()}
+	result := messageResult()
+	if err := os.WriteFile(r.resultFile(result.Name)+".xlsx", []byte("saved"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	attachments := r.RenderAttachments(result, []string{output.FormatExcel}, false)
+	if len(attachments) != 1 || string(attachments[0].Data) != "saved" {
+		t.Errorf("the saved xlsx in the result directory should be attached: %v", attachments)
+	}
+}
@@ -18,11 +18,14 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
 	"github.com/kubesphere/kubeeye/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
 	kubeErr "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -32,12 +35,14 @@ import (
 // InspectRulesReconciler reconciles a Insights object
 type InspectRulesReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=kubeeye.kubesphere.io,resources=inspectrules,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=kubeeye.kubesphere.io,resources=inspectrules/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=kubeeye.kubesphere.io,resources=inspectrules/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -87,6 +92,7 @@ func (r *InspectRulesReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	if inspectRules.Status.State == "" {
 		inspectRules.Status.State = kubeeyev1alpha2.StartImport
 		inspectRules.Status.StartImportTime = &v1.Time{Time: time.Now()}
+		setCondition(&inspectRules.Status.Conditions, inspectRules.Generation, kubeeyev1alpha2.ConditionCompleted, v1.ConditionFalse, kubeeyev1alpha2.ReasonImporting, "the rules are being imported")
 		err = r.Status().Update(ctx, inspectRules)
 		if err != nil {
 			klog.Error(err, "failed to update inspect ruleFiles")
@@ -131,11 +137,18 @@ func (r *InspectRulesReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	inspectRules.Status.EndImportTime = &v1.Time{Time: time.Now()}
 	inspectRules.Status.State = kubeeyev1alpha2.ImportComplete
 	inspectRules.Status.LevelCount = levelCount
+	total := 0
+	for _, n := range levelCount {
+		total += *n
+	}
+	message := fmt.Sprintf("imported %d rules", total)
+	setCondition(&inspectRules.Status.Conditions, inspectRules.Generation, kubeeyev1alpha2.ConditionCompleted, v1.ConditionTrue, kubeeyev1alpha2.ReasonImported, message)
 	err = r.Status().Update(ctx, inspectRules)
 	if err != nil {
 		klog.Error(err, "failed to update inspect ruleFiles")
 		return ctrl.Result{}, err
 	}
+	r.Recorder.Event(inspectRules, corev1.EventTypeNormal, kubeeyev1alpha2.ReasonImported, message)
 
 	return ctrl.Result{}, nil
 }
@@ -22,19 +22,26 @@ import (
 	"fmt"
 	kubeeyeInformers "github.com/kubesphere/kubeeye/clients/informers/externalversions/kubeeye"
 	"github.com/kubesphere/kubeeye/pkg/constant"
+	"github.com/kubesphere/kubeeye/pkg/metrics"
 	"github.com/kubesphere/kubeeye/pkg/output"
 	"github.com/kubesphere/kubeeye/pkg/rules"
 	"github.com/kubesphere/kubeeye/pkg/template"
 	"github.com/kubesphere/kubeeye/pkg/utils"
 	v1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"math"
 	"os"
 	"path"
-	"sync"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
@@ -42,10 +49,21 @@ import (
 	"github.com/kubesphere/kubeeye/pkg/inspect"
 	"github.com/kubesphere/kubeeye/pkg/kube"
 	kubeErr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// jobResyncPeriod is how often the jobs are checked when nothing changes, to time them out
+	jobResyncPeriod = time.Minute
+	// remoteJobCheckInterval is how often the jobs of member clusters are checked, they are not watched
+	remoteJobCheckInterval = 10 * time.Second
 )
 
 // InspectTaskReconciler reconciles a InspectTask object
@@ -55,6 +73,10 @@ type InspectTaskReconciler struct {
 	K8sClients     *kube.KubernetesClient
 	KubeEyeFactory kubeeyeInformers.Interface
 	K8sFactory     informers.SharedInformerFactory
+	Recorder       record.EventRecorder
+
+	// resultDir holds the result files, constant.ResultPathPrefix when empty
+	resultDir string
 }
 
 //+kubebuilder:rbac:groups=kubeeye.kubesphere.io,resources=inspecttasks,verbs=get;list;watch;create;update;patch;delete
@@ -65,8 +87,9 @@ type InspectTaskReconciler struct {
 //+kubebuilder:rbac:groups="",resources=namespaces,verbs=create
 //+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=create;delete
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=deletecollection
-//+kubebuilder:rbac:groups="batch",resources=jobs,verbs=create;get;delete
+//+kubebuilder:rbac:groups="batch",resources=jobs,verbs=create;get;list;watch;delete
 //+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterroles;clusterrolebindings,verbs="*"
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -99,6 +122,9 @@ func (r *InspectTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			return ctrl.Result{}, nil
 		}
 	} else {
+		if hasUnfinishedJobs(inspectTask, "") {
+			r.deleteTaskJobs(ctx, inspectTask)
+		}
 		newFinalizers := utils.SliceRemove(Finalizers, inspectTask.Finalizers)
 		inspectTask.Finalizers = newFinalizers.([]string)
 		klog.Infof("inspect task is being deleted")
@@ -110,7 +136,7 @@ func (r *InspectTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
-	if inspectTask.Status.Status.IsSucceeded() || inspectTask.Status.Status.IsFailed() {
+	if inspectTask.Status.Status.IsFinished() {
 		return ctrl.Result{}, nil
 	}
 
@@ -122,126 +148,279 @@ func (r *InspectTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 		inspectTask.Status.StartTimestamp = &metav1.Time{Time: time.Now()}
 		inspectTask.Status.Status = kubeeyev1alpha2.PhaseRunning
+		setTaskConditions(&inspectTask.Status.Conditions, inspectTask.Generation, inspectTask.Status.Status, nil)
 		err = r.Status().Update(ctx, inspectTask)
 		if err != nil {
 			klog.Error("Failed to update inspect plan status. ", err)
 			return ctrl.Result{}, err
 		}
+		r.Recorder.Event(inspectTask, corev1.EventTypeNormal, kubeeyev1alpha2.ReasonStarted, "inspection started")
 
 		return ctrl.Result{}, nil
 	}
-	inspectTask.Status.ClusterInfo, err = r.getClusterInfo(ctx)
-	if err != nil {
-		klog.Error("failed to get cluster info. ", err)
-		return ctrl.Result{}, err
+	if inspectTask.Spec.Cancel {
+		return ctrl.Result{}, r.cancelTask(ctx, inspectTask)
+	}
+	if len(inspectTask.Status.JobPhase) == 0 {
+		return r.prepareInspect(ctx, inspectTask)
 	}
-	var kubeEyeConfig conf.KubeEyeConfig
-	kubeEyeConfig, err = kube.GetKubeEyeConfig(r.K8sFactory.Core())
+	return r.syncJobs(ctx, inspectTask)
+}
+
+// prepareInspect generates the rules and the jobs of every cluster of the task. The jobs are then created and
+// followed by syncJobs as the jobs and their pods change.
+func (r *InspectTaskReconciler) prepareInspect(ctx context.Context, task *kubeeyev1alpha2.InspectTask) (ctrl.Result, error) {
+	var err error
+	task.Status.ClusterInfo, err = r.getClusterInfo(ctx)
 	if err != nil {
-		klog.Error("Unable to get jobConfig")
+		klog.Error("failed to get cluster info. ", err)
 		return ctrl.Result{}, err
 	}
 
-	if inspectTask.Spec.ClusterName != nil {
-		var wait sync.WaitGroup
-		wait.Add(len(inspectTask.Spec.ClusterName))
-		for _, cluster := range inspectTask.Spec.ClusterName {
-			go func(c kubeeyev1alpha2.Cluster) {
-				defer wait.Done()
-				clusterClient, err := kube.GetMultiClusterClient(ctx, r.K8sClients, c.Name)
-				if err != nil {
-					klog.Error(err, "Failed to get multi-cluster client.")
-					return
-				}
-				err = r.initClusterInspectConfig(ctx, clusterClient)
-				if err != nil {
-					klog.Errorf("failed To Initialize Cluster Configuration for Cluster Name:%s,err:%s", c, err)
-					return
-				}
-				err = r.createInspect(ctx, c, inspectTask, clusterClient, kubeEyeConfig)
-				if err != nil {
-					klog.Error("failed to create inspect. ", err)
-				}
-			}(cluster)
+	for _, cluster := range taskClusters(task) {
+		clients, err := r.clusterClients(ctx, task, cluster.Name)
+		if err != nil {
+			klog.Error(err, "Failed to get multi-cluster client.")
+			r.Recorder.Eventf(task, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonClusterFailed, "failed to get the client of cluster %s: %s", cluster.Name, err)
+			continue
 		}
-		wait.Wait()
-	} else {
-		err = r.initClusterInspectConfig(ctx, r.K8sClients)
+		err = r.initClusterInspectConfig(ctx, clients)
 		if err != nil {
-			klog.Errorf("failed To Initialize Cluster Configuration for Cluster Name:%s,err:%s", "default", err)
-			return ctrl.Result{}, err
+			klog.Errorf("failed To Initialize Cluster Configuration for Cluster Name:%s,err:%s", cluster.Name, err)
+			r.Recorder.Eventf(task, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonClusterFailed, "failed to initialize cluster %s: %s", cluster.Name, err)
+			if task.Spec.ClusterName == nil {
+				return ctrl.Result{}, err
+			}
+			continue
 		}
-		err = r.createInspect(ctx, kubeeyev1alpha2.Cluster{Name: "default"}, inspectTask, r.K8sClients, kubeEyeConfig)
+		jobs, err := r.prepareCluster(ctx, cluster, task, clients)
 		if err != nil {
-			return ctrl.Result{}, err
+			klog.Error("failed to create inspect. ", err)
+			r.Recorder.Eventf(task, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonFailed, "failed to inspect cluster %s: %s", cluster.Name, err)
+			if task.Spec.ClusterName == nil {
+				return ctrl.Result{}, err
+			}
+			continue
 		}
+		task.Status.JobPhase = append(task.Status.JobPhase, jobs...)
 	}
 
-	taskStatus := GetStatus(inspectTask)
-	inspectTask.Status.Status = taskStatus
-	err = r.Status().Update(ctx, inspectTask)
+	if len(task.Status.JobPhase) == 0 {
+		return ctrl.Result{}, r.finishTask(ctx, task)
+	}
+	setTaskProgress(task, time.Now())
+	err = r.Status().Update(ctx, task)
 	if err != nil {
 		klog.Error("failed to update inspect task. ", err)
 		return ctrl.Result{}, err
 	}
-	klog.Infof("all job finished for taskName:%s", inspectTask.Name)
-
-	err = r.updatePlanStatus(ctx, taskStatus, inspectTask.Labels[constant.LabelPlanName], inspectTask.Name)
-	if err != nil {
-		klog.Error("failed to update inspect plan comeToAnEnd status. ", err)
-	}
-
 	return ctrl.Result{}, nil
+}
 
+// cancelTask deletes the outstanding jobs of the task and finishes it, the results of the finished jobs are kept.
+func (r *InspectTaskReconciler) cancelTask(ctx context.Context, task *kubeeyev1alpha2.InspectTask) error {
+	klog.Infof("cancelling inspect task %s", task.Name)
+	r.deleteTaskJobs(ctx, task)
+	for i := range task.Status.JobPhase {
+		phase := &task.Status.JobPhase[i]
+		if phase.Phase.IsPending() || phase.Phase.IsRunning() {
+			phase.Phase, phase.Reason, phase.Message = kubeeyev1alpha2.PhaseCancelled, kubeeyev1alpha2.ReasonCancelled, "the task was cancelled"
+		}
+	}
+	return r.finishTask(ctx, task)
 }
 
-func (r *InspectTaskReconciler) createInspect(ctx context.Context, cluster kubeeyev1alpha2.Cluster, task *kubeeyev1alpha2.InspectTask, clients *kube.KubernetesClient, kubeEyeConfig conf.KubeEyeConfig) error {
+// prepareCluster creates the rules of the jobs of a cluster and an empty result file, the results of the jobs are
+// merged into it as they complete. It returns the jobs to create.
+func (r *InspectTaskReconciler) prepareCluster(ctx context.Context, cluster kubeeyev1alpha2.Cluster, task *kubeeyev1alpha2.InspectTask, clients *kube.KubernetesClient) ([]kubeeyev1alpha2.JobPhase, error) {
 	e := rules.NewExecuteRuleOptions(clients, task)
 
 	mergeRule, err := e.MergeRule(r.getRules(task))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	createInspectRule, err := e.CreateInspectRule(ctx, e.GenerateJob(ctx, mergeRule))
+	jobRules, err := e.CreateInspectRule(ctx, e.GenerateJob(ctx, mergeRule))
 	if err != nil {
-		return err
+		return nil, err
+	}
+	for k, v := range e.GetRuleTotal() {
+		if v > 0 && !slices.Contains(task.Status.InspectRuleType, k) {
+			task.Status.InspectRuleType = append(task.Status.InspectRuleType, k)
+		}
+	}
+	sort.Strings(task.Status.InspectRuleType)
+
+	name := resultName(cluster.Name, task)
+	r.cleanResult(clients, task, name)
+	err = writeResultFile(r.resultFile(name), &kubeeyev1alpha2.InspectResult{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: kubeeyev1alpha2.InspectResultSpec{
+			InspectRuleTotal: e.GetRuleTotal(),
+			InspectCluster:   cluster,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []kubeeyev1alpha2.JobPhase
+	for _, rule := range jobRules {
+		if _, ok := inspect.RuleOperatorMap[rule.RuleType]; !ok {
+			klog.Errorf("%s not found", rule.RuleType)
+			continue
+		}
+		nodeName, _ := rules.GetDeploySchedule(rule.RunRule)
+		if !selectJob(task.Spec.Jobs, cluster.Name, rule.RuleType, nodeName) {
+			continue
+		}
+		jobs = append(jobs, kubeeyev1alpha2.JobPhase{
+			JobName:  rule.JobName,
+			Phase:    kubeeyev1alpha2.PhasePending,
+			Cluster:  cluster.Name,
+			RuleType: rule.RuleType,
+			NodeName: nodeName,
+		})
+	}
+	return jobs, nil
+}
+
+// selectJob returns whether a job matches the selectors of the task, all jobs match when there are none.
+func selectJob(selectors []kubeeyev1alpha2.JobSelector, cluster string, ruleType string, nodeName string) bool {
+	if len(selectors) == 0 {
+		return true
 	}
-	jobConfig := kubeEyeConfig.GetClusterJobConfig(cluster.Name)
+	return slices.ContainsFunc(selectors, func(s kubeeyev1alpha2.JobSelector) bool {
+		return (s.Cluster == "" || s.Cluster == cluster) && (s.RuleType == "" || s.RuleType == ruleType) && (s.NodeName == "" || s.NodeName == nodeName)
+	})
+}
 
+// finishTask creates the results of the task when all its jobs have finished, and sets its final status.
+func (r *InspectTaskReconciler) finishTask(ctx context.Context, task *kubeeyev1alpha2.InspectTask) error {
 	task.Status.EndTimestamp = &metav1.Time{Time: time.Now()}
 	task.Status.Duration = task.Status.EndTimestamp.Sub(task.Status.StartTimestamp.Time).String()
-	task.Status.InspectRuleType = func() (data []string) {
-		for k, v := range e.GetRuleTotal() {
-			if v > 0 {
-				data = append(data, k)
+	for _, cluster := range taskClusters(task) {
+		if !slices.ContainsFunc(task.Status.JobPhase, func(p kubeeyev1alpha2.JobPhase) bool { return p.Cluster == cluster.Name }) {
+			continue
+		}
+		clients, err := r.clusterClients(ctx, task, cluster.Name)
+		if err != nil {
+			klog.Error(err, "Failed to get multi-cluster client.")
+			r.Recorder.Eventf(task, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonClusterFailed, "failed to get the client of cluster %s: %s", cluster.Name, err)
+			clients = nil
+		}
+		err = r.createResult(ctx, task, cluster, clients)
+		if err != nil {
+			klog.Error("failed to create inspect result. ", err)
+			r.Recorder.Eventf(task, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonResultParseFailed, "failed to create the result of cluster %s: %s", cluster.Name, err)
+		}
+		if clients != nil {
+			if err = r.cleanClusterInspectConfig(ctx, clients, task, cluster.Name); err != nil {
+				klog.Errorf("failed to clean the inspect config of cluster %s, err:%s", cluster.Name, err)
 			}
 		}
-		return data
-	}()
-	result := r.GenerateResult(task, cluster, clients, e.GetRuleTotal())
-	deepCopyResult := result.DeepCopy()
-	JobPhase, err := r.createJobsInspect(ctx, task, clients, jobConfig, createInspectRule, deepCopyResult)
+	}
+
+	setTaskProgress(task, time.Now())
+	taskStatus := GetStatus(task)
+	task.Status.Status = taskStatus
+	setTaskConditions(&task.Status.Conditions, task.Generation, taskStatus, task.Status.JobPhase)
+	err := r.Status().Update(ctx, task)
 	if err != nil {
+		klog.Error("failed to update inspect task. ", err)
 		return err
 	}
-	task.Status.JobPhase = append(task.Status.JobPhase, JobPhase...)
+	klog.Infof("all job finished for taskName:%s", task.Name)
+	completed := meta.FindStatusCondition(task.Status.Conditions, kubeeyev1alpha2.ConditionCompleted)
+	if taskStatus.IsSucceeded() {
+		r.Recorder.Event(task, corev1.EventTypeNormal, kubeeyev1alpha2.ReasonSucceeded, "inspection completed, "+completed.Message)
+	} else if taskStatus.IsPartiallySucceeded() {
+		r.Recorder.Event(task, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonPartiallySucceeded, "inspection partially completed, "+completed.Message)
+	} else if taskStatus.IsCancelled() {
+		r.Recorder.Event(task, corev1.EventTypeNormal, kubeeyev1alpha2.ReasonCancelled, "inspection cancelled, "+completed.Message)
+	} else {
+		r.Recorder.Event(task, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonFailed, "inspection failed, "+completed.Message)
+	}
 
-	err = r.Create(ctx, &result)
+	err = r.updatePlanStatus(ctx, taskStatus, task.Labels[constant.LabelPlanName], task.Name)
+	if err != nil {
+		klog.Error("failed to update inspect plan comeToAnEnd status. ", err)
+	}
+	return nil
+}
+
+// createResult saves the merged results of a cluster with its excel report, and creates the InspectResult. The
+// excel report has no node and pod sheets when the cluster is not reachable.
+func (r *InspectTaskReconciler) createResult(ctx context.Context, task *kubeeyev1alpha2.InspectTask, cluster kubeeyev1alpha2.Cluster, clients *kube.KubernetesClient) error {
+	resultData, err := output.ReadResultFile(r.resultFile(resultName(cluster.Name, task)))
 	if err != nil {
 		return err
 	}
-	err = r.cleanClusterInspectConfig(ctx, clients, task)
+	result := r.GenerateResult(task, cluster, resultData.Spec.InspectRuleTotal)
+	merged := resultData.Annotations[constant.AnnotationMergedJobs]
+	resultData.ObjectMeta = *result.ObjectMeta.DeepCopy()
+	// kept in the file in case the status of the task is not saved and the jobs are checked again
+	resultData.Annotations[constant.AnnotationMergedJobs] = merged
+
+	var nodes *corev1.NodeList
+	var pods *corev1.PodList
+	if clients != nil {
+		nodes, err = clients.ClientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		pods, err = clients.ClientSet.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	err = saveResultFile(r.resultFile(resultData.Name), resultData, nodes, pods)
 	if err != nil {
 		return err
 	}
+
+	err = r.Create(ctx, &result)
+	if err != nil && !kubeErr.IsAlreadyExists(err) {
+		return err
+	}
 	return nil
 }
 
-func (r *InspectTaskReconciler) GenerateResult(task *kubeeyev1alpha2.InspectTask, cluster kubeeyev1alpha2.Cluster, clients *kube.KubernetesClient, ruleNum map[string]int) kubeeyev1alpha2.InspectResult {
-	var ownerRefBol = true
-	resultName := fmt.Sprintf("%s-%s-result", cluster.Name, task.Name)
+func resultName(cluster string, task *kubeeyev1alpha2.InspectTask) string {
+	return fmt.Sprintf("%s-%s-result", cluster, task.Name)
+}
+
+// resultFile returns the path of the result file of a cluster.
+func (r *InspectTaskReconciler) resultFile(resultName string) string {
+	return resultPath(r.resultDir, resultName)
+}
+
+// resultPath returns the path of a result file in dir, constant.ResultPathPrefix when dir is empty. The excel
+// report of the result is saved next to it with the .xlsx extension.
+func resultPath(dir string, resultName string) string {
+	if dir == "" {
+		return path.Join(constant.ResultPathPrefix, resultName)
+	}
+	return path.Join(dir, resultName)
+}
+
+// taskClusters returns the clusters inspected by the task, default for the cluster KubeEye runs in.
+func taskClusters(task *kubeeyev1alpha2.InspectTask) []kubeeyev1alpha2.Cluster {
+	if task.Spec.ClusterName == nil {
+		return []kubeeyev1alpha2.Cluster{{Name: "default"}}
+	}
+	return task.Spec.ClusterName
+}
+
+func (r *InspectTaskReconciler) clusterClients(ctx context.Context, task *kubeeyev1alpha2.InspectTask, cluster string) (*kube.KubernetesClient, error) {
+	if task.Spec.ClusterName == nil {
+		return r.K8sClients, nil
+	}
+	return kube.GetMultiClusterClient(ctx, r.K8sClients, cluster)
+}
 
+// cleanResult deletes the results left by an earlier run of the task.
+func (r *InspectTaskReconciler) cleanResult(clients *kube.KubernetesClient, task *kubeeyev1alpha2.InspectTask, resultName string) {
 	list, err := clients.ClientSet.CoreV1().ConfigMaps(os.Getenv("KUBERNETES_POD_NAMESPACE")).List(context.TODO(), metav1.ListOptions{LabelSelector: labels.FormatLabels(map[string]string{constant.LabelTaskName: task.Name})})
 	if err == nil && len(list.Items) > 0 {
 		err = clients.ClientSet.CoreV1().ConfigMaps(os.Getenv("KUBERNETES_POD_NAMESPACE")).DeleteCollection(context.TODO(), metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: labels.FormatLabels(map[string]string{constant.LabelTaskName: task.Name})})
@@ -250,26 +429,29 @@ func (r *InspectTaskReconciler) GenerateResult(task *kubeeyev1alpha2.InspectTask
 		}
 	}
 
-	file, err := os.Open(path.Join(constant.ResultPathPrefix, resultName))
+	file, err := os.Open(r.resultFile(resultName))
 	if err == nil {
 		defer file.Close()
-		err = os.Remove(path.Join(constant.ResultPathPrefix, resultName))
+		err = os.Remove(r.resultFile(resultName))
 		if err != nil {
 			klog.Error("failed to delete result ")
 		}
 	}
 
-	file, err = os.Open(fmt.Sprintf("%s.xlsx", path.Join(constant.ResultPathPrefix, resultName)))
+	file, err = os.Open(fmt.Sprintf("%s.xlsx", r.resultFile(resultName)))
 	if err == nil {
 		defer file.Close()
-		err = os.Remove(fmt.Sprintf("%s.xlsx", path.Join(constant.ResultPathPrefix, resultName)))
+		err = os.Remove(fmt.Sprintf("%s.xlsx", r.resultFile(resultName)))
 		if err != nil {
 			klog.Error("failed to delete result xlsx file")
 		}
 	}
+}
 
+func (r *InspectTaskReconciler) GenerateResult(task *kubeeyev1alpha2.InspectTask, cluster kubeeyev1alpha2.Cluster, ruleNum map[string]int) kubeeyev1alpha2.InspectResult {
+	var ownerRefBol = true
 	return kubeeyev1alpha2.InspectResult{
-		ObjectMeta: metav1.ObjectMeta{Name: resultName,
+		ObjectMeta: metav1.ObjectMeta{Name: resultName(cluster.Name, task),
 			Labels: map[string]string{
 				constant.LabelTaskName: task.Name,
 				constant.LabelPlanName: task.Labels[constant.LabelPlanName],
@@ -297,16 +479,27 @@ func (r *InspectTaskReconciler) GenerateResult(task *kubeeyev1alpha2.InspectTask
 }
 
 func GetStatus(task *kubeeyev1alpha2.InspectTask) kubeeyev1alpha2.Phase {
+	if task.Spec.Cancel {
+		return kubeeyev1alpha2.PhaseCancelled
+	}
 	if task.Status.JobPhase == nil {
 		return kubeeyev1alpha2.PhaseFailed
 	}
-	_, ok, _ := utils.ArrayFinds(task.Status.JobPhase, func(m kubeeyev1alpha2.JobPhase) bool {
-		return m.Phase.IsFailed()
-	})
-	if ok {
+	failed := 0
+	for _, job := range task.Status.JobPhase {
+		if job.Phase.IsFailed() {
+			failed++
+		}
+	}
+	switch {
+	case failed == 0:
+		return kubeeyev1alpha2.PhaseSucceeded
+	case failed < len(task.Status.JobPhase):
+		// the results of the other jobs are still worth reading, e.g. when a node of many was not ready
+		return kubeeyev1alpha2.PhasePartiallySucceeded
+	default:
 		return kubeeyev1alpha2.PhaseFailed
 	}
-	return kubeeyev1alpha2.PhaseSucceeded
 }
 
 func (r *InspectTaskReconciler) getClusterInfo(ctx context.Context) (kubeeyev1alpha2.ClusterInfo, error) {
@@ -336,60 +529,374 @@ func (r *InspectTaskReconciler) getClusterInfo(ctx context.Context) (kubeeyev1al
 func (r *InspectTaskReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&kubeeyev1alpha2.InspectTask{}).
+		Watches(&v1.Job{}, handler.EnqueueRequestsFromMapFunc(taskRequest)).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(taskRequest)).
 		Complete(r)
 }
 
-func (r *InspectTaskReconciler) createJobsInspect(ctx context.Context, task *kubeeyev1alpha2.InspectTask, kubeClient *kube.KubernetesClient, config *conf.JobConfig, jobRules []kubeeyev1alpha2.JobRule, resultData *kubeeyev1alpha2.InspectResult) ([]kubeeyev1alpha2.JobPhase, error) {
-	var jobNames []kubeeyev1alpha2.JobPhase
-	nodes := kube.GetNodes(ctx, kubeClient.ClientSet)
+// CacheOptions only caches the jobs and pods of the inspect tasks, they are watched by the task controller.
+func CacheOptions() cache.Options {
+	requirement, _ := labels.NewRequirement(constant.LabelTaskName, selection.Exists, nil)
+	selector := labels.NewSelector().Add(*requirement)
+	return cache.Options{ByObject: map[client.Object]cache.ByObject{
+		&v1.Job{}:     {Label: selector},
+		&corev1.Pod{}: {Label: selector},
+	}}
+}
 
-	var wg sync.WaitGroup
-	var mutex sync.Mutex
-	semaphore := make(chan struct{}, computedDeployNum(len(nodes), len(jobRules)))
-	for _, rule := range jobRules {
-		wg.Add(1)
-		semaphore <- struct{}{}
-		go func(v kubeeyev1alpha2.JobRule) {
-			defer func() {
-				wg.Done()
-				<-semaphore
-			}()
+// taskRequest maps the jobs and pods labelled with the name of a task to the task.
+func taskRequest(_ context.Context, obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[constant.LabelTaskName]
+	if !ok || name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name}}}
+}
+
+// syncJobs creates the pending jobs as far as the concurrency allows and follows the running ones. It is triggered
+// by the changes of the jobs and pods of the task, and finishes the task when all jobs have finished.
+func (r *InspectTaskReconciler) syncJobs(ctx context.Context, task *kubeeyev1alpha2.InspectTask) (ctrl.Result, error) {
+	kubeEyeConfig, err := kube.GetKubeEyeConfig(r.K8sFactory.Core())
+	if err != nil {
+		klog.Error("Unable to get jobConfig")
+		return ctrl.Result{}, err
+	}
+
+	status := task.Status.DeepCopy()
+	requeueAfter := jobResyncPeriod
+	for _, cluster := range taskClusters(task) {
+		if !hasUnfinishedJobs(task, cluster.Name) {
+			continue
+		}
+		clients, err := r.clusterClients(ctx, task, cluster.Name)
+		if err != nil {
+			klog.Error(err, "Failed to get multi-cluster client.")
+			r.Recorder.Eventf(task, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonClusterFailed, "failed to get the client of cluster %s: %s", cluster.Name, err)
 			if isTimeout(task.CreationTimestamp, task.Spec.Timeout) {
-				jobNames = append(jobNames, kubeeyev1alpha2.JobPhase{JobName: v.JobName, Phase: kubeeyev1alpha2.PhaseFailed})
-				return
-			}
-			_, status := inspect.RuleOperatorMap[v.RuleType]
-			if status {
-				if checkJobIsDeploy(nodes, getIncompleteJob(ctx, kubeClient, task, v.RuleType), v) {
-					jobTask, err := createInspectJob(ctx, kubeClient, &v, task, config, v.RuleType)
-					if err != nil {
-						klog.Errorf("create job error. error:%s", err)
-						jobNames = append(jobNames, kubeeyev1alpha2.JobPhase{JobName: v.JobName, Phase: kubeeyev1alpha2.PhaseFailed})
-						return
-					}
-					klog.Infof("Job %s starting created", v.JobName)
-					resultJob := r.waitForJobCompletionGetResult(ctx, kubeClient, v.JobName, jobTask, task.Spec.Timeout)
-					mutex.Lock()
-					jobNames = append(jobNames, *resultJob)
-					err = r.getInspectResultData(ctx, kubeClient, resultData, resultJob.JobName)
-					if err != nil {
-						klog.Error("failed to get inspect result data", err)
-						jobNames = append(jobNames, kubeeyev1alpha2.JobPhase{JobName: v.JobName, Phase: kubeeyev1alpha2.PhaseFailed})
+				for i := range task.Status.JobPhase {
+					phase := &task.Status.JobPhase[i]
+					if phase.Cluster == cluster.Name && (phase.Phase.IsPending() || phase.Phase.IsRunning()) {
+						r.failJob(task, phase, kubeeyev1alpha2.ReasonClusterFailed, err.Error())
 					}
-					mutex.Unlock()
+				}
+			}
+			requeueAfter = min(requeueAfter, remoteJobCheckInterval)
+			continue
+		}
+		requeueAfter = min(requeueAfter, r.syncClusterJobs(ctx, task, cluster.Name, clients, kubeEyeConfig.GetClusterJobConfig(cluster.Name)))
+	}
+
+	if !hasUnfinishedJobs(task, "") {
+		return ctrl.Result{}, r.finishTask(ctx, task)
+	}
+	setTaskProgress(task, time.Now())
+	if !equality.Semantic.DeepEqual(status, &task.Status) {
+		err = r.Status().Update(ctx, task)
+		if err != nil {
+			klog.Error("failed to update inspect task. ", err)
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// syncClusterJobs updates the jobs of the task in a cluster, and returns when they have to be checked again.
+func (r *InspectTaskReconciler) syncClusterJobs(ctx context.Context, task *kubeeyev1alpha2.InspectTask, cluster string, clients *kube.KubernetesClient, config *conf.JobConfig) time.Duration {
+	// only the jobs of the cluster KubeEye runs in are watched
+	local := task.Spec.ClusterName == nil
+	requeueAfter := jobResyncPeriod
+	if !local {
+		requeueAfter = remoteJobCheckInterval
+	}
+	jobs, pods, err := r.listTaskJobs(ctx, task, clients, local)
+	if err != nil {
+		klog.Errorf("failed to list the jobs of task %s, err:%s", task.Name, err)
+		return remoteJobCheckInterval
+	}
+	timeout, err := time.ParseDuration(task.Spec.Timeout)
+	if err != nil {
+		timeout = constant.DefaultTimeout
+	}
+
+	r.adoptJobs(ctx, task, cluster, clients, jobs)
+
+	running, total := 0, 0
+	var pending []*kubeeyev1alpha2.JobPhase
+	for i := range task.Status.JobPhase {
+		phase := &task.Status.JobPhase[i]
+		if phase.Cluster != cluster {
+			continue
+		}
+		total++
+		if !phase.Phase.IsRunning() {
+			continue
+		}
+		job, ok := jobs[phase.JobName]
+		if !ok {
+			// the cache may not have seen a job just created yet
+			job, err = clients.ClientSet.BatchV1().Jobs(os.Getenv("KUBERNETES_POD_NAMESPACE")).Get(ctx, phase.JobName, metav1.GetOptions{})
+			if err != nil {
+				if kubeErr.IsNotFound(err) {
+					r.syncDeletedJob(task, phase)
 				} else {
-					klog.Errorf("failed  to deploy job with name %s", v.JobName)
-					jobNames = append(jobNames, kubeeyev1alpha2.JobPhase{JobName: v.JobName, Phase: kubeeyev1alpha2.PhaseFailed})
+					klog.Infof("failed to get job info for name:%s,err:%s", phase.JobName, err)
+					running++
 				}
-				klog.Infof("Job %s completed", v.JobName)
+				continue
+			}
+		}
+		r.syncJob(ctx, task, clients, phase, job, pods)
+		if phase.Phase.IsRunning() {
+			running++
+			requeueAfter = min(requeueAfter, time.Until(job.CreationTimestamp.Add(timeout)))
+		}
+	}
+	// the failed jobs retried above are pending again
+	for i := range task.Status.JobPhase {
+		phase := &task.Status.JobPhase[i]
+		if phase.Cluster == cluster && phase.Phase.IsPending() {
+			pending = append(pending, phase)
+		}
+	}
+	if len(pending) == 0 {
+		return max(requeueAfter, time.Second)
+	}
+
+	jobRules, err := getJobRules(ctx, clients, task)
+	if err != nil {
+		klog.Errorf("failed to get the inspect rules of task %s, err:%s", task.Name, err)
+	}
+	nodes := kube.GetNodes(ctx, clients.ClientSet)
+	incomplete := map[string][]corev1.Pod{}
+	limit := computedDeployNum(len(nodes), total)
+	for _, phase := range pending {
+		if isTimeout(task.CreationTimestamp, task.Spec.Timeout) {
+			if phase.Attempts > 0 {
+				// keep why the last attempt failed
+				r.failJob(task, phase, phase.Reason, fmt.Sprintf("the job was not retried, the task timed out after %s: %s", task.Spec.Timeout, phase.Message))
 			} else {
-				klog.Errorf("%s not found", v.RuleType)
+				r.failJob(task, phase, kubeeyev1alpha2.ReasonJobTimeout, fmt.Sprintf("the job was not started, the task timed out after %s", task.Spec.Timeout))
 			}
-		}(rule)
+			continue
+		}
+		if phase.NextAttemptTime != nil && time.Now().Before(phase.NextAttemptTime.Time) {
+			requeueAfter = min(requeueAfter, time.Until(phase.NextAttemptTime.Time))
+			continue
+		}
+		if _, ok := jobs[phase.JobName]; ok {
+			// the job of the last attempt is being deleted
+			requeueAfter = min(requeueAfter, remoteJobCheckInterval)
+			continue
+		}
+		if running >= limit {
+			continue
+		}
+		rule, ok := jobRules[phase.JobName]
+		if !ok {
+			r.failJob(task, phase, kubeeyev1alpha2.ReasonJobCreateFailed, "the rules of the job are not found")
+			continue
+		}
+		phase.Attempts++
+		if _, ok = incomplete[phase.RuleType]; !ok {
+			incomplete[phase.RuleType] = getIncompleteJob(ctx, clients, task, phase.RuleType)
+		}
+		if err = checkJobIsDeploy(nodes, incomplete[phase.RuleType], rule); err != nil {
+			klog.Errorf("failed  to deploy job with name %s", phase.JobName)
+			r.failJob(task, phase, kubeeyev1alpha2.ReasonNodeUnavailable, err.Error())
+			continue
+		}
+		_, err = createInspectJob(ctx, clients, &rule, task, config, rule.RuleType)
+		if err != nil && kubeErr.IsAlreadyExists(err) {
+			// created before the status of the task could be saved
+			klog.Infof("Job %s already exists", phase.JobName)
+		} else if err != nil {
+			klog.Errorf("create job error. error:%s", err)
+			r.failJob(task, phase, kubeeyev1alpha2.ReasonJobCreateFailed, err.Error())
+			continue
+		}
+		klog.Infof("Job %s starting created", phase.JobName)
+		phase.Phase, phase.NextAttemptTime = kubeeyev1alpha2.PhaseRunning, nil
+		running++
+	}
+	return max(min(requeueAfter, time.Until(task.CreationTimestamp.Add(timeout))), time.Second)
+}
+
+// adoptJobs reconciles the jobs found in a cluster with the status of the task, which may not have been saved after
+// the jobs changed, e.g. when the manager restarted. A pending job that exists was created and is running, the jobs
+// of finished phases or unknown to the task are left over and deleted.
+func (r *InspectTaskReconciler) adoptJobs(ctx context.Context, task *kubeeyev1alpha2.InspectTask, cluster string, clients *kube.KubernetesClient, jobs map[string]*v1.Job) {
+	phases := make(map[string]*kubeeyev1alpha2.JobPhase, len(task.Status.JobPhase))
+	for i := range task.Status.JobPhase {
+		if task.Status.JobPhase[i].Cluster == cluster {
+			phases[task.Status.JobPhase[i].JobName] = &task.Status.JobPhase[i]
+		}
+	}
+	for name, job := range jobs {
+		if !job.DeletionTimestamp.IsZero() {
+			continue
+		}
+		phase, ok := phases[name]
+		switch {
+		case !ok:
+			klog.Infof("deleting job %s, it is not a job of task %s", name, task.Name)
+			r.deleteJob(ctx, clients, name)
+		case phase.Phase.IsPending() && phase.NextAttemptTime != nil && job.CreationTimestamp.Before(phase.NextAttemptTime):
+			// the job of the last attempt, a retry is created after
+			r.deleteJob(ctx, clients, name)
+		case phase.Phase.IsPending():
+			klog.Infof("Job %s is already created", name)
+			phase.Phase, phase.NextAttemptTime = kubeeyev1alpha2.PhaseRunning, nil
+			phase.Attempts++
+		case phase.Phase.IsSucceeded() || phase.Phase.IsFailed():
+			r.deleteJob(ctx, clients, name)
+		}
+	}
+}
+
+// syncDeletedJob updates a running job that no longer exists, it succeeded when its result has been merged.
+func (r *InspectTaskReconciler) syncDeletedJob(task *kubeeyev1alpha2.InspectTask, phase *kubeeyev1alpha2.JobPhase) {
+	resultData, err := output.ReadResultFile(r.resultFile(resultName(phase.Cluster, task)))
+	if err == nil && isMerged(resultData, phase.JobName) {
+		klog.Infof("Job %s completed", phase.JobName)
+		phase.Phase, phase.Reason, phase.Message = kubeeyev1alpha2.PhaseSucceeded, "", ""
+		return
+	}
+	r.failJob(task, phase, kubeeyev1alpha2.ReasonJobFailed, "the job has been deleted")
+}
+
+// syncJob updates a running job from the job and its pods, and merges its result when it has succeeded.
+func (r *InspectTaskReconciler) syncJob(ctx context.Context, task *kubeeyev1alpha2.InspectTask, clients *kube.KubernetesClient, phase *kubeeyev1alpha2.JobPhase, job *v1.Job, pods []corev1.Pod) {
+	state, reason, message := jobState(job, pods)
+	switch {
+	case state.IsSucceeded():
+		klog.Infof("Job %s completed", phase.JobName)
+		phase.Phase, phase.Reason, phase.Message = kubeeyev1alpha2.PhaseSucceeded, "", ""
+		err := r.getInspectResultData(ctx, clients, resultName(phase.Cluster, task), phase.JobName)
+		if err != nil {
+			klog.Error("failed to get inspect result data", err)
+			r.failJob(task, phase, kubeeyev1alpha2.ReasonResultParseFailed, fmt.Sprintf("failed to get the result: %s", err))
+		}
+		r.deleteJob(ctx, clients, phase.JobName)
+	case state.IsFailed():
+		r.failJob(task, phase, reason, message)
+		r.deleteJob(ctx, clients, phase.JobName)
+	case isTimeout(job.CreationTimestamp, task.Spec.Timeout):
+		timeoutMessage := fmt.Sprintf("the job did not complete within %s", task.Spec.Timeout)
+		if reason == "" {
+			reason = kubeeyev1alpha2.ReasonJobTimeout
+		} else {
+			timeoutMessage = fmt.Sprintf("%s: %s", timeoutMessage, message)
+		}
+		r.failJob(task, phase, reason, timeoutMessage)
+		r.deleteJob(ctx, clients, phase.JobName)
+	default:
+		phase.Reason, phase.Message = reason, message
+	}
+}
+
+// failJob marks the job failed and records why, or pending again when the retry policy of the task retries it.
+func (r *InspectTaskReconciler) failJob(task *kubeeyev1alpha2.InspectTask, phase *kubeeyev1alpha2.JobPhase, reason string, message string) {
+	delay, ok := retryDelay(task.Spec.RetryPolicy, reason, phase.Attempts)
+	// no retry that would start after the task times out
+	if ok && !isTimeout(metav1.NewTime(task.CreationTimestamp.Add(-delay)), task.Spec.Timeout) {
+		// seconds, as saved in the status, a job created for the retry is not older
+		next := metav1.NewTime(time.Now().Add(delay).Truncate(time.Second))
+		phase.Phase, phase.Reason, phase.Message, phase.NextAttemptTime = kubeeyev1alpha2.PhasePending, reason, message, &next
+		klog.Infof("job %s failed, reason:%s, message:%s, retrying in %s", phase.JobName, reason, message, delay)
+		r.Recorder.Eventf(task, corev1.EventTypeWarning, kubeeyev1alpha2.ReasonRetrying, "job %s failed (%s), retrying in %s, attempt %d", phase.JobName, reason, delay, phase.Attempts+1)
+		return
+	}
+	phase.Phase, phase.Reason, phase.Message, phase.NextAttemptTime = kubeeyev1alpha2.PhaseFailed, reason, message, nil
+	klog.Errorf("job %s failed, reason:%s, message:%s", phase.JobName, reason, message)
+	if message == "" {
+		r.Recorder.Eventf(task, corev1.EventTypeWarning, reason, "job %s failed", phase.JobName)
+	} else {
+		r.Recorder.Eventf(task, corev1.EventTypeWarning, reason, "job %s failed: %s", phase.JobName, message)
+	}
+	metrics.JobFailures.WithLabelValues(phase.Cluster, task.Labels[constant.LabelPlanName]).Inc()
+}
+
+func (r *InspectTaskReconciler) deleteJob(ctx context.Context, clients *kube.KubernetesClient, jobName string) {
+	background := metav1.DeletePropagationBackground
+	err := clients.ClientSet.BatchV1().Jobs(os.Getenv("KUBERNETES_POD_NAMESPACE")).Delete(ctx, jobName, metav1.DeleteOptions{PropagationPolicy: &background})
+	if err != nil && !kubeErr.IsNotFound(err) {
+		klog.Infof("failed to delete job:%s , err:%s", jobName, err)
+	}
+}
+
+// deleteTaskJobs deletes the jobs of a task in all its clusters, they would be left running when the task is deleted.
+func (r *InspectTaskReconciler) deleteTaskJobs(ctx context.Context, task *kubeeyev1alpha2.InspectTask) {
+	for _, cluster := range taskClusters(task) {
+		clients, err := r.clusterClients(ctx, task, cluster.Name)
+		if err != nil {
+			klog.Error(err, "Failed to get multi-cluster client.")
+			continue
+		}
+		jobs, _, err := r.listTaskJobs(ctx, task, clients, task.Spec.ClusterName == nil)
+		if err != nil {
+			klog.Errorf("failed to list the jobs of task %s, err:%s", task.Name, err)
+			continue
+		}
+		for name := range jobs {
+			r.deleteJob(ctx, clients, name)
+		}
+	}
+}
+
+// listTaskJobs returns the jobs of the task by name and their pods, from the cache for the cluster KubeEye runs in.
+func (r *InspectTaskReconciler) listTaskJobs(ctx context.Context, task *kubeeyev1alpha2.InspectTask, clients *kube.KubernetesClient, local bool) (map[string]*v1.Job, []corev1.Pod, error) {
+	selector := map[string]string{constant.LabelTaskName: task.Name}
+	jobList := &v1.JobList{}
+	podList := &corev1.PodList{}
+	if local {
+		err := r.List(ctx, jobList, client.InNamespace(os.Getenv("KUBERNETES_POD_NAMESPACE")), client.MatchingLabels(selector))
+		if err != nil {
+			return nil, nil, err
+		}
+		err = r.List(ctx, podList, client.InNamespace(os.Getenv("KUBERNETES_POD_NAMESPACE")), client.MatchingLabels(selector))
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		var err error
+		jobList, err = clients.ClientSet.BatchV1().Jobs(os.Getenv("KUBERNETES_POD_NAMESPACE")).List(ctx, metav1.ListOptions{LabelSelector: labels.FormatLabels(selector)})
+		if err != nil {
+			return nil, nil, err
+		}
+		podList, err = clients.ClientSet.CoreV1().Pods(os.Getenv("KUBERNETES_POD_NAMESPACE")).List(ctx, metav1.ListOptions{LabelSelector: labels.FormatLabels(selector)})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	jobs := make(map[string]*v1.Job, len(jobList.Items))
+	for i := range jobList.Items {
+		jobs[jobList.Items[i].Name] = &jobList.Items[i]
+	}
+	return jobs, podList.Items, nil
+}
+
+// getJobRules returns the rules of the jobs of the task by job name, kept in the ConfigMap created by CreateInspectRule.
+func getJobRules(ctx context.Context, clients *kube.KubernetesClient, task *kubeeyev1alpha2.InspectTask) (map[string]kubeeyev1alpha2.JobRule, error) {
+	configMap, err := clients.ClientSet.CoreV1().ConfigMaps(os.Getenv("KUBERNETES_POD_NAMESPACE")).Get(ctx, task.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var jobRules []kubeeyev1alpha2.JobRule
+	err = json.Unmarshal(configMap.BinaryData[constant.Data], &jobRules)
+	if err != nil {
+		return nil, err
 	}
-	wg.Wait()
+	rules := make(map[string]kubeeyev1alpha2.JobRule, len(jobRules))
+	for _, rule := range jobRules {
+		rules[rule.JobName] = rule
+	}
+	return rules, nil
+}
 
-	return jobNames, nil
+func hasUnfinishedJobs(task *kubeeyev1alpha2.InspectTask, cluster string) bool {
+	return slices.ContainsFunc(task.Status.JobPhase, func(p kubeeyev1alpha2.JobPhase) bool {
+		return (cluster == "" || p.Cluster == cluster) && (p.Phase.IsPending() || p.Phase.IsRunning())
+	})
 }
 
 func computedDeployNum(nodeNum int, jobRulesNum int) int {
@@ -401,41 +908,18 @@ func computedDeployNum(nodeNum int, jobRulesNum int) int {
 	return concurrency
 }
 
-func (r *InspectTaskReconciler) waitForJobCompletionGetResult(ctx context.Context, clients *kube.KubernetesClient, jobName string, jobPhase *kubeeyev1alpha2.JobPhase, timeout string) *kubeeyev1alpha2.JobPhase {
-	for {
-		klog.Infof("wait job run complete for name:%s", jobName)
-		jobInfo, err := clients.ClientSet.BatchV1().Jobs(os.Getenv("KUBERNETES_POD_NAMESPACE")).Get(ctx, jobName, metav1.GetOptions{})
-		if err != nil {
-			klog.Infof("failed to get job info for name:%s,err:%s", jobName, err)
-			jobPhase.Phase = kubeeyev1alpha2.PhaseFailed
-			return jobPhase
-		}
-		if isTimeout(jobInfo.CreationTimestamp, timeout) {
-			klog.Infof("job executed timeout for name:%s", jobName)
-			jobPhase.Phase = kubeeyev1alpha2.PhaseFailed
-			return jobPhase
-		}
-		if jobInfo.Status.Conditions != nil && jobInfo.Status.Conditions[0].Type == v1.JobFailed {
-			klog.Infof("failed to job executed successful for name:%s", jobName)
-			jobPhase.Phase = kubeeyev1alpha2.PhaseFailed
-			return jobPhase
-		}
-		if jobInfo.Status.CompletionTime != nil && !jobInfo.Status.CompletionTime.IsZero() && jobInfo.Status.Active == 0 {
-			jobPhase.Phase = kubeeyev1alpha2.PhaseSucceeded
-			background := metav1.DeletePropagationBackground
-			err = clients.ClientSet.BatchV1().Jobs(os.Getenv("KUBERNETES_POD_NAMESPACE")).Delete(ctx, jobName, metav1.DeleteOptions{PropagationPolicy: &background})
-			if err != nil {
-				klog.Infof("failed to delete job:%s , err:%s", jobName, err)
-			}
-			return jobPhase
-		}
-
-		time.Sleep(10 * time.Second)
+// getInspectResultData merges the result of a job into the result file of its cluster. The merged jobs are recorded
+// in the file, a job merged before the status of the task could be saved is not merged twice.
+func (r *InspectTaskReconciler) getInspectResultData(ctx context.Context, clients *kube.KubernetesClient, resultName string, jobName string) error {
+	resultData, err := output.ReadResultFile(r.resultFile(resultName))
+	if err != nil {
+		return err
+	}
+	if isMerged(resultData, jobName) {
+		klog.Infof("the result of job %s is already merged", jobName)
+		return r.deleteResultConfigMap(ctx, clients, jobName)
 	}
 
-}
-
-func (r *InspectTaskReconciler) getInspectResultData(ctx context.Context, clients *kube.KubernetesClient, resultData *kubeeyev1alpha2.InspectResult, jobName string) error {
 	configMap, err := clients.ClientSet.CoreV1().ConfigMaps(os.Getenv("KUBERNETES_POD_NAMESPACE")).Get(ctx, jobName,
 		metav1.GetOptions{})
 	if err != nil {
@@ -453,34 +937,54 @@ func (r *InspectTaskReconciler) getInspectResultData(ctx context.Context, client
 		}
 	}
 
-	// get all nodes
-	nodes, err := clients.ClientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if resultData.Annotations == nil {
+		resultData.Annotations = map[string]string{}
+	}
+	resultData.Annotations[constant.AnnotationMergedJobs] = strings.Join(append(mergedJobs(resultData), jobName), ",")
+	err = writeResultFile(r.resultFile(resultName), resultData)
 	if err != nil {
 		return err
 	}
 
-	// Get all pods
-	pods, err := clients.ClientSet.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
-	if err != nil {
+	return r.deleteResultConfigMap(ctx, clients, jobName)
+}
+
+func (r *InspectTaskReconciler) deleteResultConfigMap(ctx context.Context, clients *kube.KubernetesClient, jobName string) error {
+	err := clients.ClientSet.CoreV1().ConfigMaps(os.Getenv("KUBERNETES_POD_NAMESPACE")).Delete(ctx, jobName, metav1.DeleteOptions{})
+	if err != nil && !kubeErr.IsNotFound(err) {
 		return err
 	}
+	return nil
+}
 
-	err = saveResultFile(resultData, nodes, pods)
-	if err != nil {
-		return err
+func mergedJobs(resultData *kubeeyev1alpha2.InspectResult) []string {
+	merged := resultData.Annotations[constant.AnnotationMergedJobs]
+	if merged == "" {
+		return nil
 	}
+	return strings.Split(merged, ",")
+}
+
+func isMerged(resultData *kubeeyev1alpha2.InspectResult, jobName string) bool {
+	return slices.Contains(mergedJobs(resultData), jobName)
+}
 
-	err = clients.ClientSet.CoreV1().ConfigMaps(os.Getenv("KUBERNETES_POD_NAMESPACE")).Delete(ctx, jobName, metav1.DeleteOptions{})
+func saveResultFile(file string, resultData *kubeeyev1alpha2.InspectResult, nodes *corev1.NodeList, pods *corev1.PodList) error {
+	// json
+	err := writeResultFile(file, resultData)
 	if err != nil {
 		return err
 	}
-
+	// execl
+	err = output.GenerateExcel(fmt.Sprintf("%s.xlsx", file), resultData, nodes, pods)
+	if err != nil {
+		klog.Error(err, "generate excel error")
+	}
 	return nil
 }
 
-func saveResultFile(resultData *kubeeyev1alpha2.InspectResult, nodes *corev1.NodeList, pods *corev1.PodList) error {
-	// json
-	file, err := os.OpenFile(path.Join(constant.ResultPathPrefix, resultData.Name), os.O_CREATE|os.O_WRONLY, 0775)
+func writeResultFile(name string, resultData *kubeeyev1alpha2.InspectResult) error {
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0775)
 	if err != nil {
 		klog.Error(err, "open file error")
 		return err
@@ -495,11 +999,6 @@ func saveResultFile(resultData *kubeeyev1alpha2.InspectResult, nodes *corev1.Nod
 		klog.Error(err, "write file error")
 		return err
 	}
-	// execl
-	err = output.GenerateExcel(resultData, nodes, pods)
-	if err != nil {
-		klog.Error(err, "generate excel error")
-	}
 	return nil
 }
 
@@ -550,15 +1049,24 @@ func (r *InspectTaskReconciler) initClusterInspectConfig(ctx context.Context, cl
 	return nil
 }
 
-func (r *InspectTaskReconciler) cleanClusterInspectConfig(ctx context.Context, clients *kube.KubernetesClient, task *kubeeyev1alpha2.InspectTask) error {
-	// clean temp inspect rule
-	err := clients.ClientSet.CoreV1().ConfigMaps(os.Getenv("KUBERNETES_POD_NAMESPACE")).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{
-		LabelSelector: labels.FormatLabels(map[string]string{constant.LabelInspectRuleGroup: "inspect-rule-temp"}),
-	})
+// cleanClusterInspectConfig deletes the rules of the task in a cluster. The service account and the RBAC of the
+// inspect jobs are shared by the tasks, they are only deleted when no other task is inspecting the cluster.
+func (r *InspectTaskReconciler) cleanClusterInspectConfig(ctx context.Context, clients *kube.KubernetesClient, task *kubeeyev1alpha2.InspectTask, cluster string) error {
+	// clean temp inspect rule, the rules of other running tasks are kept
+	err := clients.ClientSet.CoreV1().ConfigMaps(os.Getenv("KUBERNETES_POD_NAMESPACE")).Delete(ctx, task.Name, metav1.DeleteOptions{})
 	if err != nil && !kubeErr.IsNotFound(err) {
 		return err
 	}
 
+	inUse, err := r.clusterInspecting(ctx, task, cluster)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		klog.Infof("other tasks are inspecting cluster %s, keep its inspect config", cluster)
+		return nil
+	}
+
 	err = clients.ClientSet.CoreV1().ServiceAccounts(os.Getenv("KUBERNETES_POD_NAMESPACE")).Delete(ctx, template.GetServiceAccountTemplate().Name, metav1.DeleteOptions{})
 	if err != nil && !kubeErr.IsNotFound(err) {
 		return err
@@ -574,18 +1082,45 @@ func (r *InspectTaskReconciler) cleanClusterInspectConfig(ctx context.Context, c
 	return nil
 }
 
-func (r *InspectTaskReconciler) updatePlanStatus(ctx context.Context, phase kubeeyev1alpha2.Phase, planName string, taskName string) error {
+// clusterInspecting reports whether a task other than the given one is inspecting the cluster, that is it is running
+// and has jobs to run in the cluster, or has not created its jobs yet.
+func (r *InspectTaskReconciler) clusterInspecting(ctx context.Context, task *kubeeyev1alpha2.InspectTask, cluster string) (bool, error) {
+	tasks := &kubeeyev1alpha2.InspectTaskList{}
+	err := r.List(ctx, tasks)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range tasks.Items {
+		if t.Name == task.Name || !t.Status.Status.IsRunning() {
+			continue
+		}
+		if !slices.ContainsFunc(taskClusters(&t), func(c kubeeyev1alpha2.Cluster) bool { return c.Name == cluster }) {
+			continue
+		}
+		if len(t.Status.JobPhase) == 0 || hasUnfinishedJobs(&t, cluster) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
 
-	plan, err := r.KubeEyeFactory.V1alpha2().InspectPlans().Lister().Get(planName)
+// updatePlanStatus records the phase of the task in its plan, tasks created without a plan are skipped.
+func (r *InspectTaskReconciler) updatePlanStatus(ctx context.Context, phase kubeeyev1alpha2.Phase, planName string, taskName string) error {
+	if planName == "" {
+		return nil
+	}
+	cached, err := r.KubeEyeFactory.V1alpha2().InspectPlans().Lister().Get(planName)
 	if err != nil {
 		klog.Error(err, "get plan error")
 		return err
 	}
-	for i, name := range plan.Status.TaskNames {
-		if name.Name == taskName {
-			plan.Status.TaskNames[i].TaskStatus = phase
-			break
-		}
+	plan := cached.DeepCopy()
+	i := slices.IndexFunc(plan.Status.TaskNames, func(name kubeeyev1alpha2.TaskNames) bool { return name.Name == taskName })
+	if i >= 0 {
+		plan.Status.TaskNames[i].TaskStatus = phase
+	} else {
+		// a task rerun by hand is not created by the plan
+		plan.Status.TaskNames = append(plan.Status.TaskNames, kubeeyev1alpha2.TaskNames{Name: taskName, TaskStatus: phase})
 	}
 	timeNow := metav1.Now()
 	if phase.IsRunning() {
@@ -594,6 +1129,7 @@ func (r *InspectTaskReconciler) updatePlanStatus(ctx context.Context, phase kube
 		plan.Status.LastTaskEndTime = &timeNow
 	}
 	plan.Status.LastTaskStatus = phase
+	setPlanTaskConditions(plan, phase, taskName)
 	err = r.Status().Update(ctx, plan)
 	if err != nil {
 		klog.Error(err, "update plan status error")
@@ -617,7 +1153,7 @@ func (r *InspectTaskReconciler) getRules(task *kubeeyev1alpha2.InspectTask) (rul
 
 func createInspectJob(ctx context.Context, clients *kube.KubernetesClient, jobRule *kubeeyev1alpha2.JobRule, task *kubeeyev1alpha2.InspectTask, config *conf.JobConfig, ruleType string) (*kubeeyev1alpha2.JobPhase, error) {
 
-	nodeName, err := GetDeploySchedule(jobRule.RunRule)
+	nodeName, err := rules.GetDeploySchedule(jobRule.RunRule)
 	if err != nil && ruleType != constant.ServiceConnect && ruleType != constant.Component {
 		return nil, fmt.Errorf("%s:%s", ruleType, err.Error())
 	}
@@ -638,22 +1174,8 @@ func createInspectJob(ctx context.Context, clients *kube.KubernetesClient, jobRu
 	return &kubeeyev1alpha2.JobPhase{JobName: jobRule.JobName, Phase: kubeeyev1alpha2.PhaseRunning}, nil
 }
 
-func GetDeploySchedule(r []byte) (string, error) {
-	var data []map[string]interface{}
-	err := json.Unmarshal(r, &data)
-	if len(data) == 0 || err != nil {
-		return "", fmt.Errorf("rule is empty")
-	}
-	v := data[0]["nodeName"]
-
-	if !utils.IsEmptyValue(v) {
-		return v.(string), nil
-	}
-
-	return "", nil
-}
-
-func checkJobIsDeploy(allNode []corev1.Node, inComplete []corev1.Pod, job kubeeyev1alpha2.JobRule) bool {
+// checkJobIsDeploy returns why the job can't be deployed now, nil when it can.
+func checkJobIsDeploy(allNode []corev1.Node, inComplete []corev1.Pod, job kubeeyev1alpha2.JobRule) error {
 	nodeStatus := make(map[string]bool, len(allNode))
 	for _, n := range allNode {
 		if kube.IsNodesReady(n) {
@@ -663,18 +1185,18 @@ func checkJobIsDeploy(allNode []corev1.Node, inComplete []corev1.Pod, job kubeey
 
 	if len(nodeStatus) == 0 {
 		klog.Error("There are currently no nodes to deploy.", job.JobName)
-		return false
+		return fmt.Errorf("there are currently no ready nodes to deploy")
 	}
 
-	nodeName, err := GetDeploySchedule(job.RunRule)
+	nodeName, err := rules.GetDeploySchedule(job.RunRule)
 	if err != nil || utils.IsEmptyValue(nodeName) {
-		return true
+		return nil
 	}
 
 	_, isDeploy := nodeStatus[nodeName]
 	if !isDeploy {
 		klog.Errorf("Deployable node not found: %s, jobName: %s", nodeName, job.JobName)
-		return false
+		return fmt.Errorf("node %s is not found or not ready", nodeName)
 	}
 
 	_, exist, _ := utils.ArrayFinds(inComplete, func(m corev1.Pod) bool {
@@ -682,9 +1204,9 @@ func checkJobIsDeploy(allNode []corev1.Node, inComplete []corev1.Pod, job kubeey
 	})
 	if exist {
 		klog.Errorf("Node: %s has unfinished tasks, jobName: %s", nodeName, job.JobName)
-		return false
+		return fmt.Errorf("node %s has unfinished tasks", nodeName)
 	}
-	return true
+	return nil
 }
 
 func getIncompleteJob(ctx context.Context, kubeClient *kube.KubernetesClient, task *kubeeyev1alpha2.InspectTask, ruleType string) []corev1.Pod {
@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	"github.com/kubesphere/kubeeye/pkg/kube"
+	"github.com/kubesphere/kubeeye/pkg/output"
+	"github.com/kubesphere/kubeeye/pkg/template"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const jobsNamespace = "kubeeye-system"
+
+func newJobsReconciler(t *testing.T, objects ...runtime.Object) (*InspectTaskReconciler, *kube.KubernetesClient) {
+	t.Setenv("KUBERNETES_POD_NAMESPACE", jobsNamespace)
+	r := &InspectTaskReconciler{Recorder: record.NewFakeRecorder(100), resultDir: t.TempDir()}
+	return r, &kube.KubernetesClient{ClientSet: kubefake.NewSimpleClientset(objects...)}
+}
+
+func newJobsTask(phases ...kubeeyev1alpha2.JobPhase) *kubeeyev1alpha2.InspectTask {
+	return &kubeeyev1alpha2.InspectTask{
+		ObjectMeta: metav1.ObjectMeta{Name: "task", CreationTimestamp: metav1.Now()},
+		Spec:       kubeeyev1alpha2.InspectTaskSpec{Timeout: "10m"},
+		Status:     kubeeyev1alpha2.InspectTaskStatus{JobPhase: phases},
+	}
+}
+
+func newTaskJob(name string, created time.Time) *batchv1.Job {
+	return &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: jobsNamespace, CreationTimestamp: metav1.NewTime(created)}}
+}
+
+func writeTestResult(t *testing.T, r *InspectTaskReconciler, result *kubeeyev1alpha2.InspectResult) {
+	if err := writeResultFile(r.resultFile(result.Name), result); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAdoptJobs(t *testing.T) {
+	now := time.Now()
+	retryAt := metav1.NewTime(now.Add(time.Minute))
+	task := newJobsTask(
+		kubeeyev1alpha2.JobPhase{JobName: "pending", Cluster: "default", Phase: kubeeyev1alpha2.PhasePending},
+		kubeeyev1alpha2.JobPhase{JobName: "retrying", Cluster: "default", Phase: kubeeyev1alpha2.PhasePending, Attempts: 1, NextAttemptTime: &retryAt},
+		kubeeyev1alpha2.JobPhase{JobName: "running", Cluster: "default", Phase: kubeeyev1alpha2.PhaseRunning, Attempts: 1},
+		kubeeyev1alpha2.JobPhase{JobName: "succeeded", Cluster: "default", Phase: kubeeyev1alpha2.PhaseSucceeded, Attempts: 1},
+		kubeeyev1alpha2.JobPhase{JobName: "member", Cluster: "member", Phase: kubeeyev1alpha2.PhasePending},
+	)
+	deleting := newTaskJob("deleting", now)
+	deleting.DeletionTimestamp = &metav1.Time{Time: now}
+	jobs := map[string]*batchv1.Job{
+		"pending":   newTaskJob("pending", now),
+		"retrying":  newTaskJob("retrying", now.Add(-time.Minute)),
+		"running":   newTaskJob("running", now),
+		"succeeded": newTaskJob("succeeded", now),
+		"unknown":   newTaskJob("unknown", now),
+		"deleting":  deleting,
+	}
+	var objects []runtime.Object
+	for _, job := range jobs {
+		objects = append(objects, job)
+	}
+	r, clients := newJobsReconciler(t, objects...)
+
+	r.adoptJobs(context.TODO(), task, "default", clients, jobs)
+
+	want := map[string]struct {
+		phase    kubeeyev1alpha2.Phase
+		attempts int
+	}{
+		"pending":   {kubeeyev1alpha2.PhaseRunning, 1},
+		"retrying":  {kubeeyev1alpha2.PhasePending, 1},
+		"running":   {kubeeyev1alpha2.PhaseRunning, 1},
+		"succeeded": {kubeeyev1alpha2.PhaseSucceeded, 1},
+		"member":    {kubeeyev1alpha2.PhasePending, 0},
+	}
+	for _, phase := range task.Status.JobPhase {
+		if w := want[phase.JobName]; phase.Phase != w.phase || phase.Attempts != w.attempts {
+			t.Errorf("job %s = %s after %d attempts, want %s after %d", phase.JobName, phase.Phase, phase.Attempts, w.phase, w.attempts)
+		}
+	}
+	if task.Status.JobPhase[0].NextAttemptTime != nil {
+		t.Error("an adopted job should not wait for a retry")
+	}
+
+	list, err := clients.ClientSet.BatchV1().Jobs(jobsNamespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	remaining := map[string]bool{}
+	for _, job := range list.Items {
+		remaining[job.Name] = true
+	}
+	for name, kept := range map[string]bool{"pending": true, "retrying": false, "running": true, "succeeded": false, "unknown": false, "deleting": true} {
+		if remaining[name] != kept {
+			t.Errorf("job %s kept = %v, want %v", name, remaining[name], kept)
+		}
+	}
+}
+
+func TestSyncDeletedJob(t *testing.T) {
+	tests := []struct {
+		name       string
+		merged     string
+		noResult   bool
+		wantPhase  kubeeyev1alpha2.Phase
+		wantReason string
+	}{
+		{name: "merged", merged: "other,deleted", wantPhase: kubeeyev1alpha2.PhaseSucceeded},
+		{name: "not merged", merged: "other", wantPhase: kubeeyev1alpha2.PhaseFailed, wantReason: kubeeyev1alpha2.ReasonJobFailed},
+		{name: "no result file", noResult: true, wantPhase: kubeeyev1alpha2.PhaseFailed, wantReason: kubeeyev1alpha2.ReasonJobFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := newJobsTask(kubeeyev1alpha2.JobPhase{JobName: "deleted", Cluster: "default", Phase: kubeeyev1alpha2.PhaseRunning, Attempts: 1})
+			r, _ := newJobsReconciler(t)
+			if !tt.noResult {
+				writeTestResult(t, r, &kubeeyev1alpha2.InspectResult{ObjectMeta: metav1.ObjectMeta{
+					Name:        resultName("default", task),
+					Annotations: map[string]string{constant.AnnotationMergedJobs: tt.merged},
+				}})
+			}
+
+			phase := &task.Status.JobPhase[0]
+			r.syncDeletedJob(task, phase)
+			if phase.Phase != tt.wantPhase || phase.Reason != tt.wantReason {
+				t.Errorf("syncDeletedJob() = %s %q, want %s %q", phase.Phase, phase.Reason, tt.wantPhase, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestGetInspectResultDataMergesOnce(t *testing.T) {
+	data, err := json.Marshal([]kubeeyev1alpha2.NodeMetricsResultItem{{BaseResult: kubeeyev1alpha2.BaseResult{Name: "net.ipv4.ip_forward", Assert: true}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resultConfigMap := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sysctl-job",
+				Namespace: jobsNamespace,
+				Labels:    map[string]string{constant.LabelRuleType: constant.Sysctl, constant.LabelNodeName: "node1"},
+			},
+			BinaryData: map[string][]byte{constant.Data: data},
+		}
+	}
+	r, clients := newJobsReconciler(t, resultConfigMap())
+	writeTestResult(t, r, &kubeeyev1alpha2.InspectResult{ObjectMeta: metav1.ObjectMeta{Name: "default-task-result"}})
+
+	if err = r.getInspectResultData(context.TODO(), clients, "default-task-result", "sysctl-job"); err != nil {
+		t.Fatal(err)
+	}
+	// the status of the task was not saved and the result ConfigMap is still there, e.g. it could not be deleted
+	if _, err = clients.ClientSet.CoreV1().ConfigMaps(jobsNamespace).Create(context.TODO(), resultConfigMap(), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.getInspectResultData(context.TODO(), clients, "default-task-result", "sysctl-job"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := output.ReadResultFile(path.Join(r.resultDir, "default-task-result"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Spec.SysctlResult) != 1 || result.Spec.SysctlResult[0].NodeName != "node1" {
+		t.Errorf("sysctl results = %+v, want the result of the job merged once", result.Spec.SysctlResult)
+	}
+	if merged := mergedJobs(result); len(merged) != 1 || merged[0] != "sysctl-job" {
+		t.Errorf("merged jobs = %v, want [sysctl-job]", merged)
+	}
+	if _, err = clients.ClientSet.CoreV1().ConfigMaps(jobsNamespace).Get(context.TODO(), "sysctl-job", metav1.GetOptions{}); err == nil {
+		t.Error("the result ConfigMap of a merged job should be deleted")
+	}
+}
+
+func TestGetInspectResultDataMissingResult(t *testing.T) {
+	r, clients := newJobsReconciler(t)
+	if err := r.getInspectResultData(context.TODO(), clients, "default-task-result", "sysctl-job"); !os.IsNotExist(err) {
+		t.Errorf("getInspectResultData() error = %v, want a missing result file", err)
+	}
+}
+
+func TestCleanClusterInspectConfig(t *testing.T) {
+	running := func(name string, clusters []kubeeyev1alpha2.Cluster, phases ...kubeeyev1alpha2.JobPhase) *kubeeyev1alpha2.InspectTask {
+		return &kubeeyev1alpha2.InspectTask{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       kubeeyev1alpha2.InspectTaskSpec{ClusterName: clusters},
+			Status:     kubeeyev1alpha2.InspectTaskStatus{Status: kubeeyev1alpha2.PhaseRunning, JobPhase: phases},
+		}
+	}
+	tests := []struct {
+		name  string
+		other *kubeeyev1alpha2.InspectTask
+		kept  bool
+	}{{
+		name: "no other task",
+	}, {
+		name:  "other task with running jobs",
+		other: running("other", nil, kubeeyev1alpha2.JobPhase{JobName: "other-job", Cluster: "default", Phase: kubeeyev1alpha2.PhaseRunning}),
+		kept:  true,
+	}, {
+		name:  "other task creating its jobs",
+		other: running("other", nil),
+		kept:  true,
+	}, {
+		name:  "other task with finished jobs",
+		other: running("other", nil, kubeeyev1alpha2.JobPhase{JobName: "other-job", Cluster: "default", Phase: kubeeyev1alpha2.PhaseSucceeded}),
+	}, {
+		name:  "other task in another cluster",
+		other: running("other", []kubeeyev1alpha2.Cluster{{Name: "member"}}, kubeeyev1alpha2.JobPhase{JobName: "other-job", Cluster: "member", Phase: kubeeyev1alpha2.PhaseRunning}),
+	}, {
+		name: "other task finished",
+		other: &kubeeyev1alpha2.InspectTask{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			Status:     kubeeyev1alpha2.InspectTaskStatus{Status: kubeeyev1alpha2.PhaseSucceeded},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := newJobsTask(kubeeyev1alpha2.JobPhase{JobName: "job", Cluster: "default", Phase: kubeeyev1alpha2.PhaseSucceeded})
+			task.Status.Status = kubeeyev1alpha2.PhaseRunning
+			objects := []client.Object{task}
+			if tt.other != nil {
+				objects = append(objects, tt.other)
+			}
+			r, clients := newJobsReconciler(t, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: task.Name, Namespace: jobsNamespace}})
+			scheme := runtime.NewScheme()
+			if err := kubeeyev1alpha2.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+			ctx := context.TODO()
+			if err := r.initClusterInspectConfig(ctx, clients); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := r.cleanClusterInspectConfig(ctx, clients, task, "default"); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := clients.ClientSet.CoreV1().ConfigMaps(jobsNamespace).Get(ctx, task.Name, metav1.GetOptions{}); err == nil {
+				t.Error("the rules of the task should be deleted")
+			}
+			_, saErr := clients.ClientSet.CoreV1().ServiceAccounts(jobsNamespace).Get(ctx, template.GetServiceAccountTemplate().Name, metav1.GetOptions{})
+			_, bindingErr := clients.ClientSet.RbacV1().ClusterRoleBindings().Get(ctx, template.GetClusterRoleBindingTemplate().Name, metav1.GetOptions{})
+			_, roleErr := clients.ClientSet.RbacV1().ClusterRoles().Get(ctx, template.GetClusterRoleTemplate().Name, metav1.GetOptions{})
+			for _, err := range []error{saErr, bindingErr, roleErr} {
+				if kept := err == nil; kept != tt.kept {
+					t.Errorf("inspect config kept = %v, want %v (%v)", kept, tt.kept, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveAndCleanResultFiles(t *testing.T) {
+	r, clients := newJobsReconciler(t)
+	result := &kubeeyev1alpha2.InspectResult{ObjectMeta: metav1.ObjectMeta{Name: "default-task-result"}}
+	if err := saveResultFile(r.resultFile(result.Name), result, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{result.Name, result.Name + ".xlsx"} {
+		if _, err := os.Stat(path.Join(r.resultDir, file)); err != nil {
+			t.Errorf("%s should be saved in the result directory: %s", file, err)
+		}
+	}
+
+	r.cleanResult(clients, newJobsTask(), result.Name)
+	if entries, _ := os.ReadDir(r.resultDir); len(entries) != 0 {
+		t.Errorf("files left in the result directory: %v", entries)
+	}
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	versionedfake "github.com/kubesphere/kubeeye/clients/clientset/versioned/fake"
+	"github.com/kubesphere/kubeeye/clients/informers/externalversions"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newOperationsReconciler returns a reconciler of the cluster KubeEye runs in, objects are served by its client.
+func newOperationsReconciler(t *testing.T, objects ...client.Object) *InspectTaskReconciler {
+	r, clients := newJobsReconciler(t)
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := kubeeyev1alpha2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	r.Client = fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&kubeeyev1alpha2.InspectPlan{}, &kubeeyev1alpha2.InspectTask{}).
+		Build()
+	r.K8sClients = clients
+	return r
+}
+
+func TestSelectJob(t *testing.T) {
+	tests := []struct {
+		name      string
+		selectors []kubeeyev1alpha2.JobSelector
+		want      bool
+	}{
+		{name: "no selectors", want: true},
+		{name: "rule type", selectors: []kubeeyev1alpha2.JobSelector{{RuleType: constant.Sysctl}}, want: true},
+		{name: "other rule type", selectors: []kubeeyev1alpha2.JobSelector{{RuleType: constant.Systemd}}},
+		{name: "cluster and node", selectors: []kubeeyev1alpha2.JobSelector{{Cluster: "member", RuleType: constant.Sysctl, NodeName: "node1"}}, want: true},
+		{name: "other cluster", selectors: []kubeeyev1alpha2.JobSelector{{Cluster: "default", RuleType: constant.Sysctl}}},
+		{name: "other node", selectors: []kubeeyev1alpha2.JobSelector{{RuleType: constant.Sysctl, NodeName: "node2"}}},
+		{name: "any selector", selectors: []kubeeyev1alpha2.JobSelector{{NodeName: "node2"}, {Cluster: "member"}}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectJob(tt.selectors, "member", constant.Sysctl, "node1"); got != tt.want {
+				t.Errorf("selectJob() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCancelTask(t *testing.T) {
+	task := newJobsTask(
+		kubeeyev1alpha2.JobPhase{JobName: "succeeded", Cluster: "default", Phase: kubeeyev1alpha2.PhaseSucceeded},
+		kubeeyev1alpha2.JobPhase{JobName: "running", Cluster: "default", Phase: kubeeyev1alpha2.PhaseRunning},
+		kubeeyev1alpha2.JobPhase{JobName: "pending", Cluster: "default", Phase: kubeeyev1alpha2.PhasePending},
+	)
+	task.Spec.Cancel = true
+	task.Status.Status = kubeeyev1alpha2.PhaseRunning
+	task.Status.StartTimestamp = &metav1.Time{Time: task.CreationTimestamp.Time}
+	running := newTaskJob("running", task.CreationTimestamp.Time)
+	running.Labels = map[string]string{constant.LabelTaskName: task.Name}
+	r := newOperationsReconciler(t, task, running.DeepCopy())
+	ctx := context.TODO()
+	if _, err := r.K8sClients.ClientSet.BatchV1().Jobs(jobsNamespace).Create(ctx, running, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	writeTestResult(t, r, &kubeeyev1alpha2.InspectResult{ObjectMeta: metav1.ObjectMeta{Name: resultName("default", task)}})
+
+	if err := r.cancelTask(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+
+	if jobs, _ := r.K8sClients.ClientSet.BatchV1().Jobs(jobsNamespace).List(ctx, metav1.ListOptions{}); len(jobs.Items) != 0 {
+		t.Errorf("the outstanding jobs should be deleted: %v", jobs.Items)
+	}
+	saved := &kubeeyev1alpha2.InspectTask{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(task), saved); err != nil {
+		t.Fatal(err)
+	}
+	if !saved.Status.Status.IsCancelled() {
+		t.Errorf("task status = %s, want Cancelled", saved.Status.Status)
+	}
+	want := map[string]kubeeyev1alpha2.Phase{
+		"succeeded": kubeeyev1alpha2.PhaseSucceeded,
+		"running":   kubeeyev1alpha2.PhaseCancelled,
+		"pending":   kubeeyev1alpha2.PhaseCancelled,
+	}
+	for _, phase := range saved.Status.JobPhase {
+		if phase.Phase != want[phase.JobName] {
+			t.Errorf("job %s phase = %s, want %s", phase.JobName, phase.Phase, want[phase.JobName])
+		}
+	}
+	result := &kubeeyev1alpha2.InspectResult{}
+	if err := r.Get(ctx, client.ObjectKey{Name: resultName("default", task)}, result); err != nil {
+		t.Errorf("the results of the finished jobs should be kept: %s", err)
+	}
+}
+
+func TestUpdatePlanStatusWithoutPlan(t *testing.T) {
+	// a task rerun without a plan has no plan to update, the factory is not used
+	r := newOperationsReconciler(t)
+	if err := r.updatePlanStatus(context.TODO(), kubeeyev1alpha2.PhaseRunning, "", "task-rerun"); err != nil {
+		t.Errorf("updatePlanStatus() error = %v, want the plan skipped", err)
+	}
+}
+
+func TestUpdatePlanStatusOfRerunTask(t *testing.T) {
+	plan := &kubeeyev1alpha2.InspectPlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "plan"},
+		Status:     kubeeyev1alpha2.InspectPlanStatus{TaskNames: []kubeeyev1alpha2.TaskNames{{Name: "plan-1", TaskStatus: kubeeyev1alpha2.PhaseFailed}}},
+	}
+	r := newOperationsReconciler(t, plan)
+	ctx := context.TODO()
+	// the cache holds the plan as saved, with its resource version
+	if err := r.Get(ctx, client.ObjectKeyFromObject(plan), plan); err != nil {
+		t.Fatal(err)
+	}
+	factory := externalversions.NewSharedInformerFactory(versionedfake.NewSimpleClientset(), 0)
+	if err := factory.Kubeeye().V1alpha2().InspectPlans().Informer().GetIndexer().Add(plan); err != nil {
+		t.Fatal(err)
+	}
+	r.KubeEyeFactory = factory.Kubeeye()
+
+	if err := r.updatePlanStatus(ctx, kubeeyev1alpha2.PhaseRunning, "plan", "plan-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := r.KubeEyeFactory.V1alpha2().InspectPlans().Lister().Get("plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cached.Status.TaskNames) != 1 || cached.Status.LastTaskStartTime != nil {
+		t.Errorf("the cached plan was changed: %+v", cached.Status)
+	}
+	saved := &kubeeyev1alpha2.InspectPlan{}
+	if err = r.Get(ctx, client.ObjectKey{Name: "plan"}, saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Status.TaskNames) != 2 || saved.Status.TaskNames[1].Name != "plan-2" || !saved.Status.LastTaskStatus.IsRunning() {
+		t.Errorf("the rerun task should be added to the plan: %+v", saved.Status)
+	}
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// jobState returns the phase of an inspect job from the job and its pods, with the reason and message of a
+// failure, or of what keeps a running job from progressing.
+func jobState(job *batchv1.Job, pods []corev1.Pod) (kubeeyev1alpha2.Phase, string, string) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return kubeeyev1alpha2.PhaseSucceeded, "", ""
+		case batchv1.JobFailed:
+			if reason, message := podFailure(job, pods); reason != "" {
+				return kubeeyev1alpha2.PhaseFailed, reason, message
+			}
+			reason := c.Reason
+			if reason == "" {
+				reason = kubeeyev1alpha2.ReasonJobFailed
+			}
+			return kubeeyev1alpha2.PhaseFailed, reason, c.Message
+		}
+	}
+	if job.Status.CompletionTime != nil && !job.Status.CompletionTime.IsZero() && job.Status.Active == 0 {
+		return kubeeyev1alpha2.PhaseSucceeded, "", ""
+	}
+
+	for _, pod := range jobPods(job, pods) {
+		for _, status := range pod.Status.ContainerStatuses {
+			// the image has been pulled again and failed, retrying the pod won't help
+			if waiting := status.State.Waiting; waiting != nil && (waiting.Reason == "ImagePullBackOff" || waiting.Reason == "InvalidImageName") {
+				return kubeeyev1alpha2.PhaseFailed, kubeeyev1alpha2.ReasonImagePullBackOff, waiting.Message
+			}
+		}
+	}
+	reason, message := podFailure(job, pods)
+	return kubeeyev1alpha2.PhaseRunning, reason, message
+}
+
+// podFailure returns why a pod of the job failed or is not running: unschedulable, a container killed for
+// running out of memory or waiting, or the pod failed, e.g. evicted.
+func podFailure(job *batchv1.Job, pods []corev1.Pod) (string, string) {
+	var reason, message string
+	for _, pod := range jobPods(job, pods) {
+		for _, status := range pod.Status.ContainerStatuses {
+			if terminated := status.State.Terminated; terminated != nil && terminated.Reason == "OOMKilled" {
+				return kubeeyev1alpha2.ReasonOOMKilled, "container " + status.Name + " was killed for exceeding its memory limit"
+			}
+			if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason == "OOMKilled" {
+				return kubeeyev1alpha2.ReasonOOMKilled, "container " + status.Name + " was killed for exceeding its memory limit"
+			}
+			if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" && waiting.Reason != "ContainerCreating" {
+				reason, message = waiting.Reason, waiting.Message
+			}
+		}
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Reason == corev1.PodReasonUnschedulable {
+				reason, message = kubeeyev1alpha2.ReasonUnschedulable, c.Message
+			}
+		}
+		if pod.Status.Phase == corev1.PodFailed && pod.Status.Reason != "" {
+			reason, message = pod.Status.Reason, pod.Status.Message
+		}
+	}
+	return reason, message
+}
+
+// jobPods returns the pods created by the job.
+func jobPods(job *batchv1.Job, pods []corev1.Pod) (owned []corev1.Pod) {
+	for _, pod := range pods {
+		if owner := metav1.GetControllerOf(&pod); owner != nil && owner.UID == job.UID {
+			owned = append(owned, pod)
+		}
+	}
+	return owned
+}
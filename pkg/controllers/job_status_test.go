@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"testing"
+	"time"
+)
+
+func TestJobState(t *testing.T) {
+	job := func(conditions ...batchv1.JobCondition) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "inspect-job", Namespace: "kubeeye-system", UID: "job-uid"},
+			Status:     batchv1.JobStatus{Conditions: conditions},
+		}
+	}
+	pod := func(status corev1.PodStatus) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "inspect-job-abcde",
+				Namespace:       "kubeeye-system",
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: "inspect-job", UID: "job-uid", Controller: ptr.To(true)}},
+			},
+			Status: status,
+		}
+	}
+	container := func(state, last corev1.ContainerState) corev1.PodStatus {
+		return corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "inspector", State: state, LastTerminationState: last}}}
+	}
+	failed := batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"}
+	oomKilled := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}}
+
+	tests := []struct {
+		name        string
+		job         *batchv1.Job
+		pods        []corev1.Pod
+		wantPhase   kubeeyev1alpha2.Phase
+		wantReason  string
+		wantMessage string
+	}{{
+		name:      "complete",
+		job:       job(batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}),
+		wantPhase: kubeeyev1alpha2.PhaseSucceeded,
+	}, {
+		name: "completion time without condition",
+		job: func() *batchv1.Job {
+			j := job()
+			j.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+			return j
+		}(),
+		wantPhase: kubeeyev1alpha2.PhaseSucceeded,
+	}, {
+		name:      "condition not true",
+		job:       job(batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionFalse}),
+		wantPhase: kubeeyev1alpha2.PhaseRunning,
+	}, {
+		name:        "failed without a pod reason",
+		job:         job(failed),
+		wantPhase:   kubeeyev1alpha2.PhaseFailed,
+		wantReason:  "BackoffLimitExceeded",
+		wantMessage: "Job has reached the specified backoff limit",
+	}, {
+		name:       "failed without any reason",
+		job:        job(batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}),
+		wantPhase:  kubeeyev1alpha2.PhaseFailed,
+		wantReason: kubeeyev1alpha2.ReasonJobFailed,
+	}, {
+		name:        "failed with a pod reason",
+		job:         job(failed),
+		pods:        []corev1.Pod{pod(container(corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error"}}, oomKilled))},
+		wantPhase:   kubeeyev1alpha2.PhaseFailed,
+		wantReason:  kubeeyev1alpha2.ReasonOOMKilled,
+		wantMessage: "container inspector was killed for exceeding its memory limit",
+	}, {
+		name:        "image pull back-off",
+		job:         job(),
+		pods:        []corev1.Pod{pod(container(corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}}, corev1.ContainerState{}))},
+		wantPhase:   kubeeyev1alpha2.PhaseFailed,
+		wantReason:  kubeeyev1alpha2.ReasonImagePullBackOff,
+		wantMessage: "Back-off pulling image",
+	}, {
+		name:        "oom killed in the last termination state",
+		job:         job(),
+		pods:        []corev1.Pod{pod(container(corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}, oomKilled))},
+		wantPhase:   kubeeyev1alpha2.PhaseRunning,
+		wantReason:  kubeeyev1alpha2.ReasonOOMKilled,
+		wantMessage: "container inspector was killed for exceeding its memory limit",
+	}, {
+		name:        "crash loop back-off",
+		job:         job(),
+		pods:        []corev1.Pod{pod(container(corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off restarting"}}, corev1.ContainerState{}))},
+		wantPhase:   kubeeyev1alpha2.PhaseRunning,
+		wantReason:  "CrashLoopBackOff",
+		wantMessage: "back-off restarting",
+	}, {
+		name:      "container creating",
+		job:       job(),
+		pods:      []corev1.Pod{pod(container(corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}, corev1.ContainerState{}))},
+		wantPhase: kubeeyev1alpha2.PhaseRunning,
+	}, {
+		name: "unschedulable",
+		job:  job(),
+		pods: []corev1.Pod{pod(corev1.PodStatus{
+			Phase:      corev1.PodPending,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable, Message: "0/3 nodes are available"}},
+		})},
+		wantPhase:   kubeeyev1alpha2.PhaseRunning,
+		wantReason:  kubeeyev1alpha2.ReasonUnschedulable,
+		wantMessage: "0/3 nodes are available",
+	}, {
+		name:        "evicted",
+		job:         job(failed),
+		pods:        []corev1.Pod{pod(corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted", Message: "The node was low on resource: memory."})},
+		wantPhase:   kubeeyev1alpha2.PhaseFailed,
+		wantReason:  "Evicted",
+		wantMessage: "The node was low on resource: memory.",
+	}, {
+		name: "pods of other jobs are ignored",
+		job:  job(),
+		pods: func() []corev1.Pod {
+			p := pod(container(corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}, corev1.ContainerState{}))
+			p.OwnerReferences[0].UID = types.UID("other-job-uid")
+			return []corev1.Pod{p}
+		}(),
+		wantPhase: kubeeyev1alpha2.PhaseRunning,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phase, reason, message := jobState(tt.job, tt.pods)
+			if phase != tt.wantPhase || reason != tt.wantReason || message != tt.wantMessage {
+				t.Errorf("jobState() = %s, %q, %q, want %s, %q, %q", phase, reason, message, tt.wantPhase, tt.wantReason, tt.wantMessage)
+			}
+		})
+	}
+}
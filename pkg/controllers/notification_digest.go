@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"github.com/kubesphere/kubeeye/pkg/kube"
+	"github.com/kubesphere/kubeeye/pkg/message"
+	"github.com/kubesphere/kubeeye/pkg/output"
+	"github.com/robfig/cron/v3"
+	"html"
+	"k8s.io/client-go/informers"
+	"k8s.io/klog/v2"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+	"time"
+)
+
+// digestCheckInterval is how often the digest schedules are checked.
+const digestCheckInterval = time.Minute
+
+// NotificationDigest sends the results collected by the digest channels on their schedules.
+type NotificationDigest struct {
+	client.Client
+	// APIReader reads the notification state without the cache
+	APIReader  client.Reader
+	K8sFactory informers.SharedInformerFactory
+}
+
+func (d *NotificationDigest) Start(ctx context.Context) error {
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			d.SendDigests(ctx, now)
+		}
+	}
+}
+
+// NeedLeaderElection makes only the leader send digests.
+func (d *NotificationDigest) NeedLeaderElection() bool {
+	return true
+}
+
+// SendDigests sends the digest of every channel whose schedule is due.
+func (d *NotificationDigest) SendDigests(ctx context.Context, now time.Time) {
+	kc, err := kube.GetKubeEyeConfig(d.K8sFactory.Core())
+	if err != nil || kc.Message == nil || !kc.Message.Enable {
+		return
+	}
+	store := message.NewStateStore(d.Client, d.APIReader, os.Getenv("KUBERNETES_POD_NAMESPACE"))
+	for _, channel := range kc.Message.GetChannels() {
+		if channel.Digest == nil {
+			continue
+		}
+		schedule, err := cron.ParseStandard(channel.Digest.Schedule)
+		if err != nil {
+			klog.Errorf("invalid digest schedule of channel %s, err:%s", channel.Name, err)
+			continue
+		}
+		var entries []message.DigestEntry
+		var since time.Time
+		err = store.Update(ctx, channel.Name, func(state *message.ReceiverState) error {
+			entries = nil
+			if state.LastDigest.IsZero() {
+				state.LastDigest = now
+				return nil
+			}
+			if schedule.Next(state.LastDigest).After(now) {
+				return nil
+			}
+			entries, since = state.Pending, state.LastDigest
+			state.Pending = nil
+			state.LastDigest = now
+			return nil
+		})
+		if err != nil {
+			klog.Errorf("failed to update the notification state of channel %s, err:%s", channel.Name, err)
+			continue
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		klog.Infof("sending digest of %d results to channel %s", len(entries), channel.Name)
+		dispatcher := message.RegisterHandler(message.NewChannelHandler(&channel, d.Client))
+		if err = dispatcher.DispatchMessageEvent(DigestEvent(entries, since, now, kc.Message.Format, kc.Message.Language)); err != nil {
+			klog.Errorf("failed to send digest to channel %s, err:%s", channel.Name, err)
+			d.restoreDigest(ctx, store, channel.Name, entries, since, now)
+		}
+	}
+}
+
+// restoreDigest puts back the results of a digest that failed to send, they are sent with the next attempt.
+func (d *NotificationDigest) restoreDigest(ctx context.Context, store *message.StateStore, receiver string, entries []message.DigestEntry, since time.Time, now time.Time) {
+	err := store.Update(ctx, receiver, func(state *message.ReceiverState) error {
+		state.AddPending(entries...)
+		if state.LastDigest.Equal(now) {
+			state.LastDigest = since
+		}
+		return nil
+	})
+	if err != nil {
+		klog.Errorf("failed to restore the digest of channel %s, %d results are lost, err:%s", receiver, len(entries), err)
+	}
+}
+
+// DigestEvent summarises the results: the summary adds up the latest result of every plan and cluster, the
+// content lists every result. The title is in the language of the message config.
+func DigestEvent(entries []message.DigestEntry, since time.Time, now time.Time, format string, language string) *conf.MessageEvent {
+	latest := map[string]message.DigestEntry{}
+	for _, entry := range entries {
+		key := entry.Plan + "/" + entry.Cluster
+		if previous, ok := latest[key]; !ok || previous.Time.Before(entry.Time) {
+			latest[key] = entry
+		}
+	}
+	summary := &conf.MessageSummary{Score: 100, Levels: map[string]int{}}
+	var clusters []string
+	seen := map[string]bool{}
+	for _, entry := range latest {
+		summary.Total += entry.Total
+		summary.Score = min(summary.Score, entry.Score)
+		for level, n := range entry.Levels {
+			summary.Levels[level] += n
+		}
+		if !seen[entry.Cluster] {
+			seen[entry.Cluster] = true
+			clusters = append(clusters, entry.Cluster)
+		}
+	}
+	sort.Strings(clusters)
+	summary.Cluster = strings.Join(clusters, ", ")
+
+	event := &conf.MessageEvent{
+		Title:     message.DigestTitle(language, len(entries), since),
+		Timestamp: now,
+		Summary:   summary,
+	}
+	buffer := &bytes.Buffer{}
+	switch format {
+	case output.FormatMarkdown, output.FormatText:
+		buffer.WriteString("| Time | Plan | Cluster | Result | Score | Danger | Warning | Ignore |\n")
+		buffer.WriteString("| --- | --- | --- | --- | --- | --- | --- | --- |\n")
+		for _, e := range entries {
+			_, _ = fmt.Fprintf(buffer, "| %s | %s | %s | %s | %d | %d | %d | %d |\n", e.Time.Format("2006-01-02 15:04"), e.Plan, e.Cluster, e.Result, e.Score, e.Levels["danger"], e.Levels["warning"], e.Levels["ignore"])
+		}
+		event.ContentType = "text/plain"
+	default:
+		_, _ = fmt.Fprintf(buffer, "<h3>%s</h3>\n<table border=\"1\" cellspacing=\"0\" cellpadding=\"4\">\n", html.EscapeString(event.Title))
+		buffer.WriteString("<tr><th>Time</th><th>Plan</th><th>Cluster</th><th>Result</th><th>Score</th><th>Danger</th><th>Warning</th><th>Ignore</th></tr>\n")
+		for _, e := range entries {
+			_, _ = fmt.Fprintf(buffer, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td></tr>\n", e.Time.Format("2006-01-02 15:04"), html.EscapeString(e.Plan), html.EscapeString(e.Cluster), html.EscapeString(e.Result), e.Score, e.Levels["danger"], e.Levels["warning"], e.Levels["ignore"])
+		}
+		buffer.WriteString("</table>\n")
+		event.ContentType = "text/html"
+	}
+	event.Content = buffer.Bytes()
+	return event
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/message"
+	"github.com/kubesphere/kubeeye/pkg/output"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"net/http"
+	"net/http/httptest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDigestEvent(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := since.Add(24 * time.Hour)
+	entries := []message.DigestEntry{
+		{Result: "daily-default-1", Plan: "daily", Cluster: "default", Score: 60, Total: 5, Levels: map[string]int{"danger": 2, "warning": 3}, Time: since.Add(time.Hour)},
+		{Result: "daily-member-1", Plan: "daily", Cluster: "member", Score: 90, Total: 1, Levels: map[string]int{"warning": 1}, Time: since.Add(2 * time.Hour)},
+		{Result: "daily-default-2", Plan: "daily", Cluster: "default", Score: 80, Total: 2, Levels: map[string]int{"danger": 1, "ignore": 1}, Time: since.Add(3 * time.Hour)},
+	}
+
+	event := DigestEvent(entries, since, now, output.FormatMarkdown, "en")
+	if event.Title != "KubeEye digest: 3 inspections since 2024-01-01 00:00" || !event.Timestamp.Equal(now) {
+		t.Errorf("unexpected title %q at %s", event.Title, event.Timestamp)
+	}
+	summary := event.Summary
+	if summary.Cluster != "default, member" || summary.Score != 80 || summary.Total != 3 {
+		t.Errorf("summary = %+v, want the latest result of each plan and cluster added up", summary)
+	}
+	if summary.Levels["danger"] != 1 || summary.Levels["warning"] != 1 || summary.Levels["ignore"] != 1 {
+		t.Errorf("summary levels = %v", summary.Levels)
+	}
+	content := string(event.Content)
+	if event.ContentType != "text/plain" || strings.Count(content, "\n") != len(entries)+2 {
+		t.Errorf("markdown digest should list every result:\n%s", content)
+	}
+	if !strings.Contains(content, "| 2024-01-01 01:00 | daily | default | daily-default-1 | 60 | 2 | 3 | 0 |") {
+		t.Errorf("markdown digest misses the first result:\n%s", content)
+	}
+
+	event = DigestEvent(entries, since, now, output.FormatMarkdown, "")
+	if event.Title != "KubeEye巡检汇总: 2024-01-01 00:00以来共3次巡检" {
+		t.Errorf("unexpected default zh title %q", event.Title)
+	}
+
+	event = DigestEvent([]message.DigestEntry{{Result: "<b>", Plan: "daily", Cluster: "default", Time: since}}, since, now, "", "en")
+	if event.ContentType != "text/html" || !strings.Contains(string(event.Content), "<td>&lt;b&gt;</td>") {
+		t.Errorf("html digest should escape the cells:\n%s", event.Content)
+	}
+}
+
+func TestSendDigestsRestoresFailedDigest(t *testing.T) {
+	t.Setenv("KUBERNETES_POD_NAMESPACE", "kubeeye-system")
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	factory := informers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 0)
+	config := fmt.Sprintf(`{"message": {"enable": true, "channels": [{"name": "weekly", "type": "webhook", "webhook": {"url": %q, "retries": 0}, "digest": {"schedule": "@daily"}}]}}`, server.URL)
+	if err := factory.Core().V1().ConfigMaps().Informer().GetIndexer().Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kubeeye-system", Name: "kubeeye-config"},
+		Data:       map[string]string{"config": config},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().Build()
+	d := &NotificationDigest{Client: c, APIReader: c, K8sFactory: factory}
+	store := message.NewStateStore(c, c, "kubeeye-system")
+	ctx := context.Background()
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pending := []message.DigestEntry{
+		{Result: "daily-default-1", Plan: "daily", Cluster: "default", Time: since.Add(time.Hour)},
+		{Result: "daily-default-2", Plan: "daily", Cluster: "default", Time: since.Add(2 * time.Hour)},
+	}
+	if err := store.Update(ctx, "weekly", func(state *message.ReceiverState) error {
+		state.LastDigest = since
+		state.AddPending(pending...)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	state := func() (s message.ReceiverState) {
+		t.Helper()
+		if err := store.Update(ctx, "weekly", func(state *message.ReceiverState) error {
+			s = *state
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	now := since.Add(25 * time.Hour)
+	d.SendDigests(ctx, now)
+	if got := state(); len(got.Pending) != len(pending) || !got.LastDigest.Equal(since) {
+		t.Errorf("after a failed digest the state is %+v, want the results pending since %s", got, since)
+	}
+
+	status.Store(http.StatusOK)
+	d.SendDigests(ctx, now.Add(time.Minute))
+	if got := state(); len(got.Pending) != 0 || !got.LastDigest.Equal(now.Add(time.Minute)) {
+		t.Errorf("after a sent digest the state is %+v, want nothing pending", got)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("got %d requests, want the failed digest and its retry", requests.Load())
+	}
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
+	"time"
+)
+
+// setTaskProgress counts the jobs of the task by phase and rule type. The estimated completion time is only
+// computed again when more jobs have finished, so that the status doesn't change on every resync.
+func setTaskProgress(task *kubeeyev1alpha2.InspectTask, now time.Time) {
+	progress := &kubeeyev1alpha2.TaskProgress{}
+	ruleTypes := map[string]*kubeeyev1alpha2.RuleTypeProgress{}
+	for _, job := range task.Status.JobPhase {
+		countJob(&progress.JobCounts, job.Phase)
+		if _, ok := ruleTypes[job.RuleType]; !ok {
+			ruleTypes[job.RuleType] = &kubeeyev1alpha2.RuleTypeProgress{RuleType: job.RuleType}
+		}
+		countJob(&ruleTypes[job.RuleType].JobCounts, job.Phase)
+	}
+	for _, ruleType := range ruleTypes {
+		progress.RuleTypes = append(progress.RuleTypes, *ruleType)
+	}
+	sort.Slice(progress.RuleTypes, func(i, j int) bool {
+		return progress.RuleTypes[i].RuleType < progress.RuleTypes[j].RuleType
+	})
+
+	finished := finishedJobs(progress.JobCounts)
+	last := task.Status.Progress
+	switch {
+	case finished == 0 || finished == progress.Total || task.Status.StartTimestamp.IsZero():
+	case last != nil && finishedJobs(last.JobCounts) == finished && last.Total == progress.Total:
+		progress.EstimatedCompletionTime = last.EstimatedCompletionTime
+	default:
+		elapsed := now.Sub(task.Status.StartTimestamp.Time)
+		eta := metav1.NewTime(task.Status.StartTimestamp.Add(elapsed * time.Duration(progress.Total) / time.Duration(finished)).Truncate(time.Second))
+		progress.EstimatedCompletionTime = &eta
+	}
+	task.Status.Progress = progress
+}
+
+func countJob(counts *kubeeyev1alpha2.JobCounts, phase kubeeyev1alpha2.Phase) {
+	counts.Total++
+	switch {
+	case phase.IsPending():
+		counts.Pending++
+	case phase.IsRunning():
+		counts.Running++
+	case phase.IsSucceeded():
+		counts.Succeeded++
+	case phase.IsFailed():
+		counts.Failed++
+	case phase.IsCancelled():
+		counts.Cancelled++
+	}
+}
+
+func finishedJobs(counts kubeeyev1alpha2.JobCounts) int {
+	return counts.Succeeded + counts.Failed + counts.Cancelled
+}
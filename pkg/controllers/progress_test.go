@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func progressTask(start time.Time, phases map[string][]kubeeyev1alpha2.Phase) *kubeeyev1alpha2.InspectTask {
+	task := &kubeeyev1alpha2.InspectTask{Status: kubeeyev1alpha2.InspectTaskStatus{StartTimestamp: &metav1.Time{Time: start}}}
+	for ruleType, list := range phases {
+		for _, phase := range list {
+			task.Status.JobPhase = append(task.Status.JobPhase, kubeeyev1alpha2.JobPhase{RuleType: ruleType, Phase: phase})
+		}
+	}
+	return task
+}
+
+func TestSetTaskProgressCounts(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	task := progressTask(start, map[string][]kubeeyev1alpha2.Phase{
+		"opa":    {kubeeyev1alpha2.PhaseSucceeded, kubeeyev1alpha2.PhaseRunning},
+		"sysctl": {kubeeyev1alpha2.PhasePending, kubeeyev1alpha2.PhaseFailed, kubeeyev1alpha2.PhaseCancelled},
+	})
+
+	setTaskProgress(task, start.Add(time.Minute))
+	progress := task.Status.Progress
+	want := kubeeyev1alpha2.JobCounts{Total: 5, Pending: 1, Running: 1, Succeeded: 1, Failed: 1, Cancelled: 1}
+	if progress.JobCounts != want {
+		t.Errorf("job counts = %+v, want %+v", progress.JobCounts, want)
+	}
+	wantRuleTypes := []kubeeyev1alpha2.RuleTypeProgress{
+		{RuleType: "opa", JobCounts: kubeeyev1alpha2.JobCounts{Total: 2, Running: 1, Succeeded: 1}},
+		{RuleType: "sysctl", JobCounts: kubeeyev1alpha2.JobCounts{Total: 3, Pending: 1, Failed: 1, Cancelled: 1}},
+	}
+	if !reflect.DeepEqual(progress.RuleTypes, wantRuleTypes) {
+		t.Errorf("rule types = %+v, want %+v", progress.RuleTypes, wantRuleTypes)
+	}
+	// 3 of 5 jobs finished in a minute
+	if eta := progress.EstimatedCompletionTime; eta == nil || !eta.Time.Equal(start.Add(100*time.Second)) {
+		t.Errorf("estimated completion = %v, want %s", eta, start.Add(100*time.Second))
+	}
+}
+
+func TestSetTaskProgressEstimate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	task := progressTask(start, map[string][]kubeeyev1alpha2.Phase{
+		"opa": {kubeeyev1alpha2.PhaseRunning, kubeeyev1alpha2.PhaseRunning, kubeeyev1alpha2.PhaseRunning, kubeeyev1alpha2.PhasePending},
+	})
+
+	setTaskProgress(task, start.Add(time.Minute))
+	if task.Status.Progress.EstimatedCompletionTime != nil {
+		t.Error("no estimate before a job has finished")
+	}
+
+	task.Status.JobPhase[0].Phase = kubeeyev1alpha2.PhaseSucceeded
+	setTaskProgress(task, start.Add(time.Minute))
+	first := task.Status.Progress.EstimatedCompletionTime
+	if first == nil || !first.Time.Equal(start.Add(4*time.Minute)) {
+		t.Fatalf("estimated completion = %v, want %s", first, start.Add(4*time.Minute))
+	}
+
+	// a job started running, the finished count is the same
+	task.Status.JobPhase[3].Phase = kubeeyev1alpha2.PhaseRunning
+	setTaskProgress(task, start.Add(3*time.Minute))
+	if got := task.Status.Progress.EstimatedCompletionTime; !got.Equal(first) {
+		t.Errorf("estimated completion = %s, want %s kept while no more jobs finished", got, first)
+	}
+
+	task.Status.JobPhase[1].Phase = kubeeyev1alpha2.PhaseFailed
+	setTaskProgress(task, start.Add(3*time.Minute))
+	if got := task.Status.Progress.EstimatedCompletionTime; got == nil || !got.Time.Equal(start.Add(6*time.Minute)) {
+		t.Errorf("estimated completion = %v, want %s once another job finished", got, start.Add(6*time.Minute))
+	}
+
+	task.Status.JobPhase[2].Phase = kubeeyev1alpha2.PhaseSucceeded
+	task.Status.JobPhase[3].Phase = kubeeyev1alpha2.PhaseSucceeded
+	setTaskProgress(task, start.Add(4*time.Minute))
+	if got := task.Status.Progress.EstimatedCompletionTime; got != nil {
+		t.Errorf("estimated completion = %s, want none once every job finished", got)
+	}
+}
+
+func TestSetTaskProgressNotStarted(t *testing.T) {
+	task := progressTask(time.Time{}, map[string][]kubeeyev1alpha2.Phase{"opa": {kubeeyev1alpha2.PhaseSucceeded, kubeeyev1alpha2.PhaseRunning}})
+	task.Status.StartTimestamp = nil
+	setTaskProgress(task, time.Now())
+	if task.Status.Progress.EstimatedCompletionTime != nil {
+		t.Error("no estimate without a start time")
+	}
+}
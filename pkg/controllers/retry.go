@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"slices"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultBackoff     = 10 * time.Second
+	maxBackoff         = 5 * time.Minute
+)
+
+// defaultRetryableReasons are transient failures: a node briefly not ready, throttled or conflicting api requests
+// failing the job pods, or evicted pods.
+var defaultRetryableReasons = []string{
+	kubeeyev1alpha2.ReasonNodeUnavailable,
+	kubeeyev1alpha2.ReasonJobCreateFailed,
+	kubeeyev1alpha2.ReasonJobFailed,
+	"BackoffLimitExceeded",
+	"Evicted",
+}
+
+// retryDelay returns how long to wait before the next attempt of a job that failed for the reason, false when the
+// job is not retried.
+func retryDelay(policy *kubeeyev1alpha2.RetryPolicy, reason string, attempts int) (time.Duration, bool) {
+	if policy == nil {
+		return 0, false
+	}
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	if attempts >= maxAttempts {
+		return 0, false
+	}
+	reasons := policy.RetryableReasons
+	if len(reasons) == 0 {
+		reasons = defaultRetryableReasons
+	}
+	if !slices.Contains(reasons, reason) {
+		return 0, false
+	}
+
+	backoff, err := time.ParseDuration(policy.Backoff)
+	if err != nil || backoff <= 0 {
+		backoff = defaultBackoff
+	}
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff), true
+}
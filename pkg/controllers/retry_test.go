@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    *kubeeyev1alpha2.RetryPolicy
+		reason    string
+		attempts  int
+		wantDelay time.Duration
+		wantRetry bool
+	}{
+		{name: "no policy", reason: kubeeyev1alpha2.ReasonJobFailed, attempts: 1},
+		{name: "default backoff", policy: &kubeeyev1alpha2.RetryPolicy{}, reason: kubeeyev1alpha2.ReasonJobFailed, attempts: 1, wantDelay: defaultBackoff, wantRetry: true},
+		{name: "invalid backoff", policy: &kubeeyev1alpha2.RetryPolicy{Backoff: "soon"}, reason: kubeeyev1alpha2.ReasonJobFailed, attempts: 1, wantDelay: defaultBackoff, wantRetry: true},
+		{name: "first retry", policy: &kubeeyev1alpha2.RetryPolicy{MaxAttempts: 10, Backoff: "30s"}, reason: "Evicted", attempts: 1, wantDelay: 30 * time.Second, wantRetry: true},
+		{name: "doubled", policy: &kubeeyev1alpha2.RetryPolicy{MaxAttempts: 10, Backoff: "30s"}, reason: "Evicted", attempts: 2, wantDelay: time.Minute, wantRetry: true},
+		{name: "doubled twice", policy: &kubeeyev1alpha2.RetryPolicy{MaxAttempts: 10, Backoff: "30s"}, reason: "Evicted", attempts: 3, wantDelay: 2 * time.Minute, wantRetry: true},
+		{name: "capped", policy: &kubeeyev1alpha2.RetryPolicy{MaxAttempts: 10, Backoff: "30s"}, reason: "Evicted", attempts: 5, wantDelay: maxBackoff, wantRetry: true},
+		{name: "backoff above the cap", policy: &kubeeyev1alpha2.RetryPolicy{Backoff: "1h"}, reason: "Evicted", attempts: 1, wantDelay: maxBackoff, wantRetry: true},
+		{name: "default max attempts", policy: &kubeeyev1alpha2.RetryPolicy{}, reason: kubeeyev1alpha2.ReasonJobFailed, attempts: defaultMaxAttempts},
+		{name: "below max attempts", policy: &kubeeyev1alpha2.RetryPolicy{MaxAttempts: 2}, reason: kubeeyev1alpha2.ReasonJobFailed, attempts: 1, wantDelay: defaultBackoff, wantRetry: true},
+		{name: "max attempts reached", policy: &kubeeyev1alpha2.RetryPolicy{MaxAttempts: 2}, reason: kubeeyev1alpha2.ReasonJobFailed, attempts: 2},
+		{name: "reason not retried by default", policy: &kubeeyev1alpha2.RetryPolicy{}, reason: kubeeyev1alpha2.ReasonImagePullBackOff, attempts: 1},
+		{name: "configured reasons", policy: &kubeeyev1alpha2.RetryPolicy{RetryableReasons: []string{kubeeyev1alpha2.ReasonImagePullBackOff}}, reason: kubeeyev1alpha2.ReasonImagePullBackOff, attempts: 1, wantDelay: defaultBackoff, wantRetry: true},
+		{name: "configured reasons replace the defaults", policy: &kubeeyev1alpha2.RetryPolicy{RetryableReasons: []string{kubeeyev1alpha2.ReasonImagePullBackOff}}, reason: kubeeyev1alpha2.ReasonJobFailed, attempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, retry := retryDelay(tt.policy, tt.reason, tt.attempts)
+			if delay != tt.wantDelay || retry != tt.wantRetry {
+				t.Errorf("retryDelay() = %s, %v, want %s, %v", delay, retry, tt.wantDelay, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestFailJob(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    *kubeeyev1alpha2.RetryPolicy
+		age       time.Duration
+		reason    string
+		wantPhase kubeeyev1alpha2.Phase
+		wantEvent string
+	}{
+		{name: "no retry policy", age: time.Minute, reason: kubeeyev1alpha2.ReasonJobFailed, wantPhase: kubeeyev1alpha2.PhaseFailed, wantEvent: "Warning JobFailed job inspect-job failed: boom"},
+		{name: "retried", policy: &kubeeyev1alpha2.RetryPolicy{Backoff: "1m"}, age: time.Minute, reason: kubeeyev1alpha2.ReasonJobFailed, wantPhase: kubeeyev1alpha2.PhasePending, wantEvent: "Warning Retrying job inspect-job failed (JobFailed), retrying in 1m0s, attempt 2"},
+		{name: "reason not retried", policy: &kubeeyev1alpha2.RetryPolicy{Backoff: "1m"}, age: time.Minute, reason: kubeeyev1alpha2.ReasonOOMKilled, wantPhase: kubeeyev1alpha2.PhaseFailed, wantEvent: "Warning OOMKilled job inspect-job failed: boom"},
+		{name: "retry after the task timeout", policy: &kubeeyev1alpha2.RetryPolicy{Backoff: "1m"}, age: 9*time.Minute + 30*time.Second, reason: kubeeyev1alpha2.ReasonJobFailed, wantPhase: kubeeyev1alpha2.PhaseFailed, wantEvent: "Warning JobFailed job inspect-job failed: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &InspectTaskReconciler{Recorder: recorder}
+			task := &kubeeyev1alpha2.InspectTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "task", CreationTimestamp: metav1.NewTime(time.Now().Add(-tt.age))},
+				Spec:       kubeeyev1alpha2.InspectTaskSpec{Timeout: "10m", RetryPolicy: tt.policy},
+			}
+			phase := &kubeeyev1alpha2.JobPhase{JobName: "inspect-job", Cluster: "default", Phase: kubeeyev1alpha2.PhaseRunning, Attempts: 1}
+
+			before := time.Now()
+			r.failJob(task, phase, tt.reason, "boom")
+			if phase.Phase != tt.wantPhase || phase.Reason != tt.reason || phase.Message != "boom" {
+				t.Errorf("failJob() = %s %q %q, want %s %q", phase.Phase, phase.Reason, phase.Message, tt.wantPhase, tt.reason)
+			}
+			if tt.wantPhase.IsPending() {
+				if phase.NextAttemptTime == nil || phase.NextAttemptTime.Time.Before(before.Add(time.Minute-time.Second)) {
+					t.Errorf("next attempt at %v, want after the backoff", phase.NextAttemptTime)
+				}
+			} else if phase.NextAttemptTime != nil {
+				t.Errorf("a failed job should not wait for a retry, next attempt at %v", phase.NextAttemptTime)
+			}
+			select {
+			case event := <-recorder.Events:
+				if !strings.HasPrefix(event, tt.wantEvent) {
+					t.Errorf("event = %q, want %q", event, tt.wantEvent)
+				}
+			default:
+				t.Error("no event recorded")
+			}
+		})
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	phases := func(phases ...kubeeyev1alpha2.Phase) []kubeeyev1alpha2.JobPhase {
+		var jobs []kubeeyev1alpha2.JobPhase
+		for _, phase := range phases {
+			jobs = append(jobs, kubeeyev1alpha2.JobPhase{Phase: phase})
+		}
+		return jobs
+	}
+	tests := []struct {
+		name   string
+		cancel bool
+		jobs   []kubeeyev1alpha2.JobPhase
+		want   kubeeyev1alpha2.Phase
+	}{
+		{name: "no jobs", want: kubeeyev1alpha2.PhaseFailed},
+		{name: "all succeeded", jobs: phases(kubeeyev1alpha2.PhaseSucceeded, kubeeyev1alpha2.PhaseSucceeded), want: kubeeyev1alpha2.PhaseSucceeded},
+		{name: "some failed", jobs: phases(kubeeyev1alpha2.PhaseSucceeded, kubeeyev1alpha2.PhaseFailed), want: kubeeyev1alpha2.PhasePartiallySucceeded},
+		{name: "all failed", jobs: phases(kubeeyev1alpha2.PhaseFailed, kubeeyev1alpha2.PhaseFailed), want: kubeeyev1alpha2.PhaseFailed},
+		{name: "cancelled", cancel: true, jobs: phases(kubeeyev1alpha2.PhaseSucceeded, kubeeyev1alpha2.PhaseFailed), want: kubeeyev1alpha2.PhaseCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &kubeeyev1alpha2.InspectTask{
+				Spec:   kubeeyev1alpha2.InspectTaskSpec{Cancel: tt.cancel},
+				Status: kubeeyev1alpha2.InspectTaskStatus{JobPhase: tt.jobs},
+			}
+			if got := GetStatus(task); got != tt.want {
+				t.Errorf("GetStatus() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
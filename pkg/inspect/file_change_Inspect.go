@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/options"
 	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
 	"github.com/kubesphere/kubeeye/pkg/constant"
 	"github.com/kubesphere/kubeeye/pkg/kube"
@@ -22,10 +23,11 @@ import (
 )
 
 type fileChangeInspect struct {
+	hostPaths HostPaths
 }
 
 func init() {
-	RuleOperatorMap[constant.FileChange] = &fileChangeInspect{}
+	RuleOperatorMap[constant.FileChange] = &fileChangeInspect{hostPaths: DefaultHostPaths()}
 }
 
 func (f *fileChangeInspect) RunInspect(ctx context.Context, rules []kubeeyev1alpha2.JobRule, clients *kube.KubernetesClient, currentJobName string, informers informers.SharedInformerFactory, ownerRef ...metav1.OwnerReference) ([]byte, error) {
@@ -50,7 +52,7 @@ func (f *fileChangeInspect) RunInspect(ctx context.Context, rules []kubeeyev1alp
 			Path:       file.Path,
 		}
 
-		baseFile, fileErr := os.ReadFile(path.Join(constant.RootPathPrefix, file.Path))
+		baseFile, fileErr := os.ReadFile(path.Join(f.hostPaths.Root, file.Path))
 		if fileErr != nil {
 			klog.Errorf("Failed to open base file path:%s,error:%s", baseFile, fileErr)
 			resultItem.Issues = []string{fmt.Sprintf("%s:The file does not exist", file.Name)}
@@ -133,3 +135,7 @@ func diffString(base1 string, base2 string) []string {
 	}
 	return isseus
 }
+
+func (f *fileChangeInspect) withHostPaths(paths HostPaths) options.InspectInterface {
+	return &fileChangeInspect{hostPaths: paths}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/options"
 	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
 	"github.com/kubesphere/kubeeye/pkg/constant"
 	"github.com/kubesphere/kubeeye/pkg/kube"
@@ -19,10 +20,11 @@ import (
 )
 
 type fileFilterInspect struct {
+	hostPaths HostPaths
 }
 
 func init() {
-	RuleOperatorMap[constant.FileFilter] = &fileFilterInspect{}
+	RuleOperatorMap[constant.FileFilter] = &fileFilterInspect{hostPaths: DefaultHostPaths()}
 }
 
 func (f *fileFilterInspect) RunInspect(ctx context.Context, rules []kubeeyev1alpha2.JobRule, clients *kube.KubernetesClient, currentJobName string, informers informers.SharedInformerFactory, ownerRef ...metav1.OwnerReference) ([]byte, error) {
@@ -41,7 +43,7 @@ func (f *fileFilterInspect) RunInspect(ctx context.Context, rules []kubeeyev1alp
 			return nil, err
 		}
 		for _, rule := range filter {
-			file, err := os.OpenFile(path.Join(constant.RootPathPrefix, rule.Path), os.O_RDONLY, 0222)
+			file, err := os.OpenFile(path.Join(f.hostPaths.Root, rule.Path), os.O_RDONLY, 0222)
 			filterR := kubeeyev1alpha2.FileChangeResultItem{
 				Path:       rule.Path,
 				BaseResult: kubeeyev1alpha2.BaseResult{Name: rule.Name},
@@ -98,3 +100,7 @@ func (f *fileFilterInspect) GetResult(runNodeName string, resultCm *corev1.Confi
 	return resultCr, nil
 
 }
+
+func (f *fileFilterInspect) withHostPaths(paths HostPaths) options.InspectInterface {
+	return &fileFilterInspect{hostPaths: paths}
+}
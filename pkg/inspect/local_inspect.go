@@ -0,0 +1,48 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/options"
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	"github.com/kubesphere/kubeeye/pkg/kube"
+	"github.com/kubesphere/kubeeye/pkg/template"
+	"k8s.io/klog/v2"
+)
+
+// HostPaths are where the node inspectors find the host proc and root filesystems.
+type HostPaths struct {
+	Proc string
+	Root string
+}
+
+// DefaultHostPaths are the host filesystems mounted into the inspect job pods.
+func DefaultHostPaths() HostPaths {
+	return HostPaths{Proc: constant.ProcPathPrefix, Root: constant.RootPathPrefix}
+}
+
+// hostInspector is an inspector reading the host filesystems, which can be pointed at other trees.
+type hostInspector interface {
+	withHostPaths(paths HostPaths) options.InspectInterface
+}
+
+// RunLocalInspect runs a single job rule in the current process and merges its output into resultCr,
+// the same way the task controller merges the result ConfigMap written back by an inspect job.
+// Node rules read the host filesystems under paths.
+func RunLocalInspect(ctx context.Context, clients *kube.KubernetesClient, jobRule kubeeyev1alpha2.JobRule, runNodeName string, paths HostPaths, resultCr *kubeeyev1alpha2.InspectResult) (*kubeeyev1alpha2.InspectResult, error) {
+	inspectInterface, exist := RuleOperatorMap[jobRule.RuleType]
+	if !exist {
+		return nil, fmt.Errorf("unsupported rule type %s", jobRule.RuleType)
+	}
+	if h, ok := inspectInterface.(hostInspector); ok {
+		inspectInterface = h.withHostPaths(paths)
+	}
+	data, err := inspectInterface.RunInspect(ctx, []kubeeyev1alpha2.JobRule{jobRule}, clients, jobRule.JobName, nil)
+	if err != nil {
+		klog.Errorf("failed to run %s inspect, err:%s", jobRule.RuleType, err)
+		return nil, err
+	}
+	resultCm := template.BinaryConfigMapTemplate(jobRule.JobName, "", data, true, map[string]string{constant.LabelNodeName: runNodeName, constant.LabelRuleType: jobRule.RuleType})
+	return inspectInterface.GetResult(runNodeName, resultCm, resultCr)
+}
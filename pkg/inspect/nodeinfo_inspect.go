@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/kubesphere/event-rule-engine/visitor"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/options"
 	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
 	"github.com/kubesphere/kubeeye/pkg/constant"
 	"github.com/kubesphere/kubeeye/pkg/kube"
@@ -21,10 +22,11 @@ import (
 )
 
 type nodeInfoInspect struct {
+	hostPaths HostPaths
 }
 
 func init() {
-	RuleOperatorMap[constant.NodeInfo] = &nodeInfoInspect{}
+	RuleOperatorMap[constant.NodeInfo] = &nodeInfoInspect{hostPaths: DefaultHostPaths()}
 }
 
 func (n *nodeInfoInspect) RunInspect(ctx context.Context, rules []kubeeyev1alpha2.JobRule, clients *kube.KubernetesClient, currentJobName string, informers informers.SharedInformerFactory, ownerRef ...metav1.OwnerReference) ([]byte, error) {
@@ -36,7 +38,7 @@ func (n *nodeInfoInspect) RunInspect(ctx context.Context, rules []kubeeyev1alpha
 	})
 
 	if exist {
-		fs, err := procfs.NewFS(constant.ProcPathPrefix)
+		fs, err := procfs.NewFS(n.hostPaths.Proc)
 		if err != nil {
 			return nil, err
 		}
@@ -74,7 +76,7 @@ func (n *nodeInfoInspect) RunInspect(ctx context.Context, rules []kubeeyev1alpha
 				if utils.IsEmptyValue(info.Mount) {
 					info.Mount = "/"
 				}
-				storage := GetFileSystem(info.Mount)
+				storage := GetFileSystem(n.hostPaths.Root, info.Mount)
 				resultItem.ResourcesType.Type = constant.Filesystem
 				resultItem.ResourcesType.Mount = info.Mount
 				resultItem.Value = fmt.Sprintf("%.0f%%", storage[constant.Filesystem])
@@ -87,7 +89,7 @@ func (n *nodeInfoInspect) RunInspect(ctx context.Context, rules []kubeeyev1alpha
 				if utils.IsEmptyValue(info.Mount) {
 					info.Mount = "/"
 				}
-				inodes := GetInodes(info.Mount)
+				inodes := GetInodes(n.hostPaths.Root, info.Mount)
 				resultItem.ResourcesType.Type = constant.Inode
 				resultItem.ResourcesType.Mount = info.Mount
 				resultItem.Value = fmt.Sprintf("%.0f%%", inodes[constant.Inode])
@@ -177,9 +179,9 @@ func GetLoadAvg(fs procfs.FS) map[string]interface{} {
 	}
 	return map[string]interface{}{"load1": avg.Load1, "load5": avg.Load5, "load15": avg.Load15}
 }
-func GetFileSystem(p string) map[string]interface{} {
+func GetFileSystem(root string, p string) map[string]interface{} {
 	u := new(unix.Statfs_t)
-	err := unix.Statfs(path.Join(constant.RootPathPrefix, p), u)
+	err := unix.Statfs(path.Join(root, p), u)
 	if err != nil {
 		klog.Error("failed to get filesystem info")
 		return nil
@@ -192,9 +194,9 @@ func GetFileSystem(p string) map[string]interface{} {
 	return map[string]interface{}{constant.Filesystem: storageUse * 100}
 }
 
-func GetInodes(p string) map[string]interface{} {
+func GetInodes(root string, p string) map[string]interface{} {
 	u := new(unix.Statfs_t)
-	err := unix.Statfs(path.Join(constant.RootPathPrefix, p), u)
+	err := unix.Statfs(path.Join(root, p), u)
 	if err != nil {
 		klog.Error("failed to get filesystem info")
 		return nil
@@ -204,3 +206,7 @@ func GetInodes(p string) map[string]interface{} {
 	inodeUseRate := float64(inodeUse) / float64(u.Files)
 	return map[string]interface{}{constant.Inode: inodeUseRate * 100}
 }
+
+func (n *nodeInfoInspect) withHostPaths(paths HostPaths) options.InspectInterface {
+	return &nodeInfoInspect{hostPaths: paths}
+}
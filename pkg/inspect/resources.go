@@ -276,6 +276,25 @@ func VailOpaRulesResult(ctx context.Context, k8sResources kube.K8SResource, Rego
 	return RuleResult
 }
 
+// ValidateResource validates a single resource with the rego query RegoRulesValidate uses for its kind.
+// Kinds that RegoRulesValidate does not inspect are reported as not found.
+func ValidateResource(ctx context.Context, resource unstructured.Unstructured, regoRulesList []string) (v1alpha2.ResourceResult, bool) {
+	var queryRule string
+	switch resource.GetKind() {
+	case "Namespace", "Deployment", "Pod", "StatefulSet", "DaemonSet", "Job", "CronJob":
+		queryRule = workloads
+	case "Role", "ClusterRole":
+		queryRule = rbac
+	case "Node":
+		queryRule = nodes
+	case "Event":
+		queryRule = events
+	default:
+		return v1alpha2.ResourceResult{}, false
+	}
+	return validateK8SResource(ctx, resource, regoRulesList, queryRule)
+}
+
 // ValidateK8SResource validate kubernetes resource by rego, return the validate results.
 func validateK8SResource(ctx context.Context, resource unstructured.Unstructured, regoRulesList []string, queryRule string) (v1alpha2.ResourceResult, bool) {
 	var auditResult v1alpha2.ResourceResult
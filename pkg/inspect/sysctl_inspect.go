@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/kubesphere/event-rule-engine/visitor"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/options"
 	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
 	"github.com/kubesphere/kubeeye/pkg/constant"
 	"github.com/kubesphere/kubeeye/pkg/kube"
@@ -17,17 +18,18 @@ import (
 )
 
 type sysctlInspect struct {
+	hostPaths HostPaths
 }
 
 func init() {
-	RuleOperatorMap[constant.Sysctl] = &sysctlInspect{}
+	RuleOperatorMap[constant.Sysctl] = &sysctlInspect{hostPaths: DefaultHostPaths()}
 }
 
 func (o *sysctlInspect) RunInspect(ctx context.Context, rules []kubeeyev1alpha2.JobRule, clients *kube.KubernetesClient, currentJobName string, informers informers.SharedInformerFactory, ownerRef ...metav1.OwnerReference) ([]byte, error) {
 
 	var SysctlResult []kubeeyev1alpha2.NodeMetricsResultItem
 
-	fs, err := procfs.NewFS(constant.ProcPathPrefix)
+	fs, err := procfs.NewFS(o.hostPaths.Proc)
 	if err != nil {
 		return nil, err
 	}
@@ -111,3 +113,7 @@ func parseSysctlVal(val []string) string {
 	}
 	return val[0]
 }
+
+func (o *sysctlInspect) withHostPaths(paths HostPaths) options.InspectInterface {
+	return &sysctlInspect{hostPaths: paths}
+}
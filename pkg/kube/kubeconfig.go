@@ -170,5 +170,11 @@ func GetKubeEyeConfig(c core.Interface) (kc conf.KubeEyeConfig, err error) {
 		klog.Errorf("failed to unmarshal kubeeye config. err:%s ", err)
 		return kc, err
 	}
+	if kc.Message != nil {
+		if err = kc.Message.Validate(); err != nil {
+			klog.Errorf("invalid kubeeye message config. err:%s ", err)
+			return kc, err
+		}
+	}
 	return kc, nil
 }
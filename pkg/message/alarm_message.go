@@ -2,6 +2,7 @@ package message
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/kubesphere/kubeeye/pkg/conf"
 
 	"io"
@@ -14,20 +15,24 @@ type AlarmMessageHandler struct {
 	RequestUrl string
 }
 
-func (h *AlarmMessageHandler) HandleMessageEvent(event *conf.MessageEvent) {
+func (h *AlarmMessageHandler) HandleMessageEvent(event *conf.MessageEvent) error {
 	// 执行消息发送操作
 	// 例如，发送消息给目标
 
 	resp, err := http.Post(h.RequestUrl, "application/json", bytes.NewReader(event.Content))
 	if err != nil {
 		klog.Error(err)
-		return
+		return err
 	}
 	defer resp.Body.Close()
 	all, err := io.ReadAll(resp.Body)
 	if err != nil {
 		klog.Error(err)
-		return
+		return err
 	}
 	klog.Info(string(all))
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("alarm request failed with status %s", resp.Status)
+	}
+	return nil
 }
@@ -0,0 +1,141 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"io"
+	"k8s.io/klog/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// AlertName is the alertname label of the findings
+	AlertName = "KubeEyeFinding"
+
+	defaultResolveTimeout      = time.Hour
+	alertmanagerRequestTimeout = 10 * time.Second
+)
+
+// Alert is a postable alert of the Alertmanager v2 API.
+type Alert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// AlertmanagerMessageHandler posts the danger and warning findings as alerts. The labels only identify the finding,
+// so an alert keeps its fingerprint across inspections and expires at endsAt once the finding is resolved.
+type AlertmanagerMessageHandler struct {
+	*conf.AlertmanagerConfig
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func NewAlertmanagerMessageHandler(config *conf.AlertmanagerConfig) *AlertmanagerMessageHandler {
+	if config == nil {
+		config = &conf.AlertmanagerConfig{}
+	}
+	return &AlertmanagerMessageHandler{
+		AlertmanagerConfig: config,
+		httpClient:         &http.Client{Timeout: alertmanagerRequestTimeout},
+		now:                time.Now,
+	}
+}
+
+func (a *AlertmanagerMessageHandler) HandleMessageEvent(event *conf.MessageEvent) error {
+	if err := a.Send(context.TODO(), event); err != nil {
+		klog.Error("failed to send alerts to alertmanager, err: ", err)
+		return err
+	}
+	klog.Info("send alerts to alertmanager success")
+	return nil
+}
+
+func (a *AlertmanagerMessageHandler) Send(ctx context.Context, event *conf.MessageEvent) error {
+	if a.URL == "" {
+		return errors.New("alertmanager url is empty")
+	}
+	alerts := a.Alerts(event)
+	if len(alerts) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(a.URL, "/")+"/api/v2/alerts", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for k, v := range a.Headers {
+		request.Header.Set(k, v)
+	}
+	resp, err := a.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("alertmanager responded %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Alerts converts the danger and warning findings of the event to alerts.
+func (a *AlertmanagerMessageHandler) Alerts(event *conf.MessageEvent) []Alert {
+	now := a.now()
+	endsAt := now.Add(parseDuration(a.ResolveTimeout, defaultResolveTimeout))
+	var cluster, plan string
+	if event.Summary != nil {
+		cluster, plan = event.Summary.Cluster, event.Summary.Plan
+	}
+
+	var alerts []Alert
+	for _, f := range event.Findings {
+		if f.Level != "danger" && f.Level != "warning" {
+			continue
+		}
+		labels := map[string]string{}
+		for k, v := range a.Labels {
+			labels[k] = v
+		}
+		for k, v := range map[string]string{
+			"alertname": AlertName,
+			"cluster":   cluster,
+			"plan":      plan,
+			"rule_type": f.RuleType,
+			"rule":      f.Name,
+			"level":     f.Level,
+			"node":      f.Node,
+			"namespace": f.Namespace,
+			"resource":  f.Resource,
+		} {
+			// alertmanager ignores empty labels, leave them out so the fingerprint doesn't depend on them
+			if v != "" {
+				labels[k] = v
+			}
+		}
+		annotations := map[string]string{"summary": findingText(f)}
+		if f.Message != "" {
+			annotations["message"] = f.Message
+		}
+		alerts = append(alerts, Alert{
+			Labels:       labels,
+			Annotations:  annotations,
+			StartsAt:     now,
+			EndsAt:       endsAt,
+			GeneratorURL: event.ReportURL,
+		})
+	}
+	return alerts
+}
@@ -0,0 +1,127 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAlertmanager records the alerts posted to /api/v2/alerts.
+type fakeAlertmanager struct {
+	sync.Mutex
+	posts [][]Alert
+}
+
+func (f *fakeAlertmanager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/api/v2/alerts" {
+		http.NotFound(w, r)
+		return
+	}
+	var alerts []Alert
+	if err := json.NewDecoder(r.Body).Decode(&alerts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.Lock()
+	f.posts = append(f.posts, alerts)
+	f.Unlock()
+}
+
+func TestAlertmanagerAlerts(t *testing.T) {
+	fake := &fakeAlertmanager{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	handler := NewAlertmanagerMessageHandler(&conf.AlertmanagerConfig{
+		URL:            server.URL + "/",
+		ResolveTimeout: "2h",
+		Labels:         map[string]string{"team": "ops"},
+	})
+	handler.now = func() time.Time { return now }
+
+	event := testEvent()
+	event.Summary.Plan = "daily"
+	event.ReportURL = "http://kubeeye/report"
+	event.Findings = append(event.Findings, conf.MessageFinding{RuleType: "opa", Name: "ImageTagIsLatest", Level: "ignore"})
+	if err := handler.Send(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.posts) != 1 || len(fake.posts[0]) != 2 {
+		t.Fatalf("want one post of 2 alerts, ignore level skipped, got %v", fake.posts)
+	}
+	alert := fake.posts[0][0]
+	wantLabels := map[string]string{
+		"alertname": AlertName,
+		"team":      "ops",
+		"cluster":   "default",
+		"plan":      "daily",
+		"rule_type": "opa",
+		"rule":      "NoCPULimits",
+		"level":     "danger",
+		"namespace": "default",
+		"resource":  "Deployment/nginx",
+	}
+	if !reflect.DeepEqual(alert.Labels, wantLabels) {
+		t.Errorf("labels = %v, want %v", alert.Labels, wantLabels)
+	}
+	if !alert.StartsAt.Equal(now) || !alert.EndsAt.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("startsAt %s endsAt %s", alert.StartsAt, alert.EndsAt)
+	}
+	if alert.GeneratorURL != "http://kubeeye/report" || alert.Annotations["message"] != `"cpu" limit is not set` {
+		t.Errorf("unexpected alert %+v", alert)
+	}
+	if _, ok := fake.posts[0][1].Labels["namespace"]; ok {
+		t.Errorf("empty labels should be left out, got %v", fake.posts[0][1].Labels)
+	}
+
+	// the next inspection finds the same issues with other messages, the labels and so the fingerprints stay the same
+	now = now.Add(30 * time.Minute)
+	event.Findings[0].Message = `"cpu" limit is not set on container nginx`
+	if err := handler.Send(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.posts) != 2 {
+		t.Fatalf("want 2 posts, got %d", len(fake.posts))
+	}
+	for i := range fake.posts[0] {
+		if !reflect.DeepEqual(fake.posts[0][i].Labels, fake.posts[1][i].Labels) {
+			t.Errorf("labels changed across runs: %v != %v", fake.posts[0][i].Labels, fake.posts[1][i].Labels)
+		}
+	}
+	if !fake.posts[1][0].EndsAt.Equal(now.Add(2 * time.Hour)) {
+		t.Errorf("endsAt should move with the inspection, got %s", fake.posts[1][0].EndsAt)
+	}
+}
+
+func TestAlertmanagerNoFindings(t *testing.T) {
+	fake := &fakeAlertmanager{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	event := testEvent()
+	event.Findings = nil
+	if err := NewAlertmanagerMessageHandler(&conf.AlertmanagerConfig{URL: server.URL}).Send(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.posts) != 0 {
+		t.Errorf("nothing should be posted without findings, got %v", fake.posts)
+	}
+}
+
+func TestAlertmanagerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if err := NewAlertmanagerMessageHandler(&conf.AlertmanagerConfig{URL: server.URL}).Send(context.Background(), testEvent()); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,188 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"net/http"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"time"
+)
+
+const (
+	defaultChatTop     = 5
+	chatRequestTimeout = 10 * time.Second
+)
+
+var chatLevels = []string{"danger", "warning", "ignore"}
+
+// chatBot holds what the chat robots have in common: the webhook, the sign secret and the http client.
+type chatBot struct {
+	*conf.ChatConfig
+	client.Client
+	httpClient *http.Client
+}
+
+func newChatBot(config *conf.ChatConfig, c client.Client) chatBot {
+	if config == nil {
+		config = &conf.ChatConfig{}
+	}
+	return chatBot{
+		ChatConfig: config,
+		Client:     c,
+		httpClient: &http.Client{Timeout: chatRequestTimeout},
+	}
+}
+
+func (b *chatBot) top() int {
+	if b.Top > 0 {
+		return b.Top
+	}
+	return defaultChatTop
+}
+
+// findings returns the findings listed in the message.
+func (b *chatBot) findings(event *conf.MessageEvent) []conf.MessageFinding {
+	if len(event.Findings) > b.top() {
+		return event.Findings[:b.top()]
+	}
+	return event.Findings
+}
+
+// secret reads the robot sign secret, empty when no SecretKey is configured.
+func (b *chatBot) secret(ctx context.Context) (string, error) {
+	if b.SecretKey == "" {
+		return "", nil
+	}
+	var secret corev1.Secret
+	err := b.Client.Get(ctx, types.NamespacedName{
+		Namespace: os.Getenv("KUBERNETES_POD_NAMESPACE"),
+		Name:      b.SecretKey,
+	}, &secret)
+	if err != nil {
+		return "", err
+	}
+	if len(secret.Data["signSecret"]) == 0 {
+		return "", fmt.Errorf("secret %s has no signSecret", b.SecretKey)
+	}
+	return string(secret.Data["signSecret"]), nil
+}
+
+// post sends the payload as JSON to url and returns the response body of a 2xx response.
+func (b *chatBot) post(ctx context.Context, url string, payload interface{}) ([]byte, error) {
+	if url == "" {
+		return nil, errors.New("chat webhook url is empty")
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := b.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("chat webhook responded %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// chatError checks the error code that dingtalk, wecom and feishu return with a 200 response.
+func chatError(body []byte) error {
+	var resp struct {
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		Code    *int   `json:"code"`
+		Msg     string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil
+	}
+	if resp.ErrCode != nil && *resp.ErrCode != 0 {
+		return fmt.Errorf("chat robot error %d: %s", *resp.ErrCode, resp.ErrMsg)
+	}
+	if resp.Code != nil && *resp.Code != 0 {
+		return fmt.Errorf("chat robot error %d: %s", *resp.Code, resp.Msg)
+	}
+	return nil
+}
+
+// hmacBase64 returns the base64 HMAC-SHA256 of message.
+func hmacBase64(key, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// levelCounts formats the issues per level, e.g. "danger: 1, warning: 2, ignore: 0".
+func levelCounts(summary *conf.MessageSummary) string {
+	if summary == nil {
+		return ""
+	}
+	counts := make([]string, 0, len(chatLevels))
+	for _, level := range chatLevels {
+		counts = append(counts, fmt.Sprintf("%s: %d", level, summary.Levels[level]))
+	}
+	return strings.Join(counts, ", ")
+}
+
+// findingText is a single line description of a finding.
+func findingText(f conf.MessageFinding) string {
+	var location []string
+	if f.Node != "" {
+		location = append(location, f.Node)
+	}
+	if f.Namespace != "" {
+		location = append(location, f.Namespace)
+	}
+	if f.Resource != "" {
+		location = append(location, f.Resource)
+	}
+	text := fmt.Sprintf("[%s] %s/%s", f.Level, f.RuleType, f.Name)
+	if len(location) > 0 {
+		text += " " + strings.Join(location, "/")
+	}
+	if f.Message != "" {
+		text += ": " + f.Message
+	}
+	return text
+}
+
+// chatMarkdown renders the compact summary as the markdown understood by dingtalk, wecom and feishu.
+func chatMarkdown(event *conf.MessageEvent, findings []conf.MessageFinding) string {
+	builder := &strings.Builder{}
+	if s := event.Summary; s != nil {
+		_, _ = fmt.Fprintf(builder, "**Cluster**: %s\n\n", s.Cluster)
+		_, _ = fmt.Fprintf(builder, "**Score**: %d\n\n", s.Score)
+		_, _ = fmt.Fprintf(builder, "**Issues**: %d (%s)\n\n", s.Total, levelCounts(s))
+	}
+	if len(findings) > 0 {
+		builder.WriteString("**Top findings**\n\n")
+		for _, f := range findings {
+			_, _ = fmt.Fprintf(builder, "- %s\n", findingText(f))
+		}
+		builder.WriteString("\n")
+	}
+	if event.ReportURL != "" {
+		_, _ = fmt.Fprintf(builder, "[View report](%s)\n", event.ReportURL)
+	}
+	return builder.String()
+}
@@ -0,0 +1,257 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chatServer records the query and the JSON body of the last request and answers with response.
+type chatServer struct {
+	*httptest.Server
+	query url.Values
+	body  map[string]interface{}
+}
+
+func newChatServer(t *testing.T, response string) *chatServer {
+	s := &chatServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("content type = %s, want application/json", r.Header.Get("Content-Type"))
+		}
+		s.query = r.URL.Query()
+		if err := json.NewDecoder(r.Body).Decode(&s.body); err != nil {
+			t.Errorf("body is not valid json: %s", err)
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// signSecretClient serves the robot sign secret "SECtest" as the secret robot-sign.
+func signSecretClient(t *testing.T) *fake.ClientBuilder {
+	t.Setenv("KUBERNETES_POD_NAMESPACE", "kubeeye-system")
+	return fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "robot-sign", Namespace: "kubeeye-system"},
+		Data:       map[string][]byte{"signSecret": []byte("SECtest")},
+	})
+}
+
+// lookup walks the nested maps and slices of a decoded JSON body, e.g. lookup(body, "card", "elements", 0, "tag").
+func lookup(value interface{}, path ...interface{}) interface{} {
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			value = m[key]
+		case int:
+			s, ok := value.([]interface{})
+			if !ok || key >= len(s) {
+				return nil
+			}
+			value = s[key]
+		}
+	}
+	return value
+}
+
+func TestDingTalkSign(t *testing.T) {
+	got := dingTalkSign("https://oapi.dingtalk.com/robot/send?access_token=abc", "SECtest", time.UnixMilli(1700000000000))
+	want := "https://oapi.dingtalk.com/robot/send?access_token=abc&timestamp=1700000000000&sign=aZLLrriXgn05YbwaGR7knYsLeJADjr9NwLaNNKpxh4g%3D"
+	if got != want {
+		t.Errorf("dingTalkSign() = %s, want %s", got, want)
+	}
+}
+
+func TestFeishuSign(t *testing.T) {
+	timestamp, sign := feishuSign("SECtest", time.Unix(1700000000, 0))
+	if timestamp != "1700000000" || sign != "G7XpBpG8NgG02fJOAhX6FRAObIljmFoxVReo8I62pEk=" {
+		t.Errorf("feishuSign() = %s, %s", timestamp, sign)
+	}
+}
+
+func TestDingTalkSend(t *testing.T) {
+	server := newChatServer(t, `{"errcode":0,"errmsg":"ok"}`)
+	handler := NewDingTalkMessageHandler(&conf.ChatConfig{URL: server.URL + "?access_token=abc", SecretKey: "robot-sign"}, signSecretClient(t).Build())
+	event := testEvent()
+	event.ReportURL = "https://kubeeye.example.com/report"
+	if err := handler.Send(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	timestamp := server.query.Get("timestamp")
+	if server.query.Get("access_token") != "abc" || timestamp == "" {
+		t.Fatalf("unexpected query %v", server.query)
+	}
+	if want := hmacBase64("SECtest", timestamp+"\nSECtest"); server.query.Get("sign") != want {
+		t.Errorf("sign = %s, want %s", server.query.Get("sign"), want)
+	}
+	if server.body["msgtype"] != "markdown" || lookup(server.body, "markdown", "title") != event.Title {
+		t.Errorf("unexpected payload %v", server.body)
+	}
+	text, _ := lookup(server.body, "markdown", "text").(string)
+	for _, want := range []string{"### " + event.Title, "**Score**: 80", "[danger] opa/NoCPULimits", "[View report](https://kubeeye.example.com/report)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("markdown text misses %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestDingTalkSendUnsigned(t *testing.T) {
+	server := newChatServer(t, `{"errcode":0}`)
+	if err := NewDingTalkMessageHandler(&conf.ChatConfig{URL: server.URL}, nil).Send(context.Background(), testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	if server.query.Has("sign") || server.query.Has("timestamp") {
+		t.Errorf("request without a sign secret should not be signed: %v", server.query)
+	}
+}
+
+func TestFeishuSend(t *testing.T) {
+	server := newChatServer(t, `{"code":0,"msg":"success"}`)
+	handler := NewFeishuMessageHandler(&conf.ChatConfig{URL: server.URL, SecretKey: "robot-sign"}, signSecretClient(t).Build())
+	event := testEvent()
+	event.ReportURL = "https://kubeeye.example.com/report"
+	if err := handler.Send(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	timestamp, _ := server.body["timestamp"].(string)
+	if want := hmacBase64(timestamp+"\nSECtest", ""); timestamp == "" || server.body["sign"] != want {
+		t.Errorf("sign = %v, want %s", server.body["sign"], want)
+	}
+	if server.body["msg_type"] != "interactive" {
+		t.Errorf("msg_type = %v, want interactive", server.body["msg_type"])
+	}
+	if got := lookup(server.body, "card", "header", "title", "content"); got != event.Title {
+		t.Errorf("card title = %v, want %s", got, event.Title)
+	}
+	if got := lookup(server.body, "card", "header", "template"); got != "red" {
+		t.Errorf("card template = %v, want red for danger findings", got)
+	}
+	if got := lookup(server.body, "card", "elements", 1, "actions", 0, "url"); got != event.ReportURL {
+		t.Errorf("report button url = %v, want %s", got, event.ReportURL)
+	}
+}
+
+func TestFeishuTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary *conf.MessageSummary
+		want    string
+	}{
+		{name: "danger", summary: &conf.MessageSummary{Total: 1, Levels: map[string]int{"danger": 1}}, want: "red"},
+		{name: "warning", summary: &conf.MessageSummary{Total: 1, Levels: map[string]int{"warning": 1}}, want: "orange"},
+		{name: "clean", summary: &conf.MessageSummary{}, want: "green"},
+		{name: "no summary", want: "green"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newChatServer(t, `{"code":0}`)
+			if err := NewFeishuMessageHandler(&conf.ChatConfig{URL: server.URL}, nil).Send(context.Background(), &conf.MessageEvent{Title: "t", Summary: tt.summary}); err != nil {
+				t.Fatal(err)
+			}
+			if got := lookup(server.body, "card", "header", "template"); got != tt.want {
+				t.Errorf("card template = %v, want %s", got, tt.want)
+			}
+			if server.body["sign"] != nil {
+				t.Error("request without a sign secret should not be signed")
+			}
+		})
+	}
+}
+
+func TestWeComSend(t *testing.T) {
+	server := newChatServer(t, `{"errcode":0,"errmsg":"ok"}`)
+	if err := NewWeComMessageHandler(&conf.ChatConfig{URL: server.URL, Top: 1}, nil).Send(context.Background(), testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := lookup(server.body, "markdown", "content").(string)
+	if server.body["msgtype"] != "markdown" || !strings.HasPrefix(content, "### default集群巡检完成") {
+		t.Errorf("unexpected payload %v", server.body)
+	}
+	if !strings.Contains(content, "opa/NoCPULimits") || strings.Contains(content, "sysctl/net.ipv4.ip_forward") {
+		t.Errorf("content should list only the top finding:\n%s", content)
+	}
+}
+
+func TestSlackSend(t *testing.T) {
+	server := newChatServer(t, "ok")
+	event := testEvent()
+	event.Findings[0].Message = "a < b & c"
+	if err := NewSlackMessageHandler(&conf.ChatConfig{URL: server.URL}, nil).Send(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if server.body["text"] != event.Title || lookup(server.body, "blocks", 0, "text", "text") != event.Title {
+		t.Errorf("unexpected header %v", server.body)
+	}
+	if got := lookup(server.body, "blocks", 1, "fields", 1, "text"); got != "*Score*\n80" {
+		t.Errorf("score field = %v", got)
+	}
+	findings, _ := lookup(server.body, "blocks", 2, "text", "text").(string)
+	if !strings.Contains(findings, "a &lt; b &amp; c") {
+		t.Errorf("findings are not escaped: %s", findings)
+	}
+}
+
+func TestTeamsSend(t *testing.T) {
+	server := newChatServer(t, "1")
+	event := testEvent()
+	event.ReportURL = "https://kubeeye.example.com/report"
+	if err := NewTeamsMessageHandler(&conf.ChatConfig{URL: server.URL}, nil).Send(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	attachment := lookup(server.body, "attachments", 0)
+	if server.body["type"] != "message" || lookup(attachment, "contentType") != "application/vnd.microsoft.card.adaptive" {
+		t.Fatalf("unexpected payload %v", server.body)
+	}
+	if got := lookup(attachment, "content", "body", 1, "facts", 1, "value"); got != "80" {
+		t.Errorf("score fact = %v, want 80", got)
+	}
+	if got := lookup(attachment, "content", "actions", 0, "url"); got != event.ReportURL {
+		t.Errorf("report action url = %v, want %s", got, event.ReportURL)
+	}
+}
+
+func TestChatRobotError(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{name: "dingtalk ok", response: `{"errcode":0,"errmsg":"ok"}`},
+		{name: "dingtalk error", response: `{"errcode":310000,"errmsg":"sign not match"}`, wantErr: true},
+		{name: "feishu error", response: `{"code":19021,"msg":"sign match fail"}`, wantErr: true},
+		{name: "not json", response: "ok"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newChatServer(t, tt.response)
+			err := NewWeComMessageHandler(&conf.ChatConfig{URL: server.URL}, nil).Send(context.Background(), testEvent())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Send() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChatMissingSignSecret(t *testing.T) {
+	server := newChatServer(t, `{"errcode":0}`)
+	handler := NewDingTalkMessageHandler(&conf.ChatConfig{URL: server.URL, SecretKey: "missing"}, signSecretClient(t).Build())
+	if err := handler.Send(context.Background(), testEvent()); err == nil {
+		t.Error("expected an error when the sign secret does not exist")
+	}
+}
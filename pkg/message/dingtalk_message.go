@@ -0,0 +1,59 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"k8s.io/klog/v2"
+	"net/url"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+)
+
+// DingTalkMessageHandler posts the summary to a DingTalk robot as markdown, signed when the robot has a sign secret.
+type DingTalkMessageHandler struct {
+	chatBot
+}
+
+func NewDingTalkMessageHandler(config *conf.ChatConfig, c client.Client) *DingTalkMessageHandler {
+	return &DingTalkMessageHandler{chatBot: newChatBot(config, c)}
+}
+
+func (d *DingTalkMessageHandler) HandleMessageEvent(event *conf.MessageEvent) error {
+	if err := d.Send(context.TODO(), event); err != nil {
+		klog.Error("failed to send dingtalk message, err: ", err)
+		return err
+	}
+	klog.Info("send dingtalk message success")
+	return nil
+}
+
+func (d *DingTalkMessageHandler) Send(ctx context.Context, event *conf.MessageEvent) error {
+	secret, err := d.secret(ctx)
+	if err != nil {
+		return err
+	}
+	requestUrl := d.URL
+	if secret != "" {
+		requestUrl = dingTalkSign(requestUrl, secret, time.Now())
+	}
+	payload := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]interface{}{
+			"title": event.Title,
+			"text":  fmt.Sprintf("### %s\n\n%s", event.Title, chatMarkdown(event, d.findings(event))),
+		},
+	}
+	body, err := d.post(ctx, requestUrl, payload)
+	if err != nil {
+		return err
+	}
+	return chatError(body)
+}
+
+// dingTalkSign appends the timestamp and the signature of "timestamp\nsecret" to the robot url.
+func dingTalkSign(requestUrl, secret string, now time.Time) string {
+	timestamp := fmt.Sprintf("%d", now.UnixMilli())
+	sign := hmacBase64(secret, timestamp+"\n"+secret)
+	return fmt.Sprintf("%s&timestamp=%s&sign=%s", requestUrl, timestamp, url.QueryEscape(sign))
+}
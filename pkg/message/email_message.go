@@ -4,22 +4,31 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"github.com/kubesphere/kubeeye/pkg/conf"
 	"github.com/kubesphere/kubeeye/pkg/utils"
+	"io"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	"mime"
+	"mime/multipart"
 	"net"
 	"net/smtp"
+	"net/textproto"
 	"os"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// emailDialTimeout bounds the connection to the SMTP server.
+const emailDialTimeout = 30 * time.Second
+
 type EmailMessageHandler struct {
 	// 可以添加处理器需要的属性
 	*conf.EmailConfig
@@ -33,46 +42,94 @@ func NewEmailMessageOptions(event *conf.EmailConfig, c client.Client) *EmailMess
 	}
 }
 
-func (e *EmailMessageHandler) HandleMessageEvent(event *conf.MessageEvent) {
+func (e *EmailMessageHandler) HandleMessageEvent(event *conf.MessageEvent) error {
 
 	if err := e.Vail(); err != nil {
 		klog.Error("failed to vail params", err)
-		return
+		return err
 	}
 
 	if err := e.SendMsg(event); err != nil {
 		klog.Error("failed to send email, err: ", err)
-		return
+		return err
 	}
 	klog.Info("send email success")
+	return nil
 }
 
-func (e *EmailMessageHandler) setMsg(me *conf.MessageEvent, to string) []byte {
+// setMsg builds the message, the Bcc recipients are left out of the headers.
+func (e *EmailMessageHandler) setMsg(me *conf.MessageEvent, subject string) []byte {
 	buffer := &bytes.Buffer{}
 	_, _ = fmt.Fprintf(buffer, "From: %s\r\n", mime.QEncoding.Encode("utf-8", e.Fo))
-	_, _ = fmt.Fprintf(buffer, "To: %s\r\n", mime.QEncoding.Encode("utf-8", to))
-	_, _ = fmt.Fprintf(buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", me.Title))
+	_, _ = fmt.Fprintf(buffer, "To: %s\r\n", mime.QEncoding.Encode("utf-8", strings.Join(e.To, ", ")))
+	if len(e.Cc) > 0 {
+		_, _ = fmt.Fprintf(buffer, "Cc: %s\r\n", mime.QEncoding.Encode("utf-8", strings.Join(e.Cc, ", ")))
+	}
+	_, _ = fmt.Fprintf(buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
 	_, _ = fmt.Fprintf(buffer, "Message-Id: %s\r\n", fmt.Sprintf("<%d.@%s>", time.Now().UnixNano(), e.Address))
 	_, _ = fmt.Fprintf(buffer, "Date: %s\r\n", me.Timestamp.Format(time.RFC1123Z))
-	_, _ = fmt.Fprintf(buffer, "Content-Type: text/html; charset=UTF-8;")
-	_, _ = fmt.Fprintf(buffer, "MIME-Version: 1.0\r\n\r\n")
-	_, _ = fmt.Fprintf(buffer, "%s", me.Content)
+	contentType := me.ContentType
+	if contentType == "" {
+		contentType = "text/html"
+	}
+	_, _ = fmt.Fprintf(buffer, "MIME-Version: 1.0\r\n")
+	if len(me.Attachments) == 0 {
+		_, _ = fmt.Fprintf(buffer, "Content-Type: %s; charset=UTF-8\r\n\r\n", contentType)
+		_, _ = fmt.Fprintf(buffer, "%s", me.Content)
+		return buffer.Bytes()
+	}
+
+	writer := multipart.NewWriter(buffer)
+	_, _ = fmt.Fprintf(buffer, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+	body, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType + "; charset=UTF-8"}})
+	if err == nil {
+		_, _ = body.Write(me.Content)
+	}
+	for _, attachment := range me.Attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+		})
+		if err != nil {
+			klog.Errorf("failed to attach %s, err: %s", attachment.Name, err)
+			continue
+		}
+		writeBase64Lines(part, attachment.Data)
+	}
+	_ = writer.Close()
 	return buffer.Bytes()
 }
 
+// writeBase64Lines writes data as base64 wrapped at 76 characters as required by RFC 2045.
+func writeBase64Lines(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		_, _ = fmt.Fprintf(w, "%s\r\n", encoded[:76])
+		encoded = encoded[76:]
+	}
+	_, _ = fmt.Fprintf(w, "%s\r\n", encoded)
+}
+
 func (e *EmailMessageHandler) SendMsg(eve *conf.MessageEvent) error {
+	ctx := context.TODO()
+	tlsConfig, err := e.tlsConfig(ctx)
+	if err != nil {
+		return err
+	}
+	mode := e.TLS
+	if mode == "" && e.Port == 465 {
+		mode = conf.EmailTLS
+	}
 
 	var conn net.Conn
-	var err error
-
-	if e.Port == 465 {
-		tlsConfig := &tls.Config{InsecureSkipVerify: true}
-		conn, err = tls.Dial("tcp", fmt.Sprintf("%s:%d", e.Address, e.Port), tlsConfig)
+	address := net.JoinHostPort(e.Address, strconv.Itoa(int(e.Port)))
+	d := &net.Dialer{Timeout: emailDialTimeout}
+	if mode == conf.EmailTLS {
+		conn, err = tls.DialWithDialer(d, "tcp", address, tlsConfig)
 	} else {
-		d := net.Dialer{}
-		conn, err = d.Dial("tcp", fmt.Sprintf("%s:%d", e.Address, e.Port))
+		conn, err = d.Dial("tcp", address)
 	}
-
 	if err != nil {
 		return err
 	}
@@ -85,30 +142,31 @@ func (e *EmailMessageHandler) SendMsg(eve *conf.MessageEvent) error {
 	if err != nil {
 		return err
 	}
-	//if ok, _ := dial.Extension("STARTTLS"); ok {
-	//	config := &tls.Config{ServerName: e.Address}
-	//	if err = dial.StartTLS(config); err != nil {
-	//		return err
-	//	}
-	//}
-
-	ok, param := dial.Extension("AUTH")
-	if !ok {
-		return errors.New("smtp: server doesn't support AUTH")
+	if mode == "" || mode == conf.EmailStartTLS {
+		ok, _ := dial.Extension("STARTTLS")
+		if ok {
+			if err = dial.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		} else if mode == conf.EmailStartTLS {
+			return errors.New("smtp: server doesn't support STARTTLS")
+		}
 	}
-	auth, err := e.auth(param)
+
+	auth, err := e.auth(ctx, dial)
 	if err != nil {
 		return err
 	}
-
-	if err = dial.Auth(auth); err != nil {
-		return err
+	if auth != nil {
+		if err = dial.Auth(auth); err != nil {
+			return err
+		}
 	}
 
 	if err = dial.Mail(e.Fo); err != nil {
 		return err
 	}
-	for _, addr := range e.To {
+	for _, addr := range e.recipients() {
 		if err = dial.Rcpt(addr); err != nil {
 			return err
 		}
@@ -117,13 +175,9 @@ func (e *EmailMessageHandler) SendMsg(eve *conf.MessageEvent) error {
 	if err != nil {
 		return err
 	}
-	for _, s := range e.To {
-		_, err = w.Write(e.setMsg(eve, s))
-		if err != nil {
-			klog.Errorf("unable send mail to %s", s)
-		}
+	if _, err = w.Write(e.setMsg(eve, e.subject(eve))); err != nil {
+		return err
 	}
-
 	err = w.Close()
 	if err != nil {
 		return err
@@ -131,6 +185,60 @@ func (e *EmailMessageHandler) SendMsg(eve *conf.MessageEvent) error {
 	return dial.Quit()
 }
 
+// recipients are the envelope recipients: To, Cc and Bcc without duplicates.
+func (e *EmailMessageHandler) recipients() []string {
+	var recipients []string
+	seen := map[string]bool{}
+	for _, list := range [][]string{e.To, e.Cc, e.Bcc} {
+		for _, addr := range list {
+			if !seen[addr] {
+				seen[addr] = true
+				recipients = append(recipients, addr)
+			}
+		}
+	}
+	return recipients
+}
+
+// subject renders the subject template, the event title is used when it is empty or fails.
+func (e *EmailMessageHandler) subject(eve *conf.MessageEvent) string {
+	if e.Subject == "" {
+		return eve.Title
+	}
+	subject, err := RenderTitle(e.Subject, NewTitleData(eve.Title, eve.Summary))
+	if err != nil {
+		klog.Error("failed to render email subject, err: ", err)
+		return eve.Title
+	}
+	return subject
+}
+
+func (e *EmailMessageHandler) tlsConfig(ctx context.Context) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         e.ServerName,
+		InsecureSkipVerify: e.InsecureSkipVerify,
+	}
+	if config.ServerName == "" {
+		config.ServerName = e.Address
+	}
+	if e.CARef != nil {
+		key := e.CARef.Key
+		if key == "" {
+			key = "ca.crt"
+		}
+		secret, err := e.getSecret(ctx, e.CARef.Namespace, e.CARef.Name)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(secret.Data[key]) {
+			return nil, fmt.Errorf("secret %s has no PEM certificate in %s", e.CARef.Name, key)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
 func (e *EmailMessageHandler) Vail() error {
 	if utils.IsEmptyValue(e.Address) {
 		return errors.New("address is empty")
@@ -144,8 +252,10 @@ func (e *EmailMessageHandler) Vail() error {
 	if utils.IsEmptyValue(e.To) {
 		return errors.New("to is empty")
 	}
-	if utils.IsEmptyValue(e.SecretKey) {
-		return errors.New("secretKey is empty")
+	switch e.TLS {
+	case "", conf.EmailTLS, conf.EmailStartTLS, conf.EmailNoTLS:
+	default:
+		return fmt.Errorf("unknown tls mode %s", e.TLS)
 	}
 
 	return nil
@@ -155,35 +265,82 @@ type mailAuth struct {
 	username, password string
 }
 
-func (e *EmailMessageHandler) auth(authType string) (smtp.Auth, error) {
+// credentials reads the username and password, both are empty when no Secret is configured.
+func (e *EmailMessageHandler) credentials(ctx context.Context) (string, string, error) {
+	ref := e.CredentialsRef
+	if ref == nil {
+		if e.SecretKey == "" {
+			return "", "", nil
+		}
+		ref = &conf.CredentialsRef{Name: e.SecretKey}
+	}
+	secret, err := e.getSecret(ctx, ref.Namespace, ref.Name)
+	if err != nil {
+		return "", "", err
+	}
+	usernameKey, passwordKey := ref.UsernameKey, ref.PasswordKey
+	if usernameKey == "" {
+		usernameKey = "username"
+	}
+	if passwordKey == "" {
+		passwordKey = "password"
+	}
+	return string(secret.Data[usernameKey]), string(secret.Data[passwordKey]), nil
+}
+
+func (e *EmailMessageHandler) getSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	if namespace == "" {
+		namespace = os.Getenv("KUBERNETES_POD_NAMESPACE")
+	}
 	var secret corev1.Secret
-	err := e.Client.Get(context.TODO(), types.NamespacedName{
-		Namespace: os.Getenv("KUBERNETES_POD_NAMESPACE"),
-		Name:      e.SecretKey,
+	err := e.Client.Get(ctx, types.NamespacedName{
+		Namespace: namespace,
+		Name:      name,
 	}, &secret)
 	if err != nil {
 		return nil, err
 	}
+	return &secret, nil
+}
 
-	for _, t := range strings.Split(authType, " ") {
-		switch t {
-		case "PLAIN":
-			return smtp.PlainAuth("", string(secret.Data["username"]), string(secret.Data["password"]), e.Address), nil
-			//case "LOGIN":
-			//	return MailAuth(string(secret.Data["username"]), string(secret.Data["password"])), nil
+// auth picks PLAIN or LOGIN from the mechanisms of the server, it is nil without credentials.
+func (e *EmailMessageHandler) auth(ctx context.Context, dial *smtp.Client) (smtp.Auth, error) {
+	username, password, err := e.credentials(ctx)
+	if err != nil || username == "" {
+		return nil, err
+	}
+	ok, authType := dial.Extension("AUTH")
+	if !ok {
+		return nil, errors.New("smtp: server doesn't support AUTH")
+	}
+	mechanisms := strings.Split(authType, " ")
+	for _, t := range mechanisms {
+		if t == "PLAIN" {
+			return smtp.PlainAuth("", username, password, e.Address), nil
+		}
+	}
+	for _, t := range mechanisms {
+		if t == "LOGIN" {
+			if _, isTLS := dial.TLSConnectionState(); !isTLS && !isLocalhost(e.Address) {
+				return nil, errors.New("smtp: LOGIN auth over an unencrypted connection")
+			}
+			return MailAuth(username, password), nil
 		}
 	}
 
 	return nil, fmt.Errorf("unknown auth mechanism: %s", authType)
 }
 
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
 func MailAuth(username, password string) smtp.Auth {
 	return &mailAuth{username, password}
 }
 
 func (a *mailAuth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
-	resp := []byte(a.username + "\x00" + a.password)
-	return "LOGIN", resp, nil
+	return "LOGIN", nil, nil
 }
 
 func (a *mailAuth) Next(fromServer []byte, more bool) ([]byte, error) {
@@ -0,0 +1,290 @@
+package message
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"math/big"
+	"net"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpStub is a minimal SMTP server recording what it receives.
+type smtpStub struct {
+	listener net.Listener
+	tls      *tls.Config
+	startTLS bool
+
+	mu       sync.Mutex
+	auth     string
+	from     string
+	rcpt     []string
+	data     string
+	upgraded bool
+}
+
+// newSMTPStub listens on 127.0.0.1, with implicit TLS when implicit is set, offering STARTTLS when startTLS is set.
+func newSMTPStub(t *testing.T, cert tls.Certificate, implicit, startTLS bool) *smtpStub {
+	s := &smtpStub{tls: &tls.Config{Certificates: []tls.Certificate{cert}}, startTLS: startTLS}
+	var err error
+	if implicit {
+		s.listener, err = tls.Listen("tcp", "127.0.0.1:0", s.tls)
+	} else {
+		s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.listener.Close() })
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStub) port() int32 {
+	return int32(s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 stub ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		s.mu.Lock()
+		switch command {
+		case "EHLO", "HELO":
+			if s.startTLS && !s.upgraded {
+				reply("250-stub")
+				reply("250-STARTTLS")
+			} else {
+				reply("250-stub")
+			}
+			reply("250 AUTH PLAIN LOGIN")
+		case "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				s.mu.Unlock()
+				return
+			}
+			conn, reader, s.upgraded = tlsConn, bufio.NewReader(tlsConn), true
+		case "AUTH":
+			fields := strings.Fields(line)
+			if len(fields) == 3 {
+				decoded, _ := base64.StdEncoding.DecodeString(fields[2])
+				s.auth = string(decoded)
+			}
+			reply("235 authenticated")
+		case "MAIL":
+			s.from = line
+			reply("250 ok")
+		case "RCPT":
+			s.rcpt = append(s.rcpt, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := reader.ReadString('\n')
+				if err != nil {
+					s.mu.Unlock()
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			s.mu.Unlock()
+			return
+		default:
+			reply("502 not implemented")
+		}
+		s.mu.Unlock()
+	}
+}
+
+// testCertificate returns a self-signed certificate for 127.0.0.1 and its PEM.
+func testCertificate(t *testing.T) (tls.Certificate, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "smtp stub"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{"localhost"},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func testSecrets(caPEM []byte) *fake.ClientBuilder {
+	return fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kubeeye-system", Name: "smtp"},
+			Data:       map[string][]byte{"user": []byte("kubeeye"), "pass": []byte("s3cret")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kubeeye-system", Name: "legacy"},
+			Data:       map[string][]byte{"username": []byte("legacy"), "password": []byte("pw")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kubeeye-system", Name: "smtp-ca"},
+			Data:       map[string][]byte{"ca.crt": caPEM},
+		},
+	)
+}
+
+func TestEmailStartTLS(t *testing.T) {
+	t.Setenv("KUBERNETES_POD_NAMESPACE", "kubeeye-system")
+	cert, caPEM := testCertificate(t)
+	stub := newSMTPStub(t, cert, false, true)
+
+	event := testEvent()
+	event.Content = []byte("<p>report</p>")
+	event.Attachments = []conf.Attachment{{Name: "report.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4")}}
+	handler := NewEmailMessageOptions(&conf.EmailConfig{
+		Address:        "127.0.0.1",
+		Port:           stub.port(),
+		Fo:             "kubeeye@example.com",
+		To:             []string{"ops@example.com"},
+		Cc:             []string{"lead@example.com"},
+		Bcc:            []string{"audit@example.com", "ops@example.com"},
+		CredentialsRef: &conf.CredentialsRef{Name: "smtp", UsernameKey: "user", PasswordKey: "pass"},
+		CARef:          &conf.SecretKeyRef{Name: "smtp-ca"},
+		Subject:        "[KubeEye] {{ .Cluster }}: {{ .Total }} issues, score {{ .Score }}",
+	}, testSecrets(caPEM).Build())
+	if err := handler.Vail(); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.SendMsg(event); err != nil {
+		t.Fatal(err)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if !stub.upgraded {
+		t.Error("the connection was not upgraded with STARTTLS")
+	}
+	if stub.auth != "\x00kubeeye\x00s3cret" {
+		t.Errorf("auth = %q", stub.auth)
+	}
+	if strings.Join(stub.rcpt, ",") != "ops@example.com,lead@example.com,audit@example.com" {
+		t.Errorf("recipients = %v", stub.rcpt)
+	}
+	for _, want := range []string{
+		"To: ops@example.com\r\n",
+		"Cc: lead@example.com\r\n",
+		"Subject: [KubeEye] default: 2 issues, score 80\r\n",
+		"Content-Type: multipart/mixed; boundary=",
+		`Content-Disposition: attachment; filename=report.pdf`,
+		"<p>report</p>",
+	} {
+		if !strings.Contains(stub.data, want) {
+			t.Errorf("message misses %q:\n%s", want, stub.data)
+		}
+	}
+	if strings.Contains(stub.data, "audit@example.com") || strings.Contains(stub.data, "Bcc") {
+		t.Errorf("bcc recipients must not be in the headers:\n%s", stub.data)
+	}
+}
+
+func TestEmailImplicitTLS(t *testing.T) {
+	t.Setenv("KUBERNETES_POD_NAMESPACE", "kubeeye-system")
+	cert, caPEM := testCertificate(t)
+	stub := newSMTPStub(t, cert, true, false)
+	config := &conf.EmailConfig{
+		Address:   "127.0.0.1",
+		Port:      stub.port(),
+		Fo:        "kubeeye@example.com",
+		To:        []string{"ops@example.com"},
+		SecretKey: "legacy",
+		TLS:       conf.EmailTLS,
+	}
+
+	// the stub certificate is not trusted by the system roots
+	if err := NewEmailMessageOptions(config, testSecrets(caPEM).Build()).SendMsg(testEvent()); err == nil {
+		t.Fatal("expected a certificate verification error")
+	}
+
+	config.CARef = &conf.SecretKeyRef{Name: "smtp-ca"}
+	if err := NewEmailMessageOptions(config, testSecrets(caPEM).Build()).SendMsg(testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if stub.auth != "\x00legacy\x00pw" {
+		t.Errorf("auth = %q", stub.auth)
+	}
+	if !strings.Contains(stub.data, "Subject: =?utf-8?q?default") {
+		t.Errorf("the title should be the default subject:\n%s", stub.data)
+	}
+}
+
+func TestEmailStartTLSRequired(t *testing.T) {
+	t.Setenv("KUBERNETES_POD_NAMESPACE", "kubeeye-system")
+	cert, caPEM := testCertificate(t)
+	stub := newSMTPStub(t, cert, false, false)
+
+	err := NewEmailMessageOptions(&conf.EmailConfig{
+		Address: "127.0.0.1",
+		Port:    stub.port(),
+		Fo:      "kubeeye@example.com",
+		To:      []string{"ops@example.com"},
+		TLS:     conf.EmailStartTLS,
+	}, testSecrets(caPEM).Build()).SendMsg(testEvent())
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("expected STARTTLS to be required, got %v", err)
+	}
+}
+
+func TestTitle(t *testing.T) {
+	if got := Title("", "host", 3); got != "host集群巡检完成,共发现3个问题" {
+		t.Errorf("zh title = %s", got)
+	}
+	if got := Title("en", "host", 3); got != "KubeEye inspection of cluster host completed, 3 issues found" {
+		t.Errorf("en title = %s", got)
+	}
+}
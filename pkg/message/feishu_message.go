@@ -0,0 +1,83 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+)
+
+// FeishuMessageHandler posts the summary to a Feishu robot as an interactive card, signed when the robot has a sign secret.
+type FeishuMessageHandler struct {
+	chatBot
+}
+
+func NewFeishuMessageHandler(config *conf.ChatConfig, c client.Client) *FeishuMessageHandler {
+	return &FeishuMessageHandler{chatBot: newChatBot(config, c)}
+}
+
+func (f *FeishuMessageHandler) HandleMessageEvent(event *conf.MessageEvent) error {
+	if err := f.Send(context.TODO(), event); err != nil {
+		klog.Error("failed to send feishu message, err: ", err)
+		return err
+	}
+	klog.Info("send feishu message success")
+	return nil
+}
+
+func (f *FeishuMessageHandler) Send(ctx context.Context, event *conf.MessageEvent) error {
+	secret, err := f.secret(ctx)
+	if err != nil {
+		return err
+	}
+	template := "green"
+	if event.Summary != nil && event.Summary.Levels["danger"] > 0 {
+		template = "red"
+	} else if event.Summary != nil && event.Summary.Total > 0 {
+		template = "orange"
+	}
+	elements := []interface{}{
+		map[string]interface{}{"tag": "markdown", "content": chatMarkdown(&conf.MessageEvent{Summary: event.Summary}, f.findings(event))},
+	}
+	if event.ReportURL != "" {
+		elements = append(elements, map[string]interface{}{
+			"tag": "action",
+			"actions": []interface{}{
+				map[string]interface{}{
+					"tag":  "button",
+					"text": map[string]interface{}{"tag": "plain_text", "content": "View report"},
+					"type": "primary",
+					"url":  event.ReportURL,
+				},
+			},
+		})
+	}
+	payload := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"header": map[string]interface{}{
+				"title":    map[string]interface{}{"tag": "plain_text", "content": event.Title},
+				"template": template,
+			},
+			"elements": elements,
+		},
+	}
+	if secret != "" {
+		timestamp, sign := feishuSign(secret, time.Now())
+		payload["timestamp"] = timestamp
+		payload["sign"] = sign
+	}
+	body, err := f.post(ctx, f.URL, payload)
+	if err != nil {
+		return err
+	}
+	return chatError(body)
+}
+
+// feishuSign signs the timestamp in seconds: an HMAC-SHA256 of an empty message keyed with "timestamp\nsecret".
+func feishuSign(secret string, now time.Time) (string, string) {
+	timestamp := fmt.Sprintf("%d", now.Unix())
+	return timestamp, hmacBase64(timestamp+"\n"+secret, "")
+}
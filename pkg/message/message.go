@@ -1,6 +1,9 @@
 package message
 
-import "github.com/kubesphere/kubeeye/pkg/conf"
+import (
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
 
 type EventDispatcher struct {
 	handlers conf.EventHandler
@@ -12,6 +15,38 @@ func RegisterHandler(handler conf.EventHandler) *EventDispatcher {
 	}
 }
 
-func (d *EventDispatcher) DispatchMessageEvent(event *conf.MessageEvent) {
-	d.handlers.HandleMessageEvent(event)
+// DispatchMessageEvent sends the event, the error tells the caller the notification was not delivered.
+func (d *EventDispatcher) DispatchMessageEvent(event *conf.MessageEvent) error {
+	return d.handlers.HandleMessageEvent(event)
+}
+
+// NewChannelHandler returns the handler of the channel type, email when the type is empty.
+func NewChannelHandler(channel *conf.ChannelConfig, c client.Client) conf.EventHandler {
+	webhook := channel.Webhook
+	if webhook == nil {
+		webhook = &conf.WebhookConfig{}
+	}
+	switch channel.Type {
+	case conf.WebhookMessage:
+		return NewWebhookMessageHandler(webhook, c)
+	case conf.AlarmMessage:
+		return &AlarmMessageHandler{RequestUrl: webhook.URL}
+	case conf.SlackMessage:
+		return NewSlackMessageHandler(channel.Chat, c)
+	case conf.DingTalkMessage:
+		return NewDingTalkMessageHandler(channel.Chat, c)
+	case conf.WeComMessage:
+		return NewWeComMessageHandler(channel.Chat, c)
+	case conf.FeishuMessage:
+		return NewFeishuMessageHandler(channel.Chat, c)
+	case conf.TeamsMessage:
+		return NewTeamsMessageHandler(channel.Chat, c)
+	case conf.AlertmanagerMessage:
+		return NewAlertmanagerMessageHandler(channel.Alertmanager)
+	}
+	email := channel.Email
+	if email == nil {
+		email = &conf.EmailConfig{}
+	}
+	return NewEmailMessageOptions(email, c)
 }
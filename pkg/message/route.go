@@ -0,0 +1,132 @@
+package message
+
+import (
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"github.com/kubesphere/kubeeye/pkg/output"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+)
+
+// RouteInput is the result being routed.
+type RouteInput struct {
+	Plan       string
+	PlanLabels map[string]string
+	Cluster    string
+	// Findings of the result, the most severe first
+	Findings []conf.MessageFinding
+	// Previous holds the findings of the previous result of the same plan and cluster, nil when there is none
+	Previous []conf.MessageFinding
+}
+
+// Delivery is a receiver and the findings routed to it.
+type Delivery struct {
+	Receiver string
+	Findings []conf.MessageFinding
+}
+
+// Route evaluates the routes against the result, a receiver reached by several routes gets the union of their findings.
+// A route that filters findings matches only when at least one finding is kept.
+func Route(routes []conf.RouteConfig, input *RouteInput) []Delivery {
+	var deliveries []Delivery
+	index := map[string]int{}
+	seen := map[string]map[string]bool{}
+	add := func(receiver string, findings []conf.MessageFinding) {
+		i, ok := index[receiver]
+		if !ok {
+			i = len(deliveries)
+			index[receiver] = i
+			deliveries = append(deliveries, Delivery{Receiver: receiver})
+			seen[receiver] = map[string]bool{}
+		}
+		for _, f := range findings {
+			if key := findingKey(f); !seen[receiver][key] {
+				seen[receiver][key] = true
+				deliveries[i].Findings = append(deliveries[i].Findings, f)
+			}
+		}
+	}
+	matchRoutes(routes, input, input.Findings, add)
+	return deliveries
+}
+
+func matchRoutes(routes []conf.RouteConfig, input *RouteInput, findings []conf.MessageFinding, add func(string, []conf.MessageFinding)) bool {
+	matched := false
+	for i := range routes {
+		route := &routes[i]
+		selected, ok := matchRoute(route, input, findings)
+		if !ok {
+			continue
+		}
+		matched = true
+		if !matchRoutes(route.Routes, input, selected, add) {
+			for _, receiver := range route.Receivers {
+				add(receiver, selected)
+			}
+		}
+		if !route.Continue {
+			break
+		}
+	}
+	return matched
+}
+
+// matchRoute returns the findings kept by the route and whether it matches.
+func matchRoute(route *conf.RouteConfig, input *RouteInput, findings []conf.MessageFinding) ([]conf.MessageFinding, bool) {
+	if len(route.Plans) > 0 && !contains(route.Plans, input.Plan) {
+		return nil, false
+	}
+	if route.PlanSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(route.PlanSelector)
+		if err != nil {
+			klog.Error("invalid route plan selector, err: ", err)
+			return nil, false
+		}
+		if !selector.Matches(labels.Set(input.PlanLabels)) {
+			return nil, false
+		}
+	}
+	if len(route.Clusters) > 0 && !contains(route.Clusters, input.Cluster) {
+		return nil, false
+	}
+	if route.MinLevel == "" && len(route.RuleTypes) == 0 && !route.NewFindingsOnly {
+		return findings, true
+	}
+
+	var previous map[string]bool
+	if route.NewFindingsOnly {
+		previous = map[string]bool{}
+		for _, f := range input.Previous {
+			previous[findingKey(f)] = true
+		}
+	}
+	var selected []conf.MessageFinding
+	for _, f := range findings {
+		if route.MinLevel != "" && output.LevelRank(f.Level) < output.LevelRank(route.MinLevel) {
+			continue
+		}
+		if len(route.RuleTypes) > 0 && !contains(route.RuleTypes, f.RuleType) {
+			continue
+		}
+		if previous[findingKey(f)] {
+			continue
+		}
+		selected = append(selected, f)
+	}
+	return selected, len(selected) > 0
+}
+
+// findingKey identifies a finding across results, the message is left out as it may carry changing values.
+func findingKey(f conf.MessageFinding) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s", f.RuleType, f.Name, f.Level, f.Node, f.Namespace, f.Resource)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
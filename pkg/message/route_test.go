@@ -0,0 +1,165 @@
+package message
+
+import (
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+)
+
+func routeInput() *RouteInput {
+	return &RouteInput{
+		Plan:       "daily",
+		PlanLabels: map[string]string{"env": "prod"},
+		Cluster:    "default",
+		Findings: []conf.MessageFinding{
+			{RuleType: "opa", Name: "NoCPULimits", Level: "danger", Namespace: "default", Resource: "Deployment/nginx"},
+			{RuleType: "sysctl", Name: "net.ipv4.ip_forward", Level: "warning", Node: "node1"},
+			{RuleType: "opa", Name: "ImageTagIsLatest", Level: "ignore", Namespace: "default", Resource: "Deployment/nginx"},
+		},
+		Previous: []conf.MessageFinding{
+			{RuleType: "opa", Name: "NoCPULimits", Level: "danger", Namespace: "default", Resource: "Deployment/nginx", Message: "changed message"},
+		},
+	}
+}
+
+// deliveredNames maps each receiver to the names of the findings it gets, in order.
+func deliveredNames(deliveries []Delivery) map[string][]string {
+	names := map[string][]string{}
+	for _, d := range deliveries {
+		names[d.Receiver] = []string{}
+		for _, f := range d.Findings {
+			names[d.Receiver] = append(names[d.Receiver], f.Name)
+		}
+	}
+	return names
+}
+
+func TestRoute(t *testing.T) {
+	all := []string{"NoCPULimits", "net.ipv4.ip_forward", "ImageTagIsLatest"}
+	tests := []struct {
+		name   string
+		routes []conf.RouteConfig
+		want   map[string][]string
+	}{{
+		name:   "empty route matches everything",
+		routes: []conf.RouteConfig{{Receivers: []string{"ops"}}},
+		want:   map[string][]string{"ops": all},
+	}, {
+		name: "first match wins",
+		routes: []conf.RouteConfig{
+			{Receivers: []string{"ops"}},
+			{Receivers: []string{"dev"}},
+		},
+		want: map[string][]string{"ops": all},
+	}, {
+		name: "continue evaluates the next routes",
+		routes: []conf.RouteConfig{
+			{Receivers: []string{"ops"}, Continue: true},
+			{Receivers: []string{"dev"}},
+		},
+		want: map[string][]string{"ops": all, "dev": all},
+	}, {
+		name: "plans and clusters",
+		routes: []conf.RouteConfig{
+			{Receivers: []string{"weekly"}, Plans: []string{"weekly"}},
+			{Receivers: []string{"member"}, Clusters: []string{"member"}},
+			{Receivers: []string{"daily"}, Plans: []string{"daily"}, Clusters: []string{"default"}},
+		},
+		want: map[string][]string{"daily": all},
+	}, {
+		name: "plan selector",
+		routes: []conf.RouteConfig{
+			{Receivers: []string{"staging"}, PlanSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "staging"}}},
+			{Receivers: []string{"prod"}, PlanSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}},
+		},
+		want: map[string][]string{"prod": all},
+	}, {
+		name:   "min level",
+		routes: []conf.RouteConfig{{Receivers: []string{"ops"}, MinLevel: "warning"}},
+		want:   map[string][]string{"ops": {"NoCPULimits", "net.ipv4.ip_forward"}},
+	}, {
+		name:   "min level alias",
+		routes: []conf.RouteConfig{{Receivers: []string{"ops"}, MinLevel: "Critical"}},
+		want:   map[string][]string{"ops": {"NoCPULimits"}},
+	}, {
+		name:   "rule types",
+		routes: []conf.RouteConfig{{Receivers: []string{"ops"}, RuleTypes: []string{"opa"}}},
+		want:   map[string][]string{"ops": {"NoCPULimits", "ImageTagIsLatest"}},
+	}, {
+		name:   "new findings only ignores the message",
+		routes: []conf.RouteConfig{{Receivers: []string{"ops"}, NewFindingsOnly: true}},
+		want:   map[string][]string{"ops": {"net.ipv4.ip_forward", "ImageTagIsLatest"}},
+	}, {
+		name: "a route keeping no finding does not match",
+		routes: []conf.RouteConfig{
+			{Receivers: []string{"node"}, RuleTypes: []string{"nodeinfo"}},
+			{Receivers: []string{"ops"}, MinLevel: "danger"},
+		},
+		want: map[string][]string{"ops": {"NoCPULimits"}},
+	}, {
+		name: "child routes take precedence",
+		routes: []conf.RouteConfig{{
+			Receivers: []string{"ops"},
+			Routes: []conf.RouteConfig{
+				{Receivers: []string{"security"}, RuleTypes: []string{"opa"}},
+			},
+		}},
+		want: map[string][]string{"security": {"NoCPULimits", "ImageTagIsLatest"}},
+	}, {
+		name: "parent receivers when no child matches",
+		routes: []conf.RouteConfig{{
+			Receivers: []string{"ops"},
+			MinLevel:  "warning",
+			Routes: []conf.RouteConfig{
+				{Receivers: []string{"security"}, Clusters: []string{"member"}},
+			},
+		}},
+		want: map[string][]string{"ops": {"NoCPULimits", "net.ipv4.ip_forward"}},
+	}, {
+		name: "child routes filter the findings of the parent",
+		routes: []conf.RouteConfig{{
+			MinLevel: "warning",
+			Routes: []conf.RouteConfig{
+				{Receivers: []string{"security"}, RuleTypes: []string{"opa"}},
+			},
+		}},
+		want: map[string][]string{"security": {"NoCPULimits"}},
+	}, {
+		name: "a receiver gets the union of its routes",
+		routes: []conf.RouteConfig{
+			{Receivers: []string{"ops", "security"}, RuleTypes: []string{"opa"}, Continue: true},
+			{Receivers: []string{"ops"}, MinLevel: "warning"},
+		},
+		want: map[string][]string{
+			"ops":      {"NoCPULimits", "ImageTagIsLatest", "net.ipv4.ip_forward"},
+			"security": {"NoCPULimits", "ImageTagIsLatest"},
+		},
+	}, {
+		name:   "no route matches",
+		routes: []conf.RouteConfig{{Receivers: []string{"ops"}, Plans: []string{"weekly"}}},
+		want:   map[string][]string{},
+	}, {
+		name:   "invalid plan selector does not match",
+		routes: []conf.RouteConfig{{Receivers: []string{"ops"}, PlanSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: "Bogus"}}}}},
+		want:   map[string][]string{},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deliveredNames(Route(tt.routes, routeInput())); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Route() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteReceiverOrder(t *testing.T) {
+	routes := []conf.RouteConfig{
+		{Receivers: []string{"b"}, Continue: true},
+		{Receivers: []string{"a", "b"}},
+	}
+	deliveries := Route(routes, routeInput())
+	if len(deliveries) != 2 || deliveries[0].Receiver != "b" || deliveries[1].Receiver != "a" {
+		t.Errorf("receivers should be in the order they are reached: %v", deliveries)
+	}
+}
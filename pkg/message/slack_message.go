@@ -0,0 +1,81 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// SlackMessageHandler posts the summary to a Slack incoming webhook as Block Kit blocks.
+type SlackMessageHandler struct {
+	chatBot
+}
+
+func NewSlackMessageHandler(config *conf.ChatConfig, c client.Client) *SlackMessageHandler {
+	return &SlackMessageHandler{chatBot: newChatBot(config, c)}
+}
+
+func (s *SlackMessageHandler) HandleMessageEvent(event *conf.MessageEvent) error {
+	if err := s.Send(context.TODO(), event); err != nil {
+		klog.Error("failed to send slack message, err: ", err)
+		return err
+	}
+	klog.Info("send slack message success")
+	return nil
+}
+
+func (s *SlackMessageHandler) Send(ctx context.Context, event *conf.MessageEvent) error {
+	_, err := s.post(ctx, s.URL, s.payload(event))
+	return err
+}
+
+func (s *SlackMessageHandler) payload(event *conf.MessageEvent) map[string]interface{} {
+	text := func(t string) map[string]interface{} {
+		return map[string]interface{}{"type": "mrkdwn", "text": t}
+	}
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": event.Title},
+		},
+	}
+	if summary := event.Summary; summary != nil {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"fields": []interface{}{
+				text("*Cluster*\n" + summary.Cluster),
+				text(fmt.Sprintf("*Score*\n%d", summary.Score)),
+				text(fmt.Sprintf("*Issues*\n%d", summary.Total)),
+				text("*Levels*\n" + levelCounts(summary)),
+			},
+		})
+	}
+	if findings := s.findings(event); len(findings) > 0 {
+		lines := make([]string, 0, len(findings))
+		for _, f := range findings {
+			lines = append(lines, "• "+slackEscape(findingText(f)))
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": text("*Top findings*\n" + strings.Join(lines, "\n")),
+		})
+	}
+	if event.ReportURL != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": text(fmt.Sprintf("<%s|View report>", event.ReportURL)),
+		})
+	}
+	return map[string]interface{}{
+		"text":   event.Title,
+		"blocks": blocks,
+	}
+}
+
+// slackEscape escapes the control characters of Slack mrkdwn.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,150 @@
+package message
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	corev1 "k8s.io/api/core/v1"
+	kubeErr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"time"
+)
+
+// NotificationStateName is the ConfigMap keeping the state of every receiver, one key per channel name.
+const NotificationStateName = "kubeeye-notification-state"
+
+// maxPendingDigest bounds the results kept for a digest, the oldest are dropped. Every channel shares the 1 MiB
+// NotificationStateName ConfigMap and an entry takes about 200 bytes.
+const maxPendingDigest = 200
+
+// ReceiverState is what a receiver was sent, used to deduplicate, throttle and collect digests.
+type ReceiverState struct {
+	LastSent time.Time `json:"lastSent,omitempty"`
+	// Fingerprints are the hashes of the finding sets last sent, by plan and cluster
+	Fingerprints map[string]string `json:"fingerprints,omitempty"`
+	// Pending are the results waiting for the next digest
+	Pending    []DigestEntry `json:"pending,omitempty"`
+	LastDigest time.Time     `json:"lastDigest,omitempty"`
+}
+
+// DigestEntry is a result collected for a digest.
+type DigestEntry struct {
+	Result  string         `json:"result"`
+	Cluster string         `json:"cluster,omitempty"`
+	Plan    string         `json:"plan,omitempty"`
+	Score   int            `json:"score"`
+	Total   int            `json:"total"`
+	Levels  map[string]int `json:"levels,omitempty"`
+	Time    time.Time      `json:"time"`
+}
+
+// StateStore reads and writes the receiver states in the NotificationStateName ConfigMap. The ConfigMap is read with
+// an uncached reader, a cached read lags behind the last write and would make every retry conflict again.
+type StateStore struct {
+	client.Client
+	Reader    client.Reader
+	Namespace string
+}
+
+func NewStateStore(c client.Client, reader client.Reader, namespace string) *StateStore {
+	return &StateStore{Client: c, Reader: reader, Namespace: namespace}
+}
+
+// Update applies fn to the state of the receiver and saves it, retrying on conflicts with concurrent updates.
+func (s *StateStore) Update(ctx context.Context, receiver string, fn func(state *ReceiverState) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var cm corev1.ConfigMap
+		err := s.Reader.Get(ctx, types.NamespacedName{Namespace: s.Namespace, Name: NotificationStateName}, &cm)
+		create := kubeErr.IsNotFound(err)
+		if err != nil && !create {
+			return err
+		}
+		if create {
+			cm = corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: s.Namespace, Name: NotificationStateName}}
+		}
+
+		state := &ReceiverState{}
+		previous, ok := cm.Data[receiver]
+		if ok {
+			if err = json.Unmarshal([]byte(previous), state); err != nil {
+				return err
+			}
+		}
+		if err = fn(state); err != nil {
+			return err
+		}
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if ok && string(data) == previous {
+			return nil
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[receiver] = string(data)
+		if create {
+			return s.Create(ctx, &cm)
+		}
+		return s.Client.Update(ctx, &cm)
+	})
+}
+
+// AddPending queues results for the next digest in time order, keeping the latest maxPendingDigest.
+func (s *ReceiverState) AddPending(entries ...DigestEntry) {
+	s.Pending = append(s.Pending, entries...)
+	sort.SliceStable(s.Pending, func(i, j int) bool { return s.Pending[i].Time.Before(s.Pending[j].Time) })
+	if len(s.Pending) > maxPendingDigest {
+		s.Pending = s.Pending[len(s.Pending)-maxPendingDigest:]
+	}
+}
+
+// Admit decides whether the findings of source (plan and cluster of a result) are sent to the channel now. A digest
+// channel only collects the entry in the state. reason tells why it is held back. A notification that was sent is
+// recorded with MarkSent, so a failed delivery does not count against the throttle.
+func Admit(state *ReceiverState, channel *conf.ChannelConfig, source string, entry DigestEntry, findings []conf.MessageFinding, now time.Time) (send bool, reason string) {
+	if channel.Digest != nil {
+		state.AddPending(entry)
+		return false, "collected for the digest"
+	}
+	if throttle := channel.Throttle; throttle != nil {
+		if throttle.SuppressUnchanged && state.Fingerprints[source] == FindingsFingerprint(findings) {
+			return false, "findings are unchanged"
+		}
+		if interval := parseDuration(throttle.MinInterval, 0); interval > 0 && now.Sub(state.LastSent) < interval {
+			return false, "rate limited"
+		}
+	}
+	return true, ""
+}
+
+// MarkSent records the findings of source as sent to the channel.
+func MarkSent(state *ReceiverState, source string, findings []conf.MessageFinding, now time.Time) {
+	state.LastSent = now
+	if state.Fingerprints == nil {
+		state.Fingerprints = map[string]string{}
+	}
+	state.Fingerprints[source] = FindingsFingerprint(findings)
+}
+
+// FindingsFingerprint hashes the set of findings, their order and messages are ignored.
+func FindingsFingerprint(findings []conf.MessageFinding) string {
+	keys := make([]string, 0, len(findings))
+	for _, f := range findings {
+		keys = append(keys, findingKey(f))
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,207 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+	"time"
+)
+
+func TestAdmit(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	findings := testEvent().Findings
+	changed := findings[:1]
+	type step struct {
+		after    time.Duration
+		findings []conf.MessageFinding
+		source   string
+		// failed is a notification that was admitted but not delivered
+		failed bool
+		want   bool
+		reason string
+	}
+	tests := []struct {
+		name    string
+		channel conf.ChannelConfig
+		steps   []step
+	}{{
+		name:    "no throttle",
+		channel: conf.ChannelConfig{},
+		steps: []step{
+			{findings: findings, want: true},
+			{findings: findings, want: true},
+		},
+	}, {
+		name:    "suppress unchanged",
+		channel: conf.ChannelConfig{Throttle: &conf.ThrottleConfig{SuppressUnchanged: true}},
+		steps: []step{
+			{findings: findings, want: true},
+			{after: time.Minute, findings: []conf.MessageFinding{findings[1], findings[0]}, want: false, reason: "findings are unchanged"},
+			{after: 2 * time.Minute, findings: changed, want: true},
+			{after: 3 * time.Minute, findings: findings, source: "weekly/default", want: true},
+			{after: 4 * time.Minute, findings: findings, want: true},
+		},
+	}, {
+		name:    "min interval",
+		channel: conf.ChannelConfig{Throttle: &conf.ThrottleConfig{MinInterval: "1h"}},
+		steps: []step{
+			{findings: findings, want: true},
+			{after: 30 * time.Minute, findings: changed, want: false, reason: "rate limited"},
+			{after: time.Hour, findings: changed, want: true},
+			{after: 90 * time.Minute, findings: findings, source: "weekly/default", want: false, reason: "rate limited"},
+		},
+	}, {
+		name:    "a failed delivery is not recorded",
+		channel: conf.ChannelConfig{Throttle: &conf.ThrottleConfig{SuppressUnchanged: true, MinInterval: "1h"}},
+		steps: []step{
+			{findings: findings, want: true, failed: true},
+			{after: time.Minute, findings: findings, want: true},
+			{after: 2 * time.Minute, findings: changed, want: false, reason: "rate limited"},
+			{after: 2 * time.Hour, findings: findings, want: false, reason: "findings are unchanged"},
+		},
+	}, {
+		name:    "digest collects every result",
+		channel: conf.ChannelConfig{Digest: &conf.DigestConfig{Schedule: "@daily"}, Throttle: &conf.ThrottleConfig{SuppressUnchanged: true}},
+		steps: []step{
+			{findings: findings, want: false, reason: "collected for the digest"},
+			{after: time.Minute, findings: findings, want: false, reason: "collected for the digest"},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &ReceiverState{}
+			for i, s := range tt.steps {
+				source := s.source
+				if source == "" {
+					source = "daily/default"
+				}
+				send, reason := Admit(state, &tt.channel, source, DigestEntry{Result: fmt.Sprintf("result-%d", i)}, s.findings, start.Add(s.after))
+				if send != s.want || reason != s.reason {
+					t.Errorf("step %d: Admit() = %v, %q, want %v, %q", i, send, reason, s.want, s.reason)
+				}
+				if send && !s.failed {
+					MarkSent(state, source, s.findings, start.Add(s.after))
+				}
+			}
+			if tt.channel.Digest != nil {
+				if len(state.Pending) != len(tt.steps) || !state.LastSent.IsZero() {
+					t.Errorf("digest state = %+v, want %d pending results and nothing sent", state, len(tt.steps))
+				}
+			} else if len(state.Pending) != 0 {
+				t.Errorf("only a digest channel collects results: %+v", state.Pending)
+			}
+		})
+	}
+}
+
+func TestAddPending(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(i int) DigestEntry {
+		return DigestEntry{Result: fmt.Sprintf("result-%d", i), Time: start.Add(time.Duration(i) * time.Minute)}
+	}
+	tests := []struct {
+		name    string
+		pending []DigestEntry
+		add     []DigestEntry
+		want    []string
+	}{{
+		name: "append",
+		add:  []DigestEntry{entry(1), entry(2)},
+		want: []string{"result-1", "result-2"},
+	}, {
+		name:    "restored entries go before the newer ones",
+		pending: []DigestEntry{entry(3)},
+		add:     []DigestEntry{entry(1), entry(2)},
+		want:    []string{"result-1", "result-2", "result-3"},
+	}, {
+		name:    "same time keeps the order",
+		pending: []DigestEntry{entry(1)},
+		add:     []DigestEntry{{Result: "other", Time: entry(1).Time}},
+		want:    []string{"result-1", "other"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &ReceiverState{Pending: tt.pending}
+			state.AddPending(tt.add...)
+			var got []string
+			for _, e := range state.Pending {
+				got = append(got, e.Result)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pending = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	state := &ReceiverState{}
+	for i := 0; i < maxPendingDigest+5; i++ {
+		state.AddPending(entry(i))
+	}
+	if len(state.Pending) != maxPendingDigest || state.Pending[0].Result != "result-5" {
+		t.Errorf("pending = %d from %s, want the latest %d", len(state.Pending), state.Pending[0].Result, maxPendingDigest)
+	}
+}
+
+func TestFindingsFingerprint(t *testing.T) {
+	findings := testEvent().Findings
+	reordered := []conf.MessageFinding{findings[1], findings[0]}
+	reordered[1].Message = "another message"
+	if FindingsFingerprint(findings) != FindingsFingerprint(reordered) {
+		t.Error("the fingerprint should ignore the order and the messages of the findings")
+	}
+	if FindingsFingerprint(findings) == FindingsFingerprint(findings[:1]) {
+		t.Error("the fingerprint should change with the findings")
+	}
+}
+
+func TestStateStoreUpdate(t *testing.T) {
+	c := fake.NewClientBuilder().Build()
+	store := NewStateStore(c, c, "kubeeye-system")
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < maxPendingDigest+2; i++ {
+		err := store.Update(ctx, "digest", func(state *ReceiverState) error {
+			state.AddPending(DigestEntry{Result: fmt.Sprintf("result-%d", i), Time: now})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Update(ctx, "ops", func(state *ReceiverState) error {
+		state.LastSent = now
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var cm corev1.ConfigMap
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "kubeeye-system", Name: NotificationStateName}, &cm); err != nil {
+		t.Fatal(err)
+	}
+	if len(cm.Data) != 2 {
+		t.Errorf("state keys = %v, want one per receiver", cm.Data)
+	}
+	version := cm.ResourceVersion
+	err := store.Update(ctx, "digest", func(state *ReceiverState) error {
+		if len(state.Pending) != maxPendingDigest || state.Pending[0].Result != "result-2" {
+			t.Errorf("pending = %d from %s, want the latest %d", len(state.Pending), state.Pending[0].Result, maxPendingDigest)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Get(ctx, types.NamespacedName{Namespace: "kubeeye-system", Name: NotificationStateName}, &cm); err != nil {
+		t.Fatal(err)
+	}
+	if cm.ResourceVersion != version {
+		t.Error("an unchanged state should not be written")
+	}
+}
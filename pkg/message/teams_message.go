@@ -0,0 +1,72 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TeamsMessageHandler posts the summary to a Microsoft Teams incoming webhook as an adaptive card.
+type TeamsMessageHandler struct {
+	chatBot
+}
+
+func NewTeamsMessageHandler(config *conf.ChatConfig, c client.Client) *TeamsMessageHandler {
+	return &TeamsMessageHandler{chatBot: newChatBot(config, c)}
+}
+
+func (t *TeamsMessageHandler) HandleMessageEvent(event *conf.MessageEvent) error {
+	if err := t.Send(context.TODO(), event); err != nil {
+		klog.Error("failed to send teams message, err: ", err)
+		return err
+	}
+	klog.Info("send teams message success")
+	return nil
+}
+
+func (t *TeamsMessageHandler) Send(ctx context.Context, event *conf.MessageEvent) error {
+	_, err := t.post(ctx, t.URL, t.payload(event))
+	return err
+}
+
+func (t *TeamsMessageHandler) payload(event *conf.MessageEvent) map[string]interface{} {
+	body := []interface{}{
+		map[string]interface{}{"type": "TextBlock", "text": event.Title, "size": "Medium", "weight": "Bolder", "wrap": true},
+	}
+	if summary := event.Summary; summary != nil {
+		facts := []interface{}{
+			map[string]interface{}{"title": "Cluster", "value": summary.Cluster},
+			map[string]interface{}{"title": "Score", "value": fmt.Sprintf("%d", summary.Score)},
+			map[string]interface{}{"title": "Issues", "value": fmt.Sprintf("%d", summary.Total)},
+		}
+		for _, level := range chatLevels {
+			facts = append(facts, map[string]interface{}{"title": level, "value": fmt.Sprintf("%d", summary.Levels[level])})
+		}
+		body = append(body, map[string]interface{}{"type": "FactSet", "facts": facts})
+	}
+	for _, f := range t.findings(event) {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": "- " + findingText(f), "wrap": true, "spacing": "None"})
+	}
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if event.ReportURL != "" {
+		card["actions"] = []interface{}{
+			map[string]interface{}{"type": "Action.OpenUrl", "title": "View report", "url": event.ReportURL},
+		}
+	}
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{
+			map[string]interface{}{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content":     card,
+			},
+		},
+	}
+}
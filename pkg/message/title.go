@@ -0,0 +1,87 @@
+package message
+
+import (
+	"bytes"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"text/template"
+	"time"
+)
+
+// defaultTitles are the notification titles by language, executed with TitleData.
+var defaultTitles = map[string]string{
+	"zh": "{{ .Cluster }}集群巡检完成,共发现{{ .Total }}个问题",
+	"en": "KubeEye inspection of cluster {{ .Cluster }} completed, {{ .Total }} issues found",
+}
+
+// digestTitles are the digest titles by language, executed with DigestTitleData.
+var digestTitles = map[string]string{
+	"zh": "KubeEye巡检汇总: {{ .Since }}以来共{{ .Inspections }}次巡检",
+	"en": "KubeEye digest: {{ .Inspections }} inspections since {{ .Since }}",
+}
+
+// DigestTitleData is the data of the digest titles.
+type DigestTitleData struct {
+	Inspections int
+	Since       string
+}
+
+// TitleData is the data of the title and subject templates.
+type TitleData struct {
+	Title   string
+	Cluster string
+	Plan    string
+	Score   int
+	Total   int
+	Levels  map[string]int
+}
+
+func NewTitleData(title string, summary *conf.MessageSummary) TitleData {
+	data := TitleData{Title: title, Levels: map[string]int{}}
+	if summary != nil {
+		data.Cluster = summary.Cluster
+		data.Plan = summary.Plan
+		data.Score = summary.Score
+		data.Total = summary.Total
+		data.Levels = summary.Levels
+	}
+	return data
+}
+
+// Title returns the notification title of a result in the language, zh when it is empty or unknown.
+func Title(language string, cluster string, issues int) string {
+	text, ok := defaultTitles[language]
+	if !ok {
+		text = defaultTitles["zh"]
+	}
+	title, err := RenderTitle(text, TitleData{Cluster: cluster, Total: issues})
+	if err != nil {
+		return text
+	}
+	return title
+}
+
+// DigestTitle returns the title of a digest in the language, zh when it is empty or unknown.
+func DigestTitle(language string, inspections int, since time.Time) string {
+	text, ok := digestTitles[language]
+	if !ok {
+		text = digestTitles["zh"]
+	}
+	title, err := RenderTitle(text, DigestTitleData{Inspections: inspections, Since: since.Format("2006-01-02 15:04")})
+	if err != nil {
+		return text
+	}
+	return title
+}
+
+// RenderTitle executes a title template with TitleData or DigestTitleData.
+func RenderTitle(text string, data interface{}) (string, error) {
+	tmpl, err := template.New("title").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	buffer := &bytes.Buffer{}
+	if err = tmpl.Execute(buffer, data); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
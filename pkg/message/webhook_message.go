@@ -0,0 +1,216 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"net/http"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the hex HMAC-SHA256 of the request body, prefixed with sha256=
+	SignatureHeader = "X-Kubeeye-Signature"
+
+	defaultWebhookTimeout = 10 * time.Second
+	defaultWebhookRetries = 3
+	defaultWebhookBackoff = time.Second
+	// webhookTopFindings is the number of findings sent, the most severe first
+	webhookTopFindings = 10
+)
+
+type WebhookMessageHandler struct {
+	*conf.WebhookConfig
+	client.Client
+
+	// key is the signing key, read from the Secret on first use
+	key []byte
+}
+
+func NewWebhookMessageHandler(config *conf.WebhookConfig, c client.Client) *WebhookMessageHandler {
+	return &WebhookMessageHandler{
+		WebhookConfig: config,
+		Client:        c,
+	}
+}
+
+func (w *WebhookMessageHandler) HandleMessageEvent(event *conf.MessageEvent) error {
+	if err := w.Send(context.TODO(), event); err != nil {
+		klog.Error("failed to send webhook, err: ", err)
+		return err
+	}
+	klog.Info("send webhook success")
+	return nil
+}
+
+// webhookData is the data of the body template.
+type webhookData struct {
+	Title     string
+	Timestamp time.Time
+	Summary   *conf.MessageSummary
+	Findings  []conf.MessageFinding
+	// Content is the rendered report, in the notification format of the message config
+	Content string
+}
+
+var webhookFuncs = template.FuncMap{
+	// json encodes a value, strings included, so it can be placed in a JSON body as is
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  func(sep string, s []string) string { return strings.Join(s, sep) },
+}
+
+// Send renders the body and sends it, retrying with backoff on connection errors, 429 and 5xx responses.
+func (w *WebhookMessageHandler) Send(ctx context.Context, event *conf.MessageEvent) error {
+	if w.URL == "" {
+		return errors.New("webhook url is empty")
+	}
+	body, err := w.body(event)
+	if err != nil {
+		return err
+	}
+	signature, err := w.sign(ctx, body)
+	if err != nil {
+		return err
+	}
+
+	timeout := parseDuration(w.Timeout, defaultWebhookTimeout)
+	backoff := parseDuration(w.Backoff, defaultWebhookBackoff)
+	retries := defaultWebhookRetries
+	if w.Retries != nil {
+		retries = *w.Retries
+	}
+	httpClient := &http.Client{Timeout: timeout}
+
+	for attempt := 0; ; attempt++ {
+		retry, err := w.do(ctx, httpClient, body, signature)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= retries {
+			return err
+		}
+		klog.Warningf("webhook attempt %d failed, retry in %s, err: %s", attempt+1, backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// do sends one request, retry tells whether a failure may succeed when sent again.
+func (w *WebhookMessageHandler) do(ctx context.Context, httpClient *http.Client, body []byte, signature string) (retry bool, err error) {
+	method := w.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	request, err := http.NewRequestWithContext(ctx, method, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		request.Header.Set(k, v)
+	}
+	if signature != "" {
+		request.Header.Set(SignatureHeader, signature)
+	}
+
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("webhook responded %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+func (w *WebhookMessageHandler) body(event *conf.MessageEvent) ([]byte, error) {
+	data := webhookData{
+		Title:     event.Title,
+		Timestamp: event.Timestamp,
+		Summary:   event.Summary,
+		Findings:  event.Findings,
+		Content:   string(event.Content),
+	}
+	if len(data.Findings) > webhookTopFindings {
+		data.Findings = data.Findings[:webhookTopFindings]
+	}
+	if w.Body == "" {
+		return json.Marshal(struct {
+			Title     string                `json:"title"`
+			Timestamp time.Time             `json:"timestamp"`
+			Summary   *conf.MessageSummary  `json:"summary,omitempty"`
+			Findings  []conf.MessageFinding `json:"findings,omitempty"`
+		}{data.Title, data.Timestamp, data.Summary, data.Findings})
+	}
+	tmpl, err := template.New("webhook").Funcs(webhookFuncs).Parse(w.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook body template: %s", err)
+	}
+	buffer := &bytes.Buffer{}
+	if err = tmpl.Execute(buffer, data); err != nil {
+		return nil, fmt.Errorf("failed to render webhook body: %s", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// sign returns the signature header value of the body, empty when no signing key is configured.
+func (w *WebhookMessageHandler) sign(ctx context.Context, body []byte) (string, error) {
+	if w.key == nil && w.SecretKey != "" {
+		var secret corev1.Secret
+		err := w.Client.Get(ctx, types.NamespacedName{
+			Namespace: os.Getenv("KUBERNETES_POD_NAMESPACE"),
+			Name:      w.SecretKey,
+		}, &secret)
+		if err != nil {
+			return "", err
+		}
+		if len(secret.Data["hmacKey"]) == 0 {
+			return "", fmt.Errorf("secret %s has no hmacKey", w.SecretKey)
+		}
+		w.key = secret.Data["hmacKey"]
+	}
+	if len(w.key) == 0 {
+		return "", nil
+	}
+	mac := hmac.New(sha256.New, w.key)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func parseDuration(value string, defaultValue time.Duration) time.Duration {
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		klog.Warningf("invalid duration %s, use %s", value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
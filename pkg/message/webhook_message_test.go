@@ -0,0 +1,182 @@
+package message
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testEvent() *conf.MessageEvent {
+	return &conf.MessageEvent{
+		Title:     "default集群巡检完成,共发现2个问题",
+		Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Summary: &conf.MessageSummary{
+			Cluster: "default",
+			Result:  "default-task-result",
+			Score:   80,
+			Total:   2,
+			Levels:  map[string]int{"danger": 1, "warning": 1, "ignore": 0},
+		},
+		Findings: []conf.MessageFinding{
+			{RuleType: "opa", Name: "NoCPULimits", Level: "danger", Namespace: "default", Resource: "Deployment/nginx", Message: `"cpu" limit is not set`},
+			{RuleType: "sysctl", Name: "net.ipv4.ip_forward", Level: "warning", Node: "node1"},
+		},
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestWebhookTemplateHeadersAndSignature(t *testing.T) {
+	var received []byte
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("method = %s, want PUT", r.Method)
+		}
+		headers = r.Header
+		received, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	handler := NewWebhookMessageHandler(&conf.WebhookConfig{
+		URL:     server.URL,
+		Method:  http.MethodPut,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		Body:    `{"text": {{ json .Title }}, "score": {{ .Summary.Score }}, "first": {{ json (index .Findings 0).Message }}}`,
+	}, nil)
+	handler.key = []byte("secret")
+
+	if err := handler.Send(context.Background(), testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(received, &body); err != nil {
+		t.Fatalf("body is not valid json: %s: %s", err, received)
+	}
+	if body["text"] != "default集群巡检完成,共发现2个问题" || body["score"] != 80.0 || body["first"] != `"cpu" limit is not set` {
+		t.Errorf("unexpected body %s", received)
+	}
+	if headers.Get("Authorization") != "Bearer token" || headers.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers %v", headers)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(received)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); headers.Get(SignatureHeader) != want {
+		t.Errorf("signature = %s, want %s", headers.Get(SignatureHeader), want)
+	}
+}
+
+func TestWebhookDefaultBody(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(SignatureHeader) != "" {
+			t.Error("body should not be signed without a key")
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	if err := NewWebhookMessageHandler(&conf.WebhookConfig{URL: server.URL}, nil).Send(context.Background(), testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	summary, ok := received["summary"].(map[string]interface{})
+	if !ok || summary["cluster"] != "default" || len(received["findings"].([]interface{})) != 2 {
+		t.Errorf("unexpected default body %v", received)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	handler := NewWebhookMessageHandler(&conf.WebhookConfig{URL: server.URL, Backoff: "1ms"}, nil)
+	if err := handler.Send(context.Background(), testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	handler.Retries = intPtr(1)
+	if err := handler.Send(context.Background(), testEvent()); err == nil {
+		t.Error("expected an error when the retries are exhausted")
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestWebhookNoRetryOnClientError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "bad payload", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := NewWebhookMessageHandler(&conf.WebhookConfig{URL: server.URL, Backoff: "1ms"}, nil).Send(context.Background(), testEvent())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDispatchReturnsSendError(t *testing.T) {
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	for _, channelType := range []conf.MessageType{conf.WebhookMessage, conf.AlarmMessage} {
+		channel := &conf.ChannelConfig{Type: channelType, Webhook: &conf.WebhookConfig{URL: server.URL, Retries: intPtr(0)}}
+		status = http.StatusInternalServerError
+		if err := RegisterHandler(NewChannelHandler(channel, nil)).DispatchMessageEvent(testEvent()); err == nil {
+			t.Errorf("%s: expected the send error", channelType)
+		}
+		status = http.StatusOK
+		if err := RegisterHandler(NewChannelHandler(channel, nil)).DispatchMessageEvent(testEvent()); err != nil {
+			t.Errorf("%s: unexpected error %s", channelType, err)
+		}
+	}
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	start := time.Now()
+	err := NewWebhookMessageHandler(&conf.WebhookConfig{URL: server.URL, Timeout: "50ms", Retries: intPtr(0)}, nil).Send(context.Background(), testEvent())
+	if err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("request was not cancelled after the timeout")
+	}
+}
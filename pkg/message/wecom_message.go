@@ -0,0 +1,41 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WeComMessageHandler posts the summary to a WeCom group robot as markdown.
+type WeComMessageHandler struct {
+	chatBot
+}
+
+func NewWeComMessageHandler(config *conf.ChatConfig, c client.Client) *WeComMessageHandler {
+	return &WeComMessageHandler{chatBot: newChatBot(config, c)}
+}
+
+func (w *WeComMessageHandler) HandleMessageEvent(event *conf.MessageEvent) error {
+	if err := w.Send(context.TODO(), event); err != nil {
+		klog.Error("failed to send wecom message, err: ", err)
+		return err
+	}
+	klog.Info("send wecom message success")
+	return nil
+}
+
+func (w *WeComMessageHandler) Send(ctx context.Context, event *conf.MessageEvent) error {
+	payload := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]interface{}{
+			"content": fmt.Sprintf("### %s\n%s", event.Title, chatMarkdown(event, w.findings(event))),
+		},
+	}
+	body, err := w.post(ctx, w.URL, payload)
+	if err != nil {
+		return err
+	}
+	return chatError(body)
+}
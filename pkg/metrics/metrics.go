@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	listersv1alpha2 "github.com/kubesphere/kubeeye/clients/listers/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	"github.com/kubesphere/kubeeye/pkg/output"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"path"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sync"
+	"time"
+)
+
+// JobFailures counts the inspect jobs that failed, it is increased by the task controller.
+var JobFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubeeye_job_failures_total",
+	Help: "Number of inspect jobs that failed.",
+}, []string{"cluster", "plan"})
+
+var (
+	findingsDesc = prometheus.NewDesc("kubeeye_findings",
+		"Number of findings of the latest inspect result.", []string{"cluster", "plan", "rule_type", "level"}, nil)
+	scoreDesc = prometheus.NewDesc("kubeeye_score",
+		"Score (0-100) of the latest inspect result.", []string{"cluster", "plan"}, nil)
+	durationDesc = prometheus.NewDesc("kubeeye_task_duration_seconds",
+		"Duration of the latest inspection.", []string{"cluster", "plan"}, nil)
+	statusDesc = prometheus.NewDesc("kubeeye_task_status",
+		"Status of the latest inspect task of the plan, 1 for the current status and 0 for the others.", []string{"plan", "status"}, nil)
+	lastSuccessDesc = prometheus.NewDesc("kubeeye_last_success_timestamp",
+		"Unix time of the latest inspection that did not fail.", []string{"cluster", "plan"}, nil)
+)
+
+var taskPhases = []v1alpha2.Phase{v1alpha2.PhasePending, v1alpha2.PhaseRunning, v1alpha2.PhaseSucceeded, v1alpha2.PhaseFailed, v1alpha2.PhasePartiallySucceeded, v1alpha2.PhaseCancelled}
+
+// resultTimeLayout is the layout of the start and end time annotations of results.
+const resultTimeLayout = "2006-01-02 15:04:05"
+
+// Register adds the kubeeye collectors to the controller-runtime registry served on the manager metrics address.
+func Register(results listersv1alpha2.InspectResultLister, tasks listersv1alpha2.InspectTaskLister) error {
+	if err := metrics.Registry.Register(NewResultCollector(results, tasks)); err != nil {
+		return err
+	}
+	return metrics.Registry.Register(JobFailures)
+}
+
+// ResultCollector exports the findings, score and timing of the latest InspectResult of every plan and cluster
+// and the status of the latest task of every plan. Results are read from the result files once and cached.
+type ResultCollector struct {
+	results listersv1alpha2.InspectResultLister
+	tasks   listersv1alpha2.InspectTaskLister
+	// resultDir holds the result files
+	resultDir string
+
+	mutex     sync.Mutex
+	summaries map[types.UID]*output.ReportData
+}
+
+func NewResultCollector(results listersv1alpha2.InspectResultLister, tasks listersv1alpha2.InspectTaskLister) *ResultCollector {
+	return &ResultCollector{
+		results:   results,
+		tasks:     tasks,
+		resultDir: constant.ResultPathPrefix,
+		summaries: map[types.UID]*output.ReportData{},
+	}
+}
+
+func (c *ResultCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- findingsDesc
+	ch <- scoreDesc
+	ch <- durationDesc
+	ch <- statusDesc
+	ch <- lastSuccessDesc
+}
+
+func (c *ResultCollector) Collect(ch chan<- prometheus.Metric) {
+	results, err := c.results.List(labels.Everything())
+	if err != nil {
+		klog.Error("failed to list inspect results for metrics, err:", err)
+		return
+	}
+	tasks, err := c.tasks.List(labels.Everything())
+	if err != nil {
+		klog.Error("failed to list inspect tasks for metrics, err:", err)
+		return
+	}
+
+	taskStatus := map[string]v1alpha2.Phase{}
+	latestTasks := map[string]*v1alpha2.InspectTask{}
+	for _, task := range tasks {
+		taskStatus[task.Name] = task.Status.Status
+		plan := task.Labels[constant.LabelPlanName]
+		if latest, exist := latestTasks[plan]; !exist || latest.CreationTimestamp.Before(&task.CreationTimestamp) {
+			latestTasks[plan] = task
+		}
+	}
+	for plan, task := range latestTasks {
+		for _, phase := range taskPhases {
+			value := 0.0
+			if task.Status.Status == phase {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(statusDesc, prometheus.GaugeValue, value, plan, string(phase))
+		}
+	}
+
+	type key struct{ cluster, plan string }
+	latest := map[key]*v1alpha2.InspectResult{}
+	lastSuccess := map[key]time.Time{}
+	for _, result := range results {
+		k := key{cluster: result.Spec.InspectCluster.Name, plan: result.Labels[constant.LabelPlanName]}
+		if current, exist := latest[k]; !exist || current.CreationTimestamp.Before(&result.CreationTimestamp) {
+			latest[k] = result
+		}
+		if taskStatus[result.Labels[constant.LabelTaskName]].IsFailed() {
+			continue
+		}
+		if end := resultEndTime(result); end.After(lastSuccess[k]) {
+			lastSuccess[k] = end
+		}
+	}
+
+	live := map[types.UID]bool{}
+	for k, result := range latest {
+		live[result.UID] = true
+		if end, exist := lastSuccess[k]; exist {
+			ch <- prometheus.MustNewConstMetric(lastSuccessDesc, prometheus.GaugeValue, float64(end.Unix()), k.cluster, k.plan)
+		}
+		if start, err := time.ParseInLocation(resultTimeLayout, result.Annotations[constant.AnnotationStartTime], time.Local); err == nil {
+			ch <- prometheus.MustNewConstMetric(durationDesc, prometheus.GaugeValue, resultEndTime(result).Sub(start).Seconds(), k.cluster, k.plan)
+		}
+
+		data := c.summary(result)
+		if data == nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(scoreDesc, prometheus.GaugeValue, float64(data.Score), k.cluster, k.plan)
+		ruleTypes := map[string]output.ReportSection{}
+		for ruleType := range result.Spec.InspectRuleTotal {
+			ruleTypes[ruleType] = output.ReportSection{}
+		}
+		for _, section := range data.RuleTypes {
+			ruleTypes[section.Name] = section
+		}
+		// every inspected rule type reports all levels, so series drop to 0 instead of disappearing
+		for ruleType, section := range ruleTypes {
+			for _, level := range []v1alpha2.Level{v1alpha2.DangerLevel, v1alpha2.WarningLevel, v1alpha2.IgnoreLevel} {
+				ch <- prometheus.MustNewConstMetric(findingsDesc, prometheus.GaugeValue, float64(section.Levels[string(level)]), k.cluster, k.plan, ruleType, string(level))
+			}
+		}
+	}
+
+	c.mutex.Lock()
+	for uid := range c.summaries {
+		if !live[uid] {
+			delete(c.summaries, uid)
+		}
+	}
+	c.mutex.Unlock()
+}
+
+// summary returns the report data of the result, the result file is only read the first time.
+func (c *ResultCollector) summary(result *v1alpha2.InspectResult) *output.ReportData {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if data, exist := c.summaries[result.UID]; exist {
+		return data
+	}
+	full, err := output.ReadResultFile(path.Join(c.resultDir, result.Name))
+	if err != nil {
+		// the file is written after the result is created, try again on the next scrape
+		klog.V(4).Infof("failed to read inspect result %s for metrics, err: %s", result.Name, err)
+		return nil
+	}
+	full.ObjectMeta = result.ObjectMeta
+	data := output.NewReportData(full)
+	// the findings are not needed for the metrics, do not keep them in memory
+	data.Findings, data.Nodes, data.Namespaces, data.Result = nil, nil, nil, nil
+	for i := range data.RuleTypes {
+		data.RuleTypes[i].Findings = nil
+	}
+	c.summaries[result.UID] = data
+	return data
+}
+
+func resultEndTime(result *v1alpha2.InspectResult) time.Time {
+	if end, err := time.ParseInLocation(resultTimeLayout, result.Annotations[constant.AnnotationEndTime], time.Local); err == nil {
+		return end
+	}
+	return result.CreationTimestamp.Time
+}
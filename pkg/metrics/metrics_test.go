@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	listersv1alpha2 "github.com/kubesphere/kubeeye/clients/listers/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTask(name string, phase v1alpha2.Phase, created time.Time) *v1alpha2.InspectTask {
+	return &v1alpha2.InspectTask{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Labels:            map[string]string{constant.LabelPlanName: "daily"},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Status: v1alpha2.InspectTaskStatus{Status: phase},
+	}
+}
+
+func newResult(cluster string, task string, created time.Time, annotations map[string]string) *v1alpha2.InspectResult {
+	return &v1alpha2.InspectResult{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              fmt.Sprintf("%s-%s-result", cluster, task),
+			UID:               types.UID(cluster + task),
+			Labels:            map[string]string{constant.LabelPlanName: "daily", constant.LabelTaskName: task},
+			Annotations:       annotations,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1alpha2.InspectResultSpec{
+			InspectCluster:   v1alpha2.Cluster{Name: cluster},
+			InspectRuleTotal: map[string]int{constant.Opa: 2, constant.Sysctl: 1},
+		},
+	}
+}
+
+func writeResultFile(t *testing.T, dir string, result *v1alpha2.InspectResult) {
+	full := result.DeepCopy()
+	full.Spec.OpaResult = v1alpha2.KubeeyeOpaResult{
+		ScoreInfo: v1alpha2.ScoreInfo{Score: 85, Total: 10},
+		ResourceResults: []v1alpha2.ResourceResult{{
+			NameSpace:    "default",
+			ResourceType: "Deployment",
+			Name:         "nginx",
+			ResultItems: []v1alpha2.ResultItem{
+				{Level: "danger", Message: "PrivilegeEscalationAllowed"},
+				{Level: "warning", Message: "NoCPULimits"},
+			},
+		}},
+	}
+	data, err := json.Marshal(full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, result.Name), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResultCollector(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tasks := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	results := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	_ = tasks.Add(newTask("t1", v1alpha2.PhaseSucceeded, day))
+	_ = tasks.Add(newTask("t2", v1alpha2.PhaseFailed, day.Add(24*time.Hour)))
+
+	succeeded := newResult("host", "t1", day, map[string]string{constant.AnnotationEndTime: "2024-01-01 10:10:00"})
+	latest := newResult("host", "t2", day.Add(24*time.Hour), map[string]string{
+		constant.AnnotationStartTime: "2024-01-02 10:00:00",
+		constant.AnnotationEndTime:   "2024-01-02 10:05:30",
+	})
+	// the result file of the member cluster is not written yet
+	pending := newResult("member", "t2", day.Add(24*time.Hour), nil)
+	for _, result := range []*v1alpha2.InspectResult{succeeded, latest, pending} {
+		_ = results.Add(result)
+	}
+
+	dir := t.TempDir()
+	writeResultFile(t, dir, latest)
+	collector := NewResultCollector(listersv1alpha2.NewInspectResultLister(results), listersv1alpha2.NewInspectTaskLister(tasks))
+	collector.resultDir = dir
+
+	lastSuccess, err := time.ParseInLocation(resultTimeLayout, "2024-01-01 10:10:00", time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fmt.Sprintf(`
+# HELP kubeeye_findings Number of findings of the latest inspect result.
+# TYPE kubeeye_findings gauge
+kubeeye_findings{cluster="host",level="danger",plan="daily",rule_type="opa"} 1
+kubeeye_findings{cluster="host",level="ignore",plan="daily",rule_type="opa"} 0
+kubeeye_findings{cluster="host",level="warning",plan="daily",rule_type="opa"} 1
+kubeeye_findings{cluster="host",level="danger",plan="daily",rule_type="sysctl"} 0
+kubeeye_findings{cluster="host",level="ignore",plan="daily",rule_type="sysctl"} 0
+kubeeye_findings{cluster="host",level="warning",plan="daily",rule_type="sysctl"} 0
+# HELP kubeeye_last_success_timestamp Unix time of the latest inspection that did not fail.
+# TYPE kubeeye_last_success_timestamp gauge
+kubeeye_last_success_timestamp{cluster="host",plan="daily"} %d
+# HELP kubeeye_score Score (0-100) of the latest inspect result.
+# TYPE kubeeye_score gauge
+kubeeye_score{cluster="host",plan="daily"} 85
+# HELP kubeeye_task_duration_seconds Duration of the latest inspection.
+# TYPE kubeeye_task_duration_seconds gauge
+kubeeye_task_duration_seconds{cluster="host",plan="daily"} 330
+# HELP kubeeye_task_status Status of the latest inspect task of the plan, 1 for the current status and 0 for the others.
+# TYPE kubeeye_task_status gauge
+kubeeye_task_status{plan="daily",status="Cancelled"} 0
+kubeeye_task_status{plan="daily",status="Failed"} 1
+kubeeye_task_status{plan="daily",status="PartiallySucceeded"} 0
+kubeeye_task_status{plan="daily",status="Pending"} 0
+kubeeye_task_status{plan="daily",status="Running"} 0
+kubeeye_task_status{plan="daily",status="Succeeded"} 0
+`, lastSuccess.Unix())
+	if err = testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the summary is cached, a later scrape doesn't read the file again
+	if err = os.Remove(filepath.Join(dir, latest.Name)); err != nil {
+		t.Fatal(err)
+	}
+	if err = testutil.CollectAndCompare(collector, strings.NewReader(expected), "kubeeye_score"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the member cluster shows up once its result file is written
+	writeResultFile(t, dir, pending)
+	member := `
+# HELP kubeeye_score Score (0-100) of the latest inspect result.
+# TYPE kubeeye_score gauge
+kubeeye_score{cluster="host",plan="daily"} 85
+kubeeye_score{cluster="member",plan="daily"} 85
+`
+	if err = testutil.CollectAndCompare(collector, strings.NewReader(member), "kubeeye_score"); err != nil {
+		t.Fatal(err)
+	}
+}
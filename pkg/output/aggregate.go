@@ -0,0 +1,236 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"io"
+	"sort"
+	"strings"
+)
+
+// worstClustersPerCategory is the number of clusters ranked for each category.
+const worstClustersPerCategory = 3
+
+// AggregateReport merges the per-cluster results of one task.
+type AggregateReport struct {
+	Task string `json:"task"`
+	// Clusters are sorted by score, lowest first.
+	Clusters []ClusterSummary `json:"clusters"`
+	// SharedFindings are rules failing in more than one cluster, the most widespread first.
+	SharedFindings []SharedFinding `json:"sharedFindings"`
+	// WorstClusters ranks the clusters with the most findings of each category.
+	WorstClusters []CategoryRanking `json:"worstClusters"`
+
+	results []*v1alpha2.InspectResult
+}
+
+type ClusterSummary struct {
+	Name   string         `json:"name"`
+	Result string         `json:"result"`
+	Score  int            `json:"score"`
+	Total  int            `json:"total"`
+	Levels map[string]int `json:"levels"`
+	// RuleTypes counts the findings by category.
+	RuleTypes map[string]int `json:"ruleTypes"`
+
+	data *ReportData
+}
+
+type SharedFinding struct {
+	RuleType string   `json:"ruleType"`
+	Name     string   `json:"name"`
+	Level    string   `json:"level"`
+	Clusters []string `json:"clusters"`
+	// Count is the number of findings across all clusters.
+	Count int `json:"count"`
+}
+
+type CategoryRanking struct {
+	RuleType string         `json:"ruleType"`
+	Clusters []ClusterCount `json:"clusters"`
+}
+
+type ClusterCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Aggregate merges the results of the clusters of a task.
+func Aggregate(task string, results []*v1alpha2.InspectResult) *AggregateReport {
+	report := &AggregateReport{Task: task, Clusters: []ClusterSummary{}, SharedFindings: []SharedFinding{}, WorstClusters: []CategoryRanking{}, results: results}
+	shared := map[string]*SharedFinding{}
+	categories := map[string][]ClusterCount{}
+	for _, result := range results {
+		data := NewReportData(result)
+		summary := ClusterSummary{Name: data.Cluster, Result: result.Name, Score: data.Score, Total: data.Total, Levels: data.Levels, RuleTypes: map[string]int{}, data: data}
+		for _, section := range data.RuleTypes {
+			summary.RuleTypes[section.Name] = section.Total
+			categories[section.Name] = append(categories[section.Name], ClusterCount{Name: data.Cluster, Count: section.Total})
+		}
+		report.Clusters = append(report.Clusters, summary)
+
+		for _, row := range data.Findings {
+			key := strings.Join([]string{row.RuleType, row.Name, NormalizeLevel(row.Level)}, "/")
+			finding, exist := shared[key]
+			if !exist {
+				finding = &SharedFinding{RuleType: row.RuleType, Name: row.Name, Level: NormalizeLevel(row.Level)}
+				shared[key] = finding
+			}
+			if len(finding.Clusters) == 0 || finding.Clusters[len(finding.Clusters)-1] != data.Cluster {
+				finding.Clusters = append(finding.Clusters, data.Cluster)
+			}
+			finding.Count++
+		}
+	}
+	sort.Slice(report.Clusters, func(i, j int) bool {
+		if report.Clusters[i].Score != report.Clusters[j].Score {
+			return report.Clusters[i].Score < report.Clusters[j].Score
+		}
+		return report.Clusters[i].Name < report.Clusters[j].Name
+	})
+
+	for _, finding := range shared {
+		if len(finding.Clusters) > 1 {
+			report.SharedFindings = append(report.SharedFindings, *finding)
+		}
+	}
+	sort.Slice(report.SharedFindings, func(i, j int) bool {
+		a, b := report.SharedFindings[i], report.SharedFindings[j]
+		if len(a.Clusters) != len(b.Clusters) {
+			return len(a.Clusters) > len(b.Clusters)
+		}
+		if LevelRank(a.Level) != LevelRank(b.Level) {
+			return LevelRank(a.Level) > LevelRank(b.Level)
+		}
+		return a.RuleType+a.Name < b.RuleType+b.Name
+	})
+
+	for ruleType, counts := range categories {
+		sort.Slice(counts, func(i, j int) bool {
+			if counts[i].Count != counts[j].Count {
+				return counts[i].Count > counts[j].Count
+			}
+			return counts[i].Name < counts[j].Name
+		})
+		if len(counts) > worstClustersPerCategory {
+			counts = counts[:worstClustersPerCategory]
+		}
+		report.WorstClusters = append(report.WorstClusters, CategoryRanking{RuleType: ruleType, Clusters: counts})
+	}
+	sort.Slice(report.WorstClusters, func(i, j int) bool { return report.WorstClusters[i].RuleType < report.WorstClusters[j].RuleType })
+	return report
+}
+
+// Rows returns the findings of every cluster, the cluster column tells them apart.
+func (a *AggregateReport) Rows() []ResultRow {
+	var rows []ResultRow
+	for _, result := range a.results {
+		rows = append(rows, ResultRows(result)...)
+	}
+	return rows
+}
+
+// IsAggregateFormat returns whether RenderAggregate can write the format.
+func IsAggregateFormat(format string) bool {
+	switch strings.ToLower(format) {
+	case FormatJSON, "", FormatCSV, FormatTSV, FormatMarkdown, "md", FormatText, FormatTable, FormatSARIF, FormatJUnit, FormatHTML, FormatReport, FormatPDF:
+		return true
+	}
+	return false
+}
+
+// RenderAggregate writes the aggregated report in one of the result formats.
+func RenderAggregate(w io.Writer, format string, report *AggregateReport) error {
+	switch strings.ToLower(format) {
+	case FormatJSON, "":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case FormatCSV:
+		return CSVOut(w, report.Rows())
+	case FormatTSV:
+		return TSVOut(w, report.Rows())
+	case FormatMarkdown, "md":
+		return aggregateMarkdown(w, report)
+	case FormatText, FormatTable:
+		return aggregateText(w, report)
+	case FormatSARIF:
+		log := NewSarifLog(nil, nil)
+		log.Runs = log.Runs[:0]
+		for _, result := range report.results {
+			run := ResultSarif(result).Runs[0]
+			run.AutomationDetails = &SarifAutomationDetails{ID: fmt.Sprintf("%s/%s", report.Task, clusterName(result))}
+			log.Runs = append(log.Runs, run)
+		}
+		return log.Write(w)
+	case FormatJUnit:
+		suites := &JUnitTestSuites{Name: "kubeeye/" + report.Task}
+		for _, result := range report.results {
+			for _, suite := range ResultJUnit(result).Suites {
+				suite.Name = clusterName(result) + "/" + suite.Name
+				suites.AddSuite(suite)
+			}
+		}
+		return suites.Write(w)
+	case FormatHTML, FormatReport:
+		return aggregateHtml(w, report)
+	case FormatPDF:
+		return aggregatePdf(w, report)
+	}
+	return fmt.Errorf("unsupported output format %s", format)
+}
+
+func aggregateMarkdown(w io.Writer, report *AggregateReport) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "## KubeEye inspection report: %s\n\n", report.Task)
+	b.WriteString("| Cluster | Score | Danger | Warning | Ignore | Total |\n")
+	b.WriteString("| --- | ---: | ---: | ---: | ---: | ---: |\n")
+	for _, c := range report.Clusters {
+		fmt.Fprintf(b, "| %s | %d | %d | %d | %d | %d |\n", markdownCell(c.Name), c.Score, c.Levels["danger"], c.Levels["warning"], c.Levels["ignore"], c.Total)
+	}
+	if len(report.SharedFindings) > 0 {
+		b.WriteString("\n### Shared findings\n\n| Level | Category | Name | Clusters |\n| --- | --- | --- | --- |\n")
+		for _, f := range report.SharedFindings {
+			fmt.Fprintf(b, "| %s | %s | %s | %d: %s |\n", f.Level, f.RuleType, markdownCell(f.Name), len(f.Clusters), markdownCell(strings.Join(f.Clusters, ", ")))
+		}
+	}
+	if len(report.WorstClusters) > 0 {
+		b.WriteString("\n### Worst clusters per category\n\n| Category | Clusters |\n| --- | --- |\n")
+		for _, ranking := range report.WorstClusters {
+			fmt.Fprintf(b, "| %s | %s |\n", ranking.RuleType, markdownCell(clusterCounts(ranking.Clusters)))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func aggregateText(w io.Writer, report *AggregateReport) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "KubeEye inspection report: %s\n\n", report.Task)
+	for _, c := range report.Clusters {
+		fmt.Fprintf(b, "%-30s score %3d  danger %d, warning %d, ignore %d\n", c.Name, c.Score, c.Levels["danger"], c.Levels["warning"], c.Levels["ignore"])
+	}
+	if len(report.SharedFindings) > 0 {
+		b.WriteString("\nShared findings:\n")
+		for _, f := range report.SharedFindings {
+			fmt.Fprintf(b, "  [%s] %s %s: %s\n", f.Level, f.RuleType, f.Name, strings.Join(f.Clusters, ", "))
+		}
+	}
+	if len(report.WorstClusters) > 0 {
+		b.WriteString("\nWorst clusters per category:\n")
+		for _, ranking := range report.WorstClusters {
+			fmt.Fprintf(b, "  %s: %s\n", ranking.RuleType, clusterCounts(ranking.Clusters))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func clusterCounts(counts []ClusterCount) string {
+	var items []string
+	for _, c := range counts {
+		items = append(items, fmt.Sprintf("%s (%d)", c.Name, c.Count))
+	}
+	return strings.Join(items, ", ")
+}
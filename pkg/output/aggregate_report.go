@@ -0,0 +1,102 @@
+package output
+
+import (
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/template"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+)
+
+// aggregateHtmlReport is the data of the standalone multi-cluster html report.
+type aggregateHtmlReport struct {
+	*AggregateReport
+	ClusterChart htmltemplate.HTML
+	// Drilldown holds the report of each cluster in the order of Clusters.
+	Drilldown []*ReportData
+}
+
+func aggregateHtml(w io.Writer, report *AggregateReport) error {
+	data := aggregateHtmlReport{AggregateReport: report, Drilldown: report.drilldown()}
+	var sections []ReportSection
+	for _, c := range report.Clusters {
+		sections = append(sections, ReportSection{Name: c.Name, Total: c.Total, Levels: c.Levels})
+	}
+	data.ClusterChart = CategoryBarsSVG(sections)
+
+	funcs := ReportFuncs()
+	funcs["scoreClass"] = func(score int) string {
+		switch {
+		case score >= 80:
+			return "score-good"
+		case score >= 60:
+			return "score-fair"
+		}
+		return "score-poor"
+	}
+	reportTemplate, err := template.GetAggregateReportHtmlTemplate(funcs)
+	if err != nil {
+		return err
+	}
+	return reportTemplate.Execute(w, data)
+}
+
+func (a *AggregateReport) drilldown() []*ReportData {
+	var reports []*ReportData
+	for _, c := range a.Clusters {
+		reports = append(reports, c.data)
+	}
+	return reports
+}
+
+// aggregatePdf writes the scores, shared findings and worst clusters followed by the findings of each cluster.
+func aggregatePdf(out io.Writer, report *AggregateReport) error {
+	w := newPdfWriter()
+	w.paragraph(22, pdfBlack, true, "KubeEye Inspection Report")
+	w.paragraph(14, pdfGray, false, fmt.Sprintf("%s · %d clusters", report.Task, len(report.Clusters)))
+	w.y -= 12
+
+	w.paragraph(14, pdfBlack, true, "Scores")
+	var scoreRows [][]string
+	for _, c := range report.Clusters {
+		scoreRows = append(scoreRows, []string{c.Name, fmt.Sprint(c.Score), fmt.Sprint(c.Levels["danger"]), fmt.Sprint(c.Levels["warning"]),
+			fmt.Sprint(c.Levels["ignore"]), fmt.Sprint(c.Total)})
+	}
+	w.table([]pdfColumn{{"Cluster", 0.4}, {"Score", 0.12}, {"Danger", 0.12}, {"Warning", 0.12}, {"Ignore", 0.12}, {"Total", 0.12}}, scoreRows, -1)
+
+	if len(report.SharedFindings) > 0 {
+		w.paragraph(14, pdfBlack, true, "Shared findings")
+		var rows [][]string
+		for _, f := range report.SharedFindings {
+			rows = append(rows, []string{f.Level, f.RuleType, f.Name, fmt.Sprintf("%d: %s", len(f.Clusters), strings.Join(f.Clusters, ", "))})
+		}
+		w.table([]pdfColumn{{"Level", 0.1}, {"Category", 0.2}, {"Name", 0.3}, {"Clusters", 0.4}}, rows, 0)
+	}
+	if len(report.WorstClusters) > 0 {
+		w.paragraph(14, pdfBlack, true, "Worst clusters per category")
+		var rows [][]string
+		for _, ranking := range report.WorstClusters {
+			rows = append(rows, []string{ranking.RuleType, clusterCounts(ranking.Clusters)})
+		}
+		w.table([]pdfColumn{{"Category", 0.3}, {"Clusters", 0.7}}, rows, -1)
+	}
+
+	for _, data := range report.drilldown() {
+		w.addPage()
+		w.paragraph(16, pdfBlack, true, fmt.Sprintf("%s (score %d, %d issues)", data.Cluster, data.Score, data.Total))
+		w.y -= 4
+		var rows [][]string
+		for _, row := range data.Findings {
+			target := strings.TrimSpace(strings.Join([]string{row.Node, row.Namespace}, " "))
+			rows = append(rows, []string{NormalizeLevel(row.Level), row.RuleType, row.Name, target, row.Resource, row.Message})
+		}
+		if len(rows) == 0 {
+			w.paragraph(11, pdfGray, false, "No issues found.")
+			continue
+		}
+		w.table([]pdfColumn{{"Level", 0.09}, {"Category", 0.13}, {"Name", 0.18}, {"Node/Namespace", 0.15}, {"Resource", 0.17}, {"Message", 0.28}}, rows, 0)
+	}
+
+	w.footer(fmt.Sprintf("KubeEye · %s", report.Task))
+	return w.writeTo(out)
+}
@@ -0,0 +1,185 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// aggregateResult is the result of a cluster with failed components and sysctl checks, each sysctl fails on node1 and node2.
+func aggregateResult(cluster string, components []string, sysctls []string) *v1alpha2.InspectResult {
+	result := &v1alpha2.InspectResult{
+		ObjectMeta: metav1.ObjectMeta{Name: cluster + "-result"},
+		Spec:       v1alpha2.InspectResultSpec{InspectCluster: v1alpha2.Cluster{Name: cluster}},
+	}
+	for _, name := range components {
+		result.Spec.ComponentResult = append(result.Spec.ComponentResult, v1alpha2.ComponentResultItem{
+			BaseResult: v1alpha2.BaseResult{Name: name, Assert: true, Level: v1alpha2.DangerLevel},
+		})
+	}
+	for _, name := range sysctls {
+		for _, node := range []string{"node1", "node2"} {
+			result.Spec.SysctlResult = append(result.Spec.SysctlResult, v1alpha2.NodeMetricsResultItem{
+				BaseResult: v1alpha2.BaseResult{Name: name, Assert: true, Level: v1alpha2.WarningLevel}, Value: ptr.To("0"), NodeName: node,
+			})
+		}
+	}
+	return result
+}
+
+func aggregateResults() []*v1alpha2.InspectResult {
+	return []*v1alpha2.InspectResult{
+		aggregateResult("a", []string{"etcd", "kube-scheduler"}, []string{"net.ipv4.ip_forward"}),
+		aggregateResult("b", []string{"etcd"}, []string{"net.ipv4.ip_forward", "vm.swappiness"}),
+		aggregateResult("c", []string{"etcd", "kube-scheduler", "kube-proxy"}, nil),
+		aggregateResult("d", []string{"kube-scheduler"}, nil),
+		aggregateResult("e", nil, nil),
+	}
+}
+
+func TestAggregateSharedFindings(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []*v1alpha2.InspectResult
+		want    []SharedFinding
+	}{
+		{
+			name:    "single cluster",
+			results: []*v1alpha2.InspectResult{aggregateResult("a", []string{"etcd"}, []string{"vm.swappiness"})},
+			want:    []SharedFinding{},
+		},
+		{
+			name:    "same rule on several nodes of one cluster",
+			results: []*v1alpha2.InspectResult{aggregateResult("a", nil, []string{"vm.swappiness"}), aggregateResult("b", nil, nil)},
+			want:    []SharedFinding{},
+		},
+		{
+			name:    "most widespread first",
+			results: aggregateResults(),
+			want: []SharedFinding{
+				{RuleType: "component", Name: "etcd", Level: "danger", Clusters: []string{"a", "b", "c"}, Count: 3},
+				{RuleType: "component", Name: "kube-scheduler", Level: "danger", Clusters: []string{"a", "c", "d"}, Count: 3},
+				{RuleType: "sysctl", Name: "net.ipv4.ip_forward", Level: "warning", Clusters: []string{"a", "b"}, Count: 4},
+			},
+		},
+		{
+			name: "same rule with another level",
+			results: []*v1alpha2.InspectResult{
+				aggregateResult("a", []string{"etcd"}, nil),
+				{Spec: v1alpha2.InspectResultSpec{InspectCluster: v1alpha2.Cluster{Name: "b"}, ComponentResult: []v1alpha2.ComponentResultItem{{
+					BaseResult: v1alpha2.BaseResult{Name: "etcd", Assert: true, Level: v1alpha2.WarningLevel},
+				}}}},
+			},
+			want: []SharedFinding{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Aggregate("task", tt.results).SharedFindings; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("shared findings = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregateWorstClusters(t *testing.T) {
+	report := Aggregate("task", aggregateResults())
+	want := []CategoryRanking{
+		{RuleType: "component", Clusters: []ClusterCount{{Name: "c", Count: 3}, {Name: "a", Count: 2}, {Name: "b", Count: 1}}},
+		{RuleType: "sysctl", Clusters: []ClusterCount{{Name: "b", Count: 4}, {Name: "a", Count: 2}}},
+	}
+	if !reflect.DeepEqual(report.WorstClusters, want) {
+		t.Errorf("worst clusters = %+v, want %+v", report.WorstClusters, want)
+	}
+
+	var names []string
+	for i, cluster := range report.Clusters {
+		names = append(names, cluster.Name)
+		if i > 0 && cluster.Score < report.Clusters[i-1].Score {
+			t.Errorf("cluster %s with score %d is ranked after a better cluster", cluster.Name, cluster.Score)
+		}
+	}
+	if last := names[len(names)-1]; last != "e" {
+		t.Errorf("clusters %v, want the cluster without findings last", names)
+	}
+	if e := report.Clusters[len(report.Clusters)-1]; e.Total != 0 || e.Score != 100 {
+		t.Errorf("cluster without findings has total %d and score %d", e.Total, e.Score)
+	}
+}
+
+func TestAggregateSarif(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderAggregate(&buf, FormatSARIF, Aggregate("task", aggregateResults())); err != nil {
+		t.Fatal(err)
+	}
+	var log SarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid sarif: %s", err)
+	}
+	tests := []struct {
+		id      string
+		results int
+	}{
+		{id: "task/a", results: 4},
+		{id: "task/b", results: 5},
+		{id: "task/c", results: 3},
+		{id: "task/d", results: 1},
+		{id: "task/e", results: 0},
+	}
+	if len(log.Runs) != len(tests) {
+		t.Fatalf("got %d runs, want one per cluster", len(log.Runs))
+	}
+	for i, tt := range tests {
+		run := log.Runs[i]
+		if run.AutomationDetails == nil || run.AutomationDetails.ID != tt.id {
+			t.Errorf("run %d has automation details %+v, want id %s", i, run.AutomationDetails, tt.id)
+		}
+		if len(run.Results) != tt.results {
+			t.Errorf("run %s has %d results, want %d", tt.id, len(run.Results), tt.results)
+		}
+	}
+}
+
+func TestAggregateJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	results := []*v1alpha2.InspectResult{
+		aggregateResult("a", []string{"etcd"}, []string{"vm.swappiness"}),
+		aggregateResult("b", []string{"etcd"}, nil),
+	}
+	if err := RenderAggregate(&buf, FormatJUnit, Aggregate("task", results)); err != nil {
+		t.Fatal(err)
+	}
+	var suites JUnitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("invalid junit: %s", err)
+	}
+	if suites.Name != "kubeeye/task" {
+		t.Errorf("suites name %s, want kubeeye/task", suites.Name)
+	}
+	tests := []struct {
+		name     string
+		failures int
+	}{
+		{name: "a/component", failures: 1},
+		// the rule fails on two nodes, it is still a single test case
+		{name: "a/sysctl", failures: 1},
+		{name: "b/component", failures: 1},
+	}
+	if len(suites.Suites) != len(tests) {
+		t.Fatalf("got %d suites, want %d", len(suites.Suites), len(tests))
+	}
+	for i, tt := range tests {
+		if suite := suites.Suites[i]; suite.Name != tt.name || suite.Failures != tt.failures {
+			t.Errorf("suite %d is %s with %d failures, want %s with %d", i, suite.Name, suite.Failures, tt.name, tt.failures)
+		}
+	}
+	if suites.Failures != 3 {
+		t.Errorf("suites have %d failures, want 3", suites.Failures)
+	}
+}
@@ -1,147 +1,241 @@
 package output
 
-//
-//import (
-//	"context"
-//	"fmt"
-//	"github.com/kubesphere/kubeeye/pkg/constant"
-//	"github.com/kubesphere/kubeeye/pkg/kube"
-//	"github.com/kubesphere/kubeeye/pkg/utils"
-//	"github.com/xuri/excelize/v2"
-//	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-//	"k8s.io/klog/v2"
-//	"path"
-//	"strings"
-//)
-//
-//func CSVOutput(clients *kube.KubernetesClient, outPath *string, taskName string, namespace string) error {
-//	filename := "kubeEyeAuditResult.xlsx"
-//	f := excelize.NewFile()
-//	defer func() {
-//		if err := f.Close(); err != nil {
-//			fmt.Println(err)
-//		}
-//	}()
-//
-//	list, err := clients.VersionClientSet.KubeeyeV1alpha2().InspectResults().List(context.TODO(), metav1.ListOptions{
-//		LabelSelector: metav1.FormatLabelSelector(metav1.SetAsLabelSelector(map[string]string{constant.LabelName: taskName})),
-//	})
-//	if err != nil {
-//		klog.Error(err)
-//		return err
-//	}
-//
-//	_, err = f.NewSheet("opa")
-//	if err != nil {
-//		klog.Error(err)
-//		return err
-//	}
-//	_, err = f.NewSheet("prometheus")
-//	if err != nil {
-//		return err
-//	}
-//	_, err = f.NewSheet("nodeInfo")
-//	if err != nil {
-//		klog.Error(err)
-//		return err
-//	}
-//
-//	for _, item := range list.Items {
-//
-//		if item.Spec.OpaResult.ResourceResults != nil {
-//			row := 1
-//			_ = f.SetSheetRow("opa", fmt.Sprintf("A%d", row), &[]string{"NameSpace", "Kind", "Name", "Level", "Message", "Reason"})
-//			row++
-//			for _, result := range item.Spec.OpaResult.ResourceResults {
-//
-//				if result.ResultItems != nil {
-//					for _, resultItem := range result.ResultItems {
-//						_ = f.SetSheetRow("opa", fmt.Sprintf("A%d", row), &[]string{result.NameSpace, result.ResourceType, result.Name, resultItem.Level, resultItem.Message, resultItem.Reason})
-//						row++
-//					}
-//				} else {
-//					_ = f.SetSheetRow("opa", fmt.Sprintf("A%d", row), &[]string{result.NameSpace, result.ResourceType, result.Name})
-//					row++
-//				}
-//
-//			}
-//		}
-//
-//		//if item.Spec.PrometheusResult != nil {
-//		//	row := 1
-//		//
-//		//	for _, prometheus := range item.Spec.PrometheusResult {
-//		//		var header []string
-//		//		for _, val := range prometheus {
-//		//			if len(header) == 0 {
-//		//				for k := range val {
-//		//					header = append(header, k)
-//		//				}
-//		//				_ = f.SetSheetRow("prometheus", fmt.Sprintf("A%d", row), &header)
-//		//				row++
-//		//			}
-//		//			var value []string
-//		//			for i := range header {
-//		//				value = append(value, val[header[i]])
-//		//			}
-//		//			_ = f.SetSheetRow("prometheus", fmt.Sprintf("A%d", row), &value)
-//		//			row++
-//		//		}
-//		//	}
-//		//
-//		//}
-//
-//		if item.Spec.NodeInfoResult != nil {
-//			row := 1
-//			err := f.SetSheetRow("nodeInfo", fmt.Sprintf("A%d", row), &[]string{"nodeName", "type", "name", "value", "assert"})
-//			row++
-//			if err != nil {
-//				fmt.Println(err)
-//			}
-//			for key, val := range item.Spec.NodeInfoResult {
-//				if val.SysctlResult != nil {
-//					for _, resultItem := range val.SysctlResult {
-//						err := f.SetSheetRow("nodeInfo", fmt.Sprintf("A%d", row), &[]string{key, "sysctl", resultItem.Name, *resultItem.Value, utils.BoolToString(resultItem.Assert)})
-//						row++
-//						if err != nil {
-//							fmt.Println(err)
-//						}
-//					}
-//				}
-//				if val.SystemdResult != nil {
-//					for _, resultItem := range val.SystemdResult {
-//						err := f.SetSheetRow("nodeInfo", fmt.Sprintf("A%d", row), &[]string{key, "systemd", resultItem.Name, *resultItem.Value, utils.BoolToString(resultItem.Assert)})
-//						row++
-//						if err != nil {
-//							fmt.Println(err)
-//						}
-//					}
-//				}
-//				if val.FileChangeResult != nil {
-//					for _, fileItem := range val.FileChangeResult {
-//						err := f.SetSheetRow("nodeInfo", fmt.Sprintf("A%d", row), &[]string{key, "filechange", fileItem.FileName, strings.Join(fileItem.Issues, ",")})
-//						row++
-//						if err != nil {
-//							fmt.Println(err)
-//						}
-//					}
-//				}
-//			}
-//		}
-//	}
-//
-//	if err != nil {
-//		fmt.Println(err)
-//	}
-//
-//	if outPath != nil {
-//		filename = path.Join(*outPath, filename)
-//	}
-//
-//	err = f.SaveAs(filename)
-//	if err != nil {
-//		return err
-//	}
-//	fmt.Printf("The result is exported to kubeEyeAuditResult.xlsx, please check it for inspect result.\n")
-//	return nil
-//}
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	"io"
+	"strings"
+)
+
+// ResultColumns is the stable column schema of the tabular export.
+var ResultColumns = []string{"cluster", "ruleType", "name", "level", "node", "namespace", "resource", "message", "value"}
+
+// ResultRow is one finding of an inspect result flattened to the tabular schema.
+type ResultRow struct {
+	Cluster   string `json:"cluster"`
+	RuleType  string `json:"ruleType"`
+	Name      string `json:"name"`
+	Level     string `json:"level"`
+	Node      string `json:"node,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Resource  string `json:"resource,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Value     string `json:"value,omitempty"`
+
+	passed bool
+}
+
+func (r ResultRow) Values() []string {
+	return []string{r.Cluster, r.RuleType, r.Name, r.Level, r.Node, r.Namespace, r.Resource, r.Message, r.Value}
+}
+
+// ResultRows flattens the findings of every result type into rows. Checks that passed are not exported.
+func ResultRows(result *v1alpha2.InspectResult) []ResultRow {
+	return flattenResult(result, false)
+}
+
+// flattenResult flattens the result, withPassed also keeps the checks that passed so every executed rule shows up.
+func flattenResult(result *v1alpha2.InspectResult, withPassed bool) []ResultRow {
+	var rows []ResultRow
+	spec := result.Spec
+	cluster := spec.InspectCluster.Name
+
+	for _, resource := range spec.OpaResult.ResourceResults {
+		for _, item := range resource.ResultItems {
+			rows = append(rows, opaRow(cluster, resource, item))
+		}
+	}
+	for _, item := range spec.PrometheusResult {
+		rows = append(rows, prometheusRow(cluster, item))
+	}
+	for _, item := range spec.FileChangeResult {
+		if item.Assert || withPassed {
+			rows = append(rows, fileRow(cluster, constant.FileChange, item))
+		}
+	}
+	for _, item := range spec.FileFilterResult {
+		if item.Assert || withPassed {
+			rows = append(rows, fileRow(cluster, constant.FileFilter, item))
+		}
+	}
+	for _, item := range spec.SysctlResult {
+		if item.Assert || withPassed {
+			rows = append(rows, metricsRow(cluster, constant.Sysctl, item))
+		}
+	}
+	for _, item := range spec.SystemdResult {
+		if item.Assert || withPassed {
+			rows = append(rows, metricsRow(cluster, constant.Systemd, item))
+		}
+	}
+	for _, item := range spec.NodeInfo {
+		if item.Assert || withPassed {
+			rows = append(rows, nodeInfoRow(cluster, item))
+		}
+	}
+	for _, item := range spec.CommandResult {
+		if item.Assert || withPassed {
+			rows = append(rows, commandRow(cluster, item))
+		}
+	}
+	for _, item := range spec.ComponentResult {
+		if item.Assert || withPassed {
+			rows = append(rows, componentRow(cluster, item))
+		}
+	}
+	for _, item := range spec.ServiceConnectResult {
+		if item.Assert || withPassed {
+			rows = append(rows, serviceConnectRow(cluster, item))
+		}
+	}
+	return rows
+}
+
+func opaRow(cluster string, resource v1alpha2.ResourceResult, item v1alpha2.ResultItem) ResultRow {
+	message := item.Reason
+	if message == "" {
+		message = item.Message
+	}
+	return ResultRow{
+		Cluster:   cluster,
+		RuleType:  constant.Opa,
+		Name:      item.Message,
+		Level:     item.Level,
+		Namespace: resource.NameSpace,
+		Resource:  fmt.Sprintf("%s/%s", resource.ResourceType, resource.Name),
+		Message:   message,
+	}
+}
+
+func prometheusRow(cluster string, item v1alpha2.PrometheusResult) ResultRow {
+	labels := item.ParseString()
+	return ResultRow{
+		Cluster:   cluster,
+		RuleType:  constant.Prometheus,
+		Name:      item.Name,
+		Level:     string(item.Level),
+		Node:      labels["node"],
+		Namespace: labels["namespace"],
+		Resource:  labels["instance"],
+		Value:     item.Result,
+	}
+}
+
+func nodeInfoRow(cluster string, item v1alpha2.NodeInfoResultItem) ResultRow {
+	resource := item.ResourcesType.Type
+	if item.ResourcesType.Mount != "" {
+		resource = fmt.Sprintf("%s:%s", resource, item.ResourcesType.Mount)
+	}
+	return ResultRow{
+		Cluster:  cluster,
+		RuleType: constant.NodeInfo,
+		Name:     item.Name,
+		Level:    string(item.Level),
+		Node:     item.NodeName,
+		Resource: resource,
+		Value:    item.Value,
+		passed:   !item.Assert,
+	}
+}
+
+func commandRow(cluster string, item v1alpha2.CommandResultItem) ResultRow {
+	return ResultRow{
+		Cluster:  cluster,
+		RuleType: constant.CustomCommand,
+		Name:     item.Name,
+		Level:    string(item.Level),
+		Node:     item.NodeName,
+		Resource: item.Command,
+		Value:    item.Value,
+		passed:   !item.Assert,
+	}
+}
+
+func componentRow(cluster string, item v1alpha2.ComponentResultItem) ResultRow {
+	return ResultRow{
+		Cluster:  cluster,
+		RuleType: constant.Component,
+		Name:     item.Name,
+		Level:    string(item.Level),
+		Resource: fmt.Sprintf("Service/%s", item.Name),
+		Message:  "component is not running",
+		passed:   !item.Assert,
+	}
+}
+
+func serviceConnectRow(cluster string, item v1alpha2.ServiceConnectResultItem) ResultRow {
+	return ResultRow{
+		Cluster:   cluster,
+		RuleType:  constant.ServiceConnect,
+		Name:      item.Name,
+		Level:     string(item.Level),
+		Namespace: item.Namespace,
+		Resource:  item.Endpoint,
+		Message:   "service is not connectable",
+		passed:    !item.Assert,
+	}
+}
+
+func fileRow(cluster string, ruleType string, item v1alpha2.FileChangeResultItem) ResultRow {
+	return ResultRow{
+		Cluster:  cluster,
+		RuleType: ruleType,
+		Name:     item.Name,
+		Level:    string(item.Level),
+		Node:     item.NodeName,
+		Resource: item.Path,
+		Message:  strings.Join(item.Issues, "; "),
+		passed:   !item.Assert,
+	}
+}
+
+func metricsRow(cluster string, ruleType string, item v1alpha2.NodeMetricsResultItem) ResultRow {
+	row := ResultRow{
+		Cluster:  cluster,
+		RuleType: ruleType,
+		Name:     item.Name,
+		Level:    string(item.Level),
+		Node:     item.NodeName,
+		passed:   !item.Assert,
+	}
+	if item.Value != nil {
+		row.Value = *item.Value
+	}
+	return row
+}
+
+// CSVOut writes a header and the rows as RFC 4180 comma separated values.
+func CSVOut(w io.Writer, rows []ResultRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(ResultColumns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.Write(row.Values()); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// TSVOut writes a header and the rows as tab separated values without quoting, tabs and line breaks inside a value
+// are replaced with spaces.
+func TSVOut(w io.Writer, rows []ResultRow) error {
+	replacer := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+	if _, err := fmt.Fprintln(w, strings.Join(ResultColumns, "\t")); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		values := row.Values()
+		for i := range values {
+			values[i] = replacer.Replace(values[i])
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(values, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,262 +5,343 @@ import (
 	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
 	"github.com/kubesphere/kubeeye/pkg/constant"
 	"github.com/xuri/excelize/v2"
+	"io"
 	corev1 "k8s.io/api/core/v1"
-	"path"
+	"os"
+	"path/filepath"
+	"sort"
 )
 
-func GenerateExcel(resultData *kubeeyev1alpha2.InspectResult, nodes *corev1.NodeList, pods *corev1.PodList) error {
-	f := excelize.NewFile()
-	defer func() error {
-		if err := f.Close(); err != nil {
-			return err
-		}
-		return nil
-	}()
-	// create a new sheet for Nodes
-	if len(nodes.Items) > 0 {
-		index, err := f.NewSheet(constant.NodesStatus)
-		if err != nil {
-			return err
-		}
+const excelSummarySheet = "summary"
 
-		nodeStatus := GetNodesStatus(nodes)
+// excelColumn is a column of a result type sheet, value picks it from the flattened row.
+type excelColumn struct {
+	title string
+	width float64
+	value func(row ResultRow) string
+}
 
-		// Write the data to the sheet
-		for i, item := range nodeStatus {
-			if i == 0 {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.NodesStatus, fmt.Sprintf("%c1", 'A'+rune(j)), c.Text)
-				}
-			} else {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.NodesStatus, fmt.Sprintf("%c%d", 'A'+rune(j), i+1), c.Text)
-				}
-			}
-		}
-		f.SetActiveSheet(index)
-	}
+var (
+	levelColumn     = excelColumn{"level", 10, func(row ResultRow) string { return NormalizeLevel(row.Level) }}
+	nameColumn      = excelColumn{"name", 30, func(row ResultRow) string { return row.Name }}
+	nodeColumn      = excelColumn{"nodeName", 24, func(row ResultRow) string { return row.Node }}
+	namespaceColumn = excelColumn{"namespace", 20, func(row ResultRow) string { return row.Namespace }}
+	valueColumn     = excelColumn{"value", 30, func(row ResultRow) string { return row.Value }}
+	messageColumn   = excelColumn{"message", 60, func(row ResultRow) string { return row.Message }}
+)
 
-	// Create a new sheet for Pods
-	if len(pods.Items) > 0 {
-		_, err := f.NewSheet(constant.AbnormalPods)
-		if err != nil {
-			return err
-		}
+func resourceColumn(title string, width float64) excelColumn {
+	return excelColumn{title, width, func(row ResultRow) string { return row.Resource }}
+}
 
-		podStatus := GetAbnormalPods(pods)
+// excelSheets are the result type sheets in workbook order, the level is always column A for the conditional formatting.
+var excelSheets = []struct {
+	ruleType string
+	columns  []excelColumn
+}{
+	{constant.Opa, []excelColumn{levelColumn, namespaceColumn, resourceColumn("resource", 40), nameColumn, {"reason", 60, func(row ResultRow) string { return row.Message }}}},
+	{constant.Prometheus, []excelColumn{levelColumn, nameColumn, nodeColumn, namespaceColumn, resourceColumn("instance", 24), {"result", 80, func(row ResultRow) string { return row.Value }}}},
+	{constant.NodeInfo, []excelColumn{levelColumn, nameColumn, nodeColumn, resourceColumn("resource", 20), valueColumn}},
+	{constant.FileChange, []excelColumn{levelColumn, nameColumn, nodeColumn, resourceColumn("path", 40), {"issues", 60, func(row ResultRow) string { return row.Message }}}},
+	{constant.FileFilter, []excelColumn{levelColumn, nameColumn, nodeColumn, resourceColumn("path", 40), {"issues", 60, func(row ResultRow) string { return row.Message }}}},
+	{constant.Sysctl, []excelColumn{levelColumn, nameColumn, nodeColumn, valueColumn}},
+	{constant.Systemd, []excelColumn{levelColumn, nameColumn, nodeColumn, valueColumn}},
+	{constant.CustomCommand, []excelColumn{levelColumn, nameColumn, nodeColumn, resourceColumn("command", 40), valueColumn}},
+	{constant.Component, []excelColumn{levelColumn, nameColumn, resourceColumn("resource", 30), messageColumn}},
+	{constant.ServiceConnect, []excelColumn{levelColumn, nameColumn, namespaceColumn, resourceColumn("endpoint", 40), messageColumn}},
+}
 
-		// Write the data to the sheet
-		for i, item := range podStatus {
-			if i == 0 {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.AbnormalPods, fmt.Sprintf("%c1", 'A'+rune(j)), c.Text)
-				}
-			} else {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.AbnormalPods, fmt.Sprintf("%c%d", 'A'+rune(j), i+1), c.Text)
-				}
-			}
-		}
-	}
+// excelLevelFills are the light variants of the report level colors, used as cell backgrounds.
+var excelLevelFills = map[string]string{
+	"danger":  "F8D7DA",
+	"warning": "FCE8C3",
+	"ignore":  "E2E6EA",
+}
 
-	// Create a new sheet for OPA
-	if resultData.Spec.OpaResult.ResourceResults != nil {
-		_, err := f.NewSheet(constant.Opa)
-		if err != nil {
-			return err
-		}
+type excelStyles struct {
+	title  int
+	label  int
+	header int
+	levels map[string]int
+}
 
-		opaResults := GetOpaList(resultData.Spec.OpaResult.ResourceResults)
+// GenerateExcel writes the excel report of the result to file.
+func GenerateExcel(file string, resultData *kubeeyev1alpha2.InspectResult, nodes *corev1.NodeList, pods *corev1.PodList) error {
+	return writeFileAtomic(file, func(w io.Writer) error {
+		return ExcelOut(w, resultData, nodes, pods)
+	})
+}
 
-		// Write the data to the sheet
-		for i, item := range opaResults {
-			if i == 0 {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.Opa, fmt.Sprintf("%c1", 'A'+rune(j)), c.Text)
-				}
-			} else {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.Opa, fmt.Sprintf("%c%d", 'A'+rune(j), i+1), c.Text)
-				}
-			}
-		}
+// writeFileAtomic writes a temporary file next to file and renames it on success, so that a failed write
+// never leaves a truncated file behind for the downloads and attachments.
+func writeFileAtomic(file string, write func(w io.Writer) error) error {
+	temp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if err = write(temp); err != nil {
+		temp.Close()
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(temp.Name(), 0644); err != nil {
+		return err
 	}
+	return os.Rename(temp.Name(), file)
+}
 
-	// Create a new sheet for systemd
-	if resultData.Spec.SystemdResult != nil {
-		_, err := f.NewSheet(constant.Systemd)
-		if err != nil {
-			return err
-		}
+// ExcelOut writes the result as a workbook: a summary sheet, the node and pod status and one sheet per result type.
+// Every sheet is written with a stream writer, so large results are buffered in temporary files instead of memory.
+func ExcelOut(w io.Writer, resultData *kubeeyev1alpha2.InspectResult, nodes *corev1.NodeList, pods *corev1.PodList) error {
+	f := excelize.NewFile()
+	defer f.Close()
 
-		systemdResults := GetSystemd(resultData.Spec.SystemdResult)
+	styles, err := newExcelStyles(f)
+	if err != nil {
+		return err
+	}
+	if err = f.SetSheetName("Sheet1", excelSummarySheet); err != nil {
+		return err
+	}
+	if err = writeExcelSummary(f, styles, resultData); err != nil {
+		return err
+	}
 
-		// Write the data to the sheet
-		for i, item := range systemdResults {
-			if i == 0 {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.Systemd, fmt.Sprintf("%c1", 'A'+rune(j)), c.Text)
-				}
-			} else {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.Systemd, fmt.Sprintf("%c%d", 'A'+rune(j), i+1), c.Text)
-				}
-			}
+	if nodes != nil && len(nodes.Items) > 0 {
+		if err = writeExcelRenderNodes(f, styles, constant.NodesStatus, GetNodesStatus(nodes)); err != nil {
+			return err
 		}
 	}
-
-	// Create a new sheet for prometheus
-	if resultData.Spec.PrometheusResult != nil {
-		_, err := f.NewSheet(constant.Prometheus)
-		if err != nil {
+	if pods != nil && len(pods.Items) > 0 {
+		if err = writeExcelRenderNodes(f, styles, constant.AbnormalPods, GetAbnormalPods(pods)); err != nil {
 			return err
 		}
+	}
 
-		prometheusReults := GetPrometheus(resultData.Spec.PrometheusResult)
-		// Write the data to the sheet
-		for i, item := range prometheusReults {
-			for j, c := range item.Children {
-				f.SetCellValue(constant.Prometheus, fmt.Sprintf("%c%d", 'A'+rune(j), i+1), c.Text)
-			}
+	rows := map[string][]ResultRow{}
+	for _, row := range ResultRows(resultData) {
+		rows[row.RuleType] = append(rows[row.RuleType], row)
+	}
+	if os.Getenv("DISABLE_SYSTEM_COMPONENT") == "true" {
+		delete(rows, constant.Component)
+	}
+	for _, sheet := range excelSheets {
+		if len(rows[sheet.ruleType]) == 0 {
+			continue
+		}
+		if err = writeExcelRows(f, styles, sheet.ruleType, sheet.columns, rows[sheet.ruleType]); err != nil {
+			return err
 		}
 	}
 
-	// Create a new sheet for fileChange
-	if resultData.Spec.FileChangeResult != nil {
-		_, err := f.NewSheet(constant.FileChange)
-		if err != nil {
-			return err
+	f.SetActiveSheet(0)
+	return f.Write(w)
+}
+
+func newExcelStyles(f *excelize.File) (*excelStyles, error) {
+	var err error
+	styles := &excelStyles{levels: map[string]int{}}
+	if styles.title, err = f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 16}}); err != nil {
+		return nil, err
+	}
+	if styles.label, err = f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Color: "5F6B7A"}}); err != nil {
+		return nil, err
+	}
+	if styles.header, err = f.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		Fill:   excelize.Fill{Type: "pattern", Color: []string{"D9E1F2"}, Pattern: 1},
+		Border: []excelize.Border{{Type: "bottom", Color: "8EA0BC", Style: 1}},
+	}); err != nil {
+		return nil, err
+	}
+	for level, color := range excelLevelFills {
+		if styles.levels[level], err = f.NewStyle(&excelize.Style{Fill: excelize.Fill{Type: "pattern", Color: []string{color}, Pattern: 1}}); err != nil {
+			return nil, err
 		}
+	}
+	return styles, nil
+}
 
-		fileChangeResults := GetFileChange(resultData.Spec.FileChangeResult)
-		// Write the data to the sheet
-		for i, item := range fileChangeResults {
-			if i == 0 {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.FileChange, fmt.Sprintf("%c1", 'A'+rune(j)), c.Text)
-				}
-			} else {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.FileChange, fmt.Sprintf("%c%d", 'A'+rune(j), i+1), c.Text)
-				}
+// newExcelSheet creates the sheet and prepares it for streaming: a frozen header row, an autofilter over the
+// header and rows and, when levelRows is set, row colors by the level in column A. These settings belong to the
+// worksheet and have to be made before the stream writer takes it over.
+func newExcelSheet(f *excelize.File, name string, columns int, rows int, levelRows bool) (*excelize.StreamWriter, error) {
+	if _, err := f.NewSheet(name); err != nil {
+		return nil, err
+	}
+	lastColumn, err := excelize.ColumnNumberToName(columns)
+	if err != nil {
+		return nil, err
+	}
+	if err = f.AutoFilter(name, fmt.Sprintf("A1:%s%d", lastColumn, rows+1), nil); err != nil {
+		return nil, err
+	}
+	if levelRows && rows > 0 {
+		var formats []excelize.ConditionalFormatOptions
+		for _, level := range summaryLevels {
+			format, err := f.NewConditionalStyle(&excelize.Style{Fill: excelize.Fill{Type: "pattern", Color: []string{excelLevelFills[level]}, Pattern: 1}})
+			if err != nil {
+				return nil, err
 			}
+			formats = append(formats, excelize.ConditionalFormatOptions{Type: "formula", Criteria: fmt.Sprintf(`$A2="%s"`, level), Format: format})
+		}
+		if err = f.SetConditionalFormat(name, fmt.Sprintf("A2:%s%d", lastColumn, rows+1), formats); err != nil {
+			return nil, err
 		}
 	}
 
-	// Create a new sheet for sysctl
-	if resultData.Spec.SysctlResult != nil {
-		_, err := f.NewSheet(constant.Sysctl)
-		if err != nil {
-			return err
-		}
+	sw, err := f.NewStreamWriter(name)
+	if err != nil {
+		return nil, err
+	}
+	err = sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft",
+		Selection: []excelize.Selection{{SQRef: "A2", ActiveCell: "A2", Pane: "bottomLeft"}}})
+	return sw, err
+}
 
-		sysctlResults := GetSysctl(resultData.Spec.SysctlResult)
-		// Write the data to the sheet
-		for i, item := range sysctlResults {
-			if i == 0 {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.Sysctl, fmt.Sprintf("%c1", 'A'+rune(j)), c.Text)
-				}
-			} else {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.Sysctl, fmt.Sprintf("%c%d", 'A'+rune(j), i+1), c.Text)
-				}
-			}
+func writeExcelRows(f *excelize.File, styles *excelStyles, name string, columns []excelColumn, rows []ResultRow) error {
+	sw, err := newExcelSheet(f, name, len(columns), len(rows), true)
+	if err != nil {
+		return err
+	}
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		if err = sw.SetColWidth(i+1, i+1, column.width); err != nil {
+			return err
 		}
+		header[i] = excelize.Cell{StyleID: styles.header, Value: column.title}
 	}
-
-	// Create a new sheet for filefilter
-	if resultData.Spec.FileFilterResult != nil {
-		_, err := f.NewSheet(constant.FileFilter)
+	if err = sw.SetRow("A1", header); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		values := make([]interface{}, len(columns))
+		for j, column := range columns {
+			values[j] = column.value(row)
+		}
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
 		if err != nil {
 			return err
 		}
+		if err = sw.SetRow(cell, values); err != nil {
+			return err
+		}
+	}
+	return sw.Flush()
+}
 
-		fileFilterResults := GetFileFilter(resultData.Spec.FileFilterResult)
-		// Write the data to the sheet
-		for i, item := range fileFilterResults {
-			if i == 0 {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.FileFilter, fmt.Sprintf("%c1", 'A'+rune(j)), c.Text)
-				}
+// writeExcelRenderNodes writes the rows built for the html report, the first one is the header.
+func writeExcelRenderNodes(f *excelize.File, styles *excelStyles, name string, nodes []renderNode) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+	sw, err := newExcelSheet(f, name, len(nodes[0].Children), len(nodes)-1, false)
+	if err != nil {
+		return err
+	}
+	if err = sw.SetColWidth(1, len(nodes[0].Children), 24); err != nil {
+		return err
+	}
+	for i, node := range nodes {
+		values := make([]interface{}, len(node.Children))
+		for j, c := range node.Children {
+			if node.Header {
+				values[j] = excelize.Cell{StyleID: styles.header, Value: c.Text}
 			} else {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.FileFilter, fmt.Sprintf("%c%d", 'A'+rune(j), i+1), c.Text)
-				}
+				values[j] = c.Text
 			}
 		}
-	}
-
-	// Create a new sheet for command
-	if resultData.Spec.CommandResult != nil {
-		_, err := f.NewSheet(constant.CustomCommand)
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
 		if err != nil {
 			return err
 		}
-
-		commandResults := GetCommand(resultData.Spec.CommandResult)
-		// Write the data to the sheet
-		for i, item := range commandResults {
-			if i == 0 {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.CustomCommand, fmt.Sprintf("%c1", 'A'+rune(j)), c.Text)
-				}
-			} else {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.CustomCommand, fmt.Sprintf("%c%d", 'A'+rune(j), i+1), c.Text)
-				}
-			}
+		if err = sw.SetRow(cell, values); err != nil {
+			return err
 		}
 	}
+	return sw.Flush()
+}
 
-	// Create a new sheet for nodeinfo
-	if resultData.Spec.NodeInfo != nil {
-		_, err := f.NewSheet(constant.NodeInfo)
-		if err != nil {
-			return err
+// writeExcelSummary writes the cluster information, the findings per level and the rules and findings per category.
+func writeExcelSummary(f *excelize.File, styles *excelStyles, resultData *kubeeyev1alpha2.InspectResult) error {
+	data := NewReportData(resultData)
+	sw, err := f.NewStreamWriter(excelSummarySheet)
+	if err != nil {
+		return err
+	}
+	if err = sw.SetColWidth(1, 1, 24); err != nil {
+		return err
+	}
+	if err = sw.SetColWidth(2, 6, 14); err != nil {
+		return err
+	}
+
+	var rows [][]interface{}
+	rows = append(rows, []interface{}{excelize.Cell{StyleID: styles.title, Value: "KubeEye inspection report"}}, nil)
+	for _, item := range [][2]interface{}{
+		{"cluster", data.Cluster},
+		{"result", data.Name},
+		{"plan", data.Plan},
+		{"task", data.Task},
+		{"start time", resultData.Annotations[constant.AnnotationStartTime]},
+		{"duration", resultData.Status.Duration},
+		{"score", data.Score},
+		{"issues", data.Total},
+	} {
+		if item[1] == "" {
+			continue
 		}
+		rows = append(rows, []interface{}{excelize.Cell{StyleID: styles.label, Value: item[0]}, item[1]})
+	}
 
-		nodeInfoResults := GetNodeInfo(resultData.Spec.NodeInfo)
-		// Write the data to the sheet
-		for i, item := range nodeInfoResults {
-			if i == 0 {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.NodeInfo, fmt.Sprintf("%c1", 'A'+rune(j)), c.Text)
-				}
-			} else {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.NodeInfo, fmt.Sprintf("%c%d", 'A'+rune(j), i+1), c.Text)
-				}
-			}
+	rows = append(rows, nil, excelHeader(styles, "level", "issues"))
+	for _, level := range summaryLevels {
+		rows = append(rows, []interface{}{excelize.Cell{StyleID: styles.levels[level], Value: level}, data.Levels[level]})
+	}
+
+	rows = append(rows, nil, excelHeader(styles, "category", "rules", "danger", "warning", "ignore", "issues"))
+	sections := map[string]ReportSection{}
+	for _, section := range data.RuleTypes {
+		sections[section.Name] = section
+	}
+	var categories []string
+	for category := range resultData.Spec.InspectRuleTotal {
+		categories = append(categories, category)
+	}
+	for category := range sections {
+		if _, exist := resultData.Spec.InspectRuleTotal[category]; !exist {
+			categories = append(categories, category)
 		}
 	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		section := sections[category]
+		rows = append(rows, []interface{}{category, resultData.Spec.InspectRuleTotal[category],
+			section.Levels["danger"], section.Levels["warning"], section.Levels["ignore"], section.Total})
+	}
 
-	// Create a new sheet for serviceconnect
-	if resultData.Spec.ServiceConnectResult != nil {
-		_, err := f.NewSheet(constant.ServiceConnect)
+	for i, row := range rows {
+		if row == nil {
+			continue
+		}
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
 		if err != nil {
 			return err
 		}
-
-		serviceConnectResults := GetServiceConnect(resultData.Spec.ServiceConnectResult)
-		// Write the data to the sheet
-		for i, item := range serviceConnectResults {
-			if i == 0 {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.ServiceConnect, fmt.Sprintf("%c1", 'A'+rune(j)), c.Text)
-				}
-			} else {
-				for j, c := range item.Children {
-					f.SetCellValue(constant.ServiceConnect, fmt.Sprintf("%c%d", 'A'+rune(j), i+1), c.Text)
-				}
-			}
+		if err = sw.SetRow(cell, row); err != nil {
+			return err
 		}
 	}
-	f.DeleteSheet("Sheet1")
-	if err := f.SaveAs(fmt.Sprintf("%s.xlsx", path.Join(constant.ResultPathPrefix, resultData.Name))); err != nil {
-		return err
-	}
+	return sw.Flush()
+}
 
-	return nil
+func excelHeader(styles *excelStyles, titles ...string) []interface{} {
+	header := make([]interface{}, len(titles))
+	for i, title := range titles {
+		header[i] = excelize.Cell{StyleID: styles.header, Value: title}
+	}
+	return header
 }
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExcelOut(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExcelOut(&buf, testResult(), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("invalid workbook: %s", err)
+	}
+	defer f.Close()
+	if sheets := f.GetSheetList(); len(sheets) < 2 || sheets[0] != excelSummarySheet {
+		t.Errorf("sheets = %v, want the summary first and a sheet per rule type", sheets)
+	}
+}
+
+func TestGenerateExcel(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "result.xlsx")
+	if err := GenerateExcel(file, testResult(), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenFile(file)
+	if err != nil {
+		t.Fatalf("invalid workbook: %s", err)
+	}
+	defer f.Close()
+	if sheets := f.GetSheetList(); len(sheets) == 0 || sheets[0] != excelSummarySheet {
+		t.Errorf("sheets = %v, want the summary first", sheets)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "result.xlsx")
+	if err := os.WriteFile(file, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := errors.New("render failed")
+	err := writeFileAtomic(file, func(w io.Writer) error {
+		_, _ = w.Write([]byte("partial"))
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("writeFileAtomic() error = %v, want %v", err, failed)
+	}
+	if data, _ := os.ReadFile(file); string(data) != "previous" {
+		t.Errorf("a failed write changed the file to %q", data)
+	}
+
+	if err = writeFileAtomic(file, func(w io.Writer) error {
+		_, err := w.Write([]byte("complete"))
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != "complete" {
+		t.Errorf("file = %q after a successful write", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
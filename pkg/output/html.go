@@ -20,24 +20,42 @@ type renderNode struct {
 }
 
 func HtmlOut(resultName string) (error, map[string]interface{}) {
+	results, err := ReadResult(resultName)
+	if err != nil {
+		return err, nil
+	}
+	return nil, HtmlData(results)
+}
+
+// ReadResult reads the full inspect result saved by the result controller.
+func ReadResult(resultName string) (*v1alpha2.InspectResult, error) {
+	return ReadResultFile(path.Join(constant.ResultPathPrefix, resultName))
+}
 
+// ReadResultFile reads an inspect result saved as json.
+func ReadResultFile(file string) (*v1alpha2.InspectResult, error) {
 	var results v1alpha2.InspectResult
 
-	open, err := os.Open(path.Join(constant.ResultPathPrefix, resultName))
+	open, err := os.Open(file)
 	if err != nil {
-		return err, nil
+		return nil, err
 	}
 	defer open.Close()
 
 	all, err := io.ReadAll(open)
 	if err != nil {
-		return err, nil
+		return nil, err
 	}
 
 	err = json.Unmarshal(all, &results)
 	if err != nil {
-		return err, nil
+		return nil, err
 	}
+	return &results, nil
+}
+
+// HtmlData builds the data rendered by the inspect result html template.
+func HtmlData(results *v1alpha2.InspectResult) map[string]interface{} {
 	var resultCollection = make(map[string][]renderNode, 5)
 
 	if results.Spec.OpaResult.ResourceResults != nil {
@@ -98,7 +116,7 @@ func HtmlOut(resultName string) (error, map[string]interface{}) {
 		data = map[string]interface{}{"title": results.Annotations[constant.AnnotationStartTime], "details": resultCollection}
 	}
 
-	return nil, data
+	return data
 }
 
 func GetOpaList(result []v1alpha2.ResourceResult) (opaList []renderNode) {
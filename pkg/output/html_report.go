@@ -0,0 +1,88 @@
+package output
+
+import (
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	"github.com/kubesphere/kubeeye/pkg/template"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+)
+
+// ReportCheck is a check in the per-node view, which also lists the checks that passed.
+type ReportCheck struct {
+	ResultRow
+	Passed bool
+}
+
+// NodeView groups every node scoped check (sysctl, systemd, nodeInfo, fileChange, command, ...) of one node.
+type NodeView struct {
+	Name   string
+	Failed int
+	Passed int
+	Checks []ReportCheck
+}
+
+// htmlReport is the data of the self-contained html report.
+type htmlReport struct {
+	*ReportData
+	LevelChart     htmltemplate.HTML
+	CategoryChart  htmltemplate.HTML
+	TrendChart     htmltemplate.HTML
+	NodeViews      []NodeView
+	NamespaceViews []ReportSection
+}
+
+// HtmlReportOut writes a standalone single-file html report with inline svg charts, per-node and per-namespace
+// views and search. history holds previous results of the same plan for the score trend, it may be nil.
+func HtmlReportOut(w io.Writer, result *v1alpha2.InspectResult, history []*v1alpha2.InspectResult) error {
+	data := NewReportData(result)
+	if len(history) > 0 {
+		data.Trend = ScoreTrend(append(history, result))
+	}
+	report := htmlReport{
+		ReportData:    data,
+		LevelChart:    LevelDonutSVG(data.Levels),
+		CategoryChart: CategoryBarsSVG(data.RuleTypes),
+		TrendChart:    TrendSVG(data.Trend),
+		NodeViews:     nodeViews(result),
+		NamespaceViews: groupRows(filterRows(data.Findings, func(row ResultRow) bool { return row.RuleType == constant.Opa }),
+			func(row ResultRow) string { return row.Namespace }),
+	}
+
+	reportTemplate, err := template.GetInspectReportHtmlTemplate(ReportFuncs())
+	if err != nil {
+		return err
+	}
+	return reportTemplate.Execute(w, report)
+}
+
+func nodeViews(result *v1alpha2.InspectResult) []NodeView {
+	index := map[string]int{}
+	var views []NodeView
+	for _, row := range flattenResult(result, true) {
+		if row.Node == "" {
+			continue
+		}
+		i, exist := index[row.Node]
+		if !exist {
+			views = append(views, NodeView{Name: row.Node})
+			i = len(views) - 1
+			index[row.Node] = i
+		}
+		if row.passed {
+			views[i].Passed++
+		} else {
+			views[i].Failed++
+		}
+		views[i].Checks = append(views[i].Checks, ReportCheck{ResultRow: row, Passed: row.passed})
+	}
+	// nodes with the most failed checks first
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].Failed != views[j].Failed {
+			return views[i].Failed > views[j].Failed
+		}
+		return views[i].Name < views[j].Name
+	})
+	return views
+}
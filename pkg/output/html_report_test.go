@@ -0,0 +1,53 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHtmlReportGolden(t *testing.T) {
+	checkRenderGolden(t, FormatReport)
+}
+
+func TestLevelDonutSVG(t *testing.T) {
+	got := string(LevelDonutSVG(map[string]int{"danger": 2, "warning": 1}))
+	if strings.Count(got, "<title>") != 2 {
+		t.Errorf("expected one arc per non empty level, got %s", got)
+	}
+	if !strings.Contains(got, `font-weight="bold">3</text>`) {
+		t.Errorf("expected the total in the middle, got %s", got)
+	}
+
+	empty := string(LevelDonutSVG(nil))
+	if strings.Contains(empty, "<title>") || !strings.Contains(empty, `font-weight="bold">0</text>`) {
+		t.Errorf("unexpected chart without findings: %s", empty)
+	}
+}
+
+func TestCategoryBarsSVG(t *testing.T) {
+	got := string(CategoryBarsSVG([]ReportSection{
+		{Name: "<opa>", Total: 3, Levels: map[string]int{"danger": 1, "warning": 2}},
+		{Name: "sysctl", Total: 1, Levels: map[string]int{"ignore": 1}},
+	}))
+	if strings.Count(got, "<rect") != 3 {
+		t.Errorf("expected one rect per section and level, got %s", got)
+	}
+	if strings.Contains(got, "<opa>") || !strings.Contains(got, "&lt;opa&gt;") {
+		t.Errorf("expected the section name to be escaped, got %s", got)
+	}
+}
+
+func TestTrendSVG(t *testing.T) {
+	if got := TrendSVG([]ScorePoint{{Name: "a", Score: 80}}); got != "" {
+		t.Errorf("expected no chart with a single point, got %s", got)
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+	got := string(TrendSVG([]ScorePoint{{Name: "a", Time: now, Score: 100}, {Name: "b", Time: now.Add(time.Hour), Score: 0}}))
+	if !strings.Contains(got, `<polyline points="24.0,24.0 496.0,136.0"`) {
+		t.Errorf("unexpected trend line: %s", got)
+	}
+	if strings.Count(got, "<circle") != 2 || !strings.Contains(got, "b 2024-01-02 04:04: 0") {
+		t.Errorf("expected one point per score, got %s", got)
+	}
+}
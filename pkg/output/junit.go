@@ -0,0 +1,112 @@
+package output
+
+import (
+	"encoding/xml"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"io"
+	"sort"
+)
+
+// JUnitTestSuites is the JUnit XML report format understood by CI test report viewers.
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr,omitempty"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitTestCase struct {
+	Name      string `xml:"name,attr"`
+	ClassName string `xml:"classname,attr"`
+	// Failure is single, many JUnit parsers reject or truncate a test case with several
+	Failure *JUnitResult `xml:"failure,omitempty"`
+	Skipped *JUnitResult `xml:"skipped,omitempty"`
+}
+
+type JUnitResult struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// Fail adds a failure to the test case, the failures of a test case are joined into one of the most severe level.
+func (c *JUnitTestCase) Fail(level string, message string, text string) {
+	if c.Failure == nil {
+		c.Failure = &JUnitResult{Message: message, Type: level, Text: text}
+		return
+	}
+	c.Failure.Message += "; " + message
+	c.Failure.Text += "\n" + text
+	if LevelRank(level) > LevelRank(c.Failure.Type) {
+		c.Failure.Type = level
+	}
+}
+
+// AddSuite appends a suite and updates the counters of both the suite and the report.
+func (j *JUnitTestSuites) AddSuite(suite JUnitTestSuite) {
+	suite.Tests = len(suite.TestCases)
+	suite.Failures = 0
+	for _, testCase := range suite.TestCases {
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+	}
+	j.Tests += suite.Tests
+	j.Failures += suite.Failures
+	j.Suites = append(j.Suites, suite)
+}
+
+func (j *JUnitTestSuites) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(j); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ResultJUnit reports one suite per rule type and one test case per rule, failing with all of its findings.
+func ResultJUnit(result *v1alpha2.InspectResult) *JUnitTestSuites {
+	report := &JUnitTestSuites{Name: "kubeeye"}
+	if result.Spec.InspectCluster.Name != "" {
+		report.Name = "kubeeye/" + result.Spec.InspectCluster.Name
+	}
+	var ruleTypes []string
+	suites := map[string]*JUnitTestSuite{}
+	testCases := map[string]int{}
+	for _, row := range flattenResult(result, true) {
+		suite, exist := suites[row.RuleType]
+		if !exist {
+			suite = &JUnitTestSuite{Name: row.RuleType}
+			suites[row.RuleType] = suite
+			ruleTypes = append(ruleTypes, row.RuleType)
+		}
+		index, exist := testCases[row.ruleID()]
+		if !exist {
+			suite.TestCases = append(suite.TestCases, JUnitTestCase{Name: row.Name, ClassName: "kubeeye." + row.RuleType})
+			index = len(suite.TestCases) - 1
+			testCases[row.ruleID()] = index
+		}
+		if row.passed {
+			continue
+		}
+		suite.TestCases[index].Fail(NormalizeLevel(row.Level), row.Summary(), row.Summary())
+	}
+	sort.Strings(ruleTypes)
+	for _, ruleType := range ruleTypes {
+		report.AddSuite(*suites[ruleType])
+	}
+	return report
+}
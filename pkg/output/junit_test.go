@@ -0,0 +1,66 @@
+package output
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+)
+
+func TestJUnitGolden(t *testing.T) {
+	checkRenderGolden(t, FormatJUnit)
+}
+
+func TestJUnitParses(t *testing.T) {
+	var suites JUnitTestSuites
+	if err := xml.Unmarshal(render(t, FormatJUnit), &suites); err != nil {
+		t.Fatalf("invalid junit: %s", err)
+	}
+	tests, failures := 0, 0
+	for _, suite := range suites.Suites {
+		if suite.Tests != len(suite.TestCases) {
+			t.Errorf("suite %s counts %d tests, has %d", suite.Name, suite.Tests, len(suite.TestCases))
+		}
+		tests += suite.Tests
+		failures += suite.Failures
+	}
+	if suites.Tests != tests || suites.Failures != failures || failures == 0 {
+		t.Errorf("junit counts %d tests and %d failures, suites have %d and %d", suites.Tests, suites.Failures, tests, failures)
+	}
+}
+
+func TestJUnitOneFailurePerTestCase(t *testing.T) {
+	result := &v1alpha2.InspectResult{Spec: v1alpha2.InspectResultSpec{
+		OpaResult: v1alpha2.KubeeyeOpaResult{ResourceResults: []v1alpha2.ResourceResult{
+			{NameSpace: "default", ResourceType: "Deployment", Name: "nginx", ResultItems: []v1alpha2.ResultItem{{Level: "warning", Message: "NoCPULimits"}}},
+			{NameSpace: "default", ResourceType: "Deployment", Name: "redis", ResultItems: []v1alpha2.ResultItem{{Level: "danger", Message: "NoCPULimits"}}},
+		}},
+	}}
+	var buf strings.Builder
+	if err := ResultJUnit(result).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "<failure "); n != 1 {
+		t.Fatalf("junit has %d failure elements, want one for the test case:\n%s", n, buf.String())
+	}
+	var suites JUnitTestSuites
+	if err := xml.Unmarshal([]byte(buf.String()), &suites); err != nil {
+		t.Fatal(err)
+	}
+	failure := suites.Suites[0].TestCases[0].Failure
+	if failure.Type != "danger" || !strings.Contains(failure.Message, "nginx") || !strings.Contains(failure.Message, "redis") {
+		t.Errorf("failure = %+v, want both findings at the most severe level", failure)
+	}
+}
+
+func TestJUnitTestCaseFail(t *testing.T) {
+	var testCase JUnitTestCase
+	testCase.Fail("ignore", "a", "text a")
+	testCase.Fail("warning", "b", "text b")
+	testCase.Fail("ignore", "c", "text c")
+	want := JUnitResult{Message: "a; b; c", Type: "warning", Text: "text a\ntext b\ntext c"}
+	if *testCase.Failure != want {
+		t.Errorf("failure = %+v, want %+v", *testCase.Failure, want)
+	}
+}
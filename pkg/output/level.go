@@ -0,0 +1,32 @@
+package output
+
+import (
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"strings"
+)
+
+// NormalizeLevel maps the level spellings found in rules to danger, warning or ignore.
+func NormalizeLevel(level string) string {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "danger", "dangerous", "error", "critical":
+		return string(v1alpha2.DangerLevel)
+	case "warning", "warn":
+		return string(v1alpha2.WarningLevel)
+	case "ignore", "info":
+		return string(v1alpha2.IgnoreLevel)
+	}
+	return strings.ToLower(level)
+}
+
+// LevelRank orders levels by severity, unknown levels rank lowest.
+func LevelRank(level string) int {
+	switch NormalizeLevel(level) {
+	case string(v1alpha2.DangerLevel):
+		return 3
+	case string(v1alpha2.WarningLevel):
+		return 2
+	case string(v1alpha2.IgnoreLevel):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+package output
+
+import (
+	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DefaultTopFindings is the number of findings listed in the top findings section.
+const DefaultTopFindings = 10
+
+var summaryLevels = []string{string(v1alpha2.DangerLevel), string(v1alpha2.WarningLevel), string(v1alpha2.IgnoreLevel)}
+
+// FindingSummary counts the findings of a result by rule type and level.
+type FindingSummary struct {
+	Total     int
+	Levels    map[string]int
+	RuleTypes []string
+	ByType    map[string]map[string]int
+}
+
+func SummarizeRows(rows []ResultRow) FindingSummary {
+	summary := FindingSummary{Levels: map[string]int{}, ByType: map[string]map[string]int{}}
+	for _, row := range rows {
+		level := NormalizeLevel(row.Level)
+		if _, exist := summary.ByType[row.RuleType]; !exist {
+			summary.ByType[row.RuleType] = map[string]int{}
+			summary.RuleTypes = append(summary.RuleTypes, row.RuleType)
+		}
+		summary.ByType[row.RuleType][level]++
+		summary.Levels[level]++
+		summary.Total++
+	}
+	sort.Strings(summary.RuleTypes)
+	return summary
+}
+
+// TopFindings returns the n most severe findings, keeping the result order within a level.
+func TopFindings(rows []ResultRow, n int) []ResultRow {
+	sorted := make([]ResultRow, len(rows))
+	copy(sorted, rows)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return LevelRank(sorted[i].Level) > LevelRank(sorted[j].Level)
+	})
+	if n > 0 && len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// MarkdownOut writes a summary by level and rule type, the top findings and a collapsible section per rule type.
+func MarkdownOut(w io.Writer, result *v1alpha2.InspectResult, topN int) error {
+	rows := ResultRows(result)
+	summary := SummarizeRows(rows)
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "## KubeEye inspection report: %s\n\n", clusterName(result))
+	if !result.CreationTimestamp.IsZero() {
+		fmt.Fprintf(b, "Inspected at %s", result.CreationTimestamp.Format("2006-01-02 15:04:05"))
+		if result.Name != "" {
+			fmt.Fprintf(b, " (`%s`)", result.Name)
+		}
+		b.WriteString("\n\n")
+	}
+	if summary.Total == 0 {
+		b.WriteString("No issues found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	fmt.Fprintf(b, "**%d issues found**\n\n", summary.Total)
+	b.WriteString("| Rule type | Danger | Warning | Ignore | Total |\n")
+	b.WriteString("| --- | ---: | ---: | ---: | ---: |\n")
+	for _, ruleType := range summary.RuleTypes {
+		counts := summary.ByType[ruleType]
+		fmt.Fprintf(b, "| %s | %d | %d | %d | %d |\n", ruleType, counts[summaryLevels[0]], counts[summaryLevels[1]], counts[summaryLevels[2]], sumCounts(counts))
+	}
+	fmt.Fprintf(b, "| **Total** | **%d** | **%d** | **%d** | **%d** |\n\n", summary.Levels[summaryLevels[0]], summary.Levels[summaryLevels[1]], summary.Levels[summaryLevels[2]], summary.Total)
+
+	if topN <= 0 {
+		topN = DefaultTopFindings
+	}
+	fmt.Fprintf(b, "### Top %d findings\n\n", min(topN, len(rows)))
+	for i, row := range TopFindings(rows, topN) {
+		fmt.Fprintf(b, "%d. **%s** `%s` %s\n", i+1, NormalizeLevel(row.Level), row.RuleType, escapeMarkdown(row.Summary()))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("### Details\n\n")
+	for _, ruleType := range summary.RuleTypes {
+		fmt.Fprintf(b, "<details>\n<summary>%s (%d)</summary>\n\n", ruleType, sumCounts(summary.ByType[ruleType]))
+		b.WriteString("| Level | Name | Node | Namespace | Resource | Message | Value |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+		for _, row := range rows {
+			if row.RuleType != ruleType {
+				continue
+			}
+			fmt.Fprintf(b, "| %s |\n", strings.Join([]string{
+				NormalizeLevel(row.Level), markdownCell(row.Name), markdownCell(row.Node), markdownCell(row.Namespace),
+				markdownCell(row.Resource), markdownCell(row.Message), markdownCell(row.Value),
+			}, " | "))
+		}
+		b.WriteString("\n</details>\n\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// TextOut writes the same summary as MarkdownOut as plain text, for channels that do not render markdown.
+func TextOut(w io.Writer, result *v1alpha2.InspectResult, topN int) error {
+	rows := ResultRows(result)
+	summary := SummarizeRows(rows)
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "KubeEye inspection report: %s\n", clusterName(result))
+	if summary.Total == 0 {
+		b.WriteString("No issues found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	fmt.Fprintf(b, "%d issues found: danger %d, warning %d, ignore %d\n", summary.Total,
+		summary.Levels[summaryLevels[0]], summary.Levels[summaryLevels[1]], summary.Levels[summaryLevels[2]])
+	for _, ruleType := range summary.RuleTypes {
+		fmt.Fprintf(b, "  %s: %d\n", ruleType, sumCounts(summary.ByType[ruleType]))
+	}
+	if topN <= 0 {
+		topN = DefaultTopFindings
+	}
+	fmt.Fprintf(b, "\nTop %d findings:\n", min(topN, len(rows)))
+	for i, row := range TopFindings(rows, topN) {
+		fmt.Fprintf(b, "%d. [%s] %s %s\n", i+1, NormalizeLevel(row.Level), row.RuleType, strings.ReplaceAll(row.Summary(), "\n", " "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func clusterName(result *v1alpha2.InspectResult) string {
+	if result.Spec.InspectCluster.Name != "" {
+		return result.Spec.InspectCluster.Name
+	}
+	return "default"
+}
+
+func sumCounts(counts map[string]int) (n int) {
+	for _, c := range counts {
+		n += c
+	}
+	return n
+}
+
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "\r\n", "<br>")
+	text = strings.ReplaceAll(text, "\n", "<br>")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+func escapeMarkdown(text string) string {
+	return strings.NewReplacer("\n", " ", "*", "\\*", "_", "\\_", "`", "\\`").Replace(text)
+}
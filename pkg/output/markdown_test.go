@@ -0,0 +1,61 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+)
+
+func TestMarkdownGolden(t *testing.T) {
+	for _, format := range []string{FormatMarkdown, FormatText} {
+		t.Run(format, func(t *testing.T) {
+			checkRenderGolden(t, format)
+		})
+	}
+}
+
+func TestMarkdownNoIssues(t *testing.T) {
+	result := &v1alpha2.InspectResult{Spec: v1alpha2.InspectResultSpec{InspectCluster: v1alpha2.Cluster{Name: "host"}}}
+	for _, out := range []func(*bytes.Buffer) error{
+		func(b *bytes.Buffer) error { return MarkdownOut(b, result, 0) },
+		func(b *bytes.Buffer) error { return TextOut(b, result, 0) },
+	} {
+		var buf bytes.Buffer
+		if err := out(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "host") || !strings.HasSuffix(buf.String(), "No issues found.\n") {
+			t.Errorf("unexpected report without findings:\n%s", buf.String())
+		}
+	}
+}
+
+func TestMarkdownTopFindings(t *testing.T) {
+	var buf bytes.Buffer
+	if err := MarkdownOut(&buf, testResult(), 2); err != nil {
+		t.Fatal(err)
+	}
+	section := buf.String()[strings.Index(buf.String(), "### Top 2 findings"):strings.Index(buf.String(), "### Details")]
+	if strings.Count(section, "**danger**") != 2 || strings.Contains(section, "**warning**") {
+		t.Errorf("the top findings should be the most severe:\n%s", section)
+	}
+}
+
+func TestMarkdownCell(t *testing.T) {
+	tests := map[string]string{
+		"a|b":           `a\|b`,
+		"line\nbreak":   "line<br>break",
+		"crlf\r\nbreak": "crlf<br>break",
+		"  trimmed \n":  "trimmed",
+	}
+	for in, want := range tests {
+		if got := markdownCell(in); got != want {
+			t.Errorf("markdownCell(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := escapeMarkdown("a*b_c`d\ne"); got != "a\\*b\\_c\\`d e" {
+		t.Errorf("escapeMarkdown() = %q", got)
+	}
+}
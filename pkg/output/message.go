@@ -0,0 +1,90 @@
+package output
+
+import (
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	"github.com/kubesphere/kubeeye/pkg/utils"
+)
+
+// MessageSummary summarizes the result for notifications, with its top most severe findings.
+func MessageSummary(result *v1alpha2.InspectResult, top int) (*conf.MessageSummary, []conf.MessageFinding) {
+	data := NewReportData(result)
+	summary := &conf.MessageSummary{
+		Cluster:   data.Cluster,
+		Result:    data.Name,
+		Plan:      data.Plan,
+		Task:      data.Task,
+		Score:     data.Score,
+		Total:     data.Total,
+		Levels:    data.Levels,
+		RuleTypes: map[string]int{},
+	}
+	for _, section := range data.RuleTypes {
+		summary.RuleTypes[section.Name] = section.Total
+	}
+	return summary, MessageFindings(TopFindings(data.Findings, top))
+}
+
+// MessageFindings converts the rows to notification findings with normalized levels.
+func MessageFindings(rows []ResultRow) []conf.MessageFinding {
+	var findings []conf.MessageFinding
+	for _, row := range rows {
+		findings = append(findings, messageFinding(row))
+	}
+	return findings
+}
+
+func messageFinding(row ResultRow) conf.MessageFinding {
+	return conf.MessageFinding{
+		RuleType:  row.RuleType,
+		Name:      row.Name,
+		Level:     NormalizeLevel(row.Level),
+		Node:      row.Node,
+		Namespace: row.Namespace,
+		Resource:  row.Resource,
+		Message:   row.Message,
+	}
+}
+
+// FilterResult returns a copy of the result keeping only the given findings, e.g. the findings a route sent to a
+// channel, so every format renders just those. The checks that passed are kept.
+func FilterResult(result *v1alpha2.InspectResult, findings []conf.MessageFinding) *v1alpha2.InspectResult {
+	keep := map[conf.MessageFinding]bool{}
+	for _, finding := range findings {
+		keep[finding] = true
+	}
+	match := func(row ResultRow) bool { return row.passed || keep[messageFinding(row)] }
+
+	filtered := result.DeepCopy()
+	spec := &filtered.Spec
+	cluster := spec.InspectCluster.Name
+	var resources []v1alpha2.ResourceResult
+	for _, resource := range spec.OpaResult.ResourceResults {
+		resource.ResultItems, _ = utils.ArrayFilter(resource.ResultItems, func(item v1alpha2.ResultItem) bool { return match(opaRow(cluster, resource, item)) })
+		if len(resource.ResultItems) > 0 {
+			resources = append(resources, resource)
+		}
+	}
+	spec.OpaResult.ResourceResults = resources
+	spec.PrometheusResult, _ = utils.ArrayFilter(spec.PrometheusResult, func(item v1alpha2.PrometheusResult) bool { return match(prometheusRow(cluster, item)) })
+	spec.FileChangeResult, _ = utils.ArrayFilter(spec.FileChangeResult, func(item v1alpha2.FileChangeResultItem) bool {
+		return match(fileRow(cluster, constant.FileChange, item))
+	})
+	spec.FileFilterResult, _ = utils.ArrayFilter(spec.FileFilterResult, func(item v1alpha2.FileChangeResultItem) bool {
+		return match(fileRow(cluster, constant.FileFilter, item))
+	})
+	spec.SysctlResult, _ = utils.ArrayFilter(spec.SysctlResult, func(item v1alpha2.NodeMetricsResultItem) bool {
+		return match(metricsRow(cluster, constant.Sysctl, item))
+	})
+	spec.SystemdResult, _ = utils.ArrayFilter(spec.SystemdResult, func(item v1alpha2.NodeMetricsResultItem) bool {
+		return match(metricsRow(cluster, constant.Systemd, item))
+	})
+	spec.NodeInfo, _ = utils.ArrayFilter(spec.NodeInfo, func(item v1alpha2.NodeInfoResultItem) bool { return match(nodeInfoRow(cluster, item)) })
+	spec.CommandResult, _ = utils.ArrayFilter(spec.CommandResult, func(item v1alpha2.CommandResultItem) bool { return match(commandRow(cluster, item)) })
+	spec.ComponentResult, _ = utils.ArrayFilter(spec.ComponentResult, func(item v1alpha2.ComponentResultItem) bool { return match(componentRow(cluster, item)) })
+	spec.ServiceConnectResult, _ = utils.ArrayFilter(spec.ServiceConnectResult, func(item v1alpha2.ServiceConnectResultItem) bool {
+		return match(serviceConnectRow(cluster, item))
+	})
+	return filtered
+}
@@ -0,0 +1,50 @@
+package output
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubesphere/kubeeye/pkg/conf"
+)
+
+func TestFilterResult(t *testing.T) {
+	result := testResult()
+	findings := MessageFindings(ResultRows(result))
+	tests := []struct {
+		name string
+		keep []conf.MessageFinding
+	}{
+		{name: "all findings", keep: findings},
+		{name: "no finding", keep: nil},
+		{name: "one opa item of a resource", keep: []conf.MessageFinding{findings[1]}},
+		{name: "findings of several rule types", keep: []conf.MessageFinding{findings[0], findings[2], findings[len(findings)-1]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := FilterResult(result, tt.keep)
+			if got := MessageFindings(ResultRows(filtered)); !reflect.DeepEqual(got, tt.keep) {
+				t.Errorf("filtered findings = %+v, want %+v", got, tt.keep)
+			}
+			passed := 0
+			for _, row := range flattenResult(filtered, true) {
+				if row.passed {
+					passed++
+				}
+			}
+			if passed != 1 {
+				t.Errorf("filtered result has %d passed checks, want the passed sysctl check kept", passed)
+			}
+		})
+	}
+	if !reflect.DeepEqual(result, testResult()) {
+		t.Error("FilterResult changed the result")
+	}
+}
+
+func TestFilterResultDropsEmptyResources(t *testing.T) {
+	findings := MessageFindings(ResultRows(testResult()))
+	filtered := FilterResult(testResult(), findings[2:])
+	if len(filtered.Spec.OpaResult.ResourceResults) != 0 {
+		t.Errorf("kept opa resources without findings: %+v", filtered.Spec.OpaResult.ResourceResults)
+	}
+}
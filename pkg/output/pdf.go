@@ -0,0 +1,111 @@
+package output
+
+import (
+	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"io"
+	"strings"
+)
+
+// PdfOut writes the report as pdf: a cover page with the score, a summary by level and category and the findings of each category.
+// Latin text uses the standard Helvetica fonts, only CJK text needs a reader with the Adobe Asian font pack.
+func PdfOut(out io.Writer, result *v1alpha2.InspectResult) error {
+	data := NewReportData(result)
+	w := newPdfWriter()
+
+	// cover page
+	w.y = pdfPageHeight * 0.7
+	w.paragraph(28, pdfBlack, true, "KubeEye Inspection Report")
+	w.y -= 10
+	w.paragraph(16, pdfGray, false, data.Cluster)
+	w.y -= 40
+	w.text(pdfMargin, w.y-48, 48, scoreColor(data.Score), true, fmt.Sprint(data.Score))
+	w.text(pdfMargin+textWidth(fmt.Sprint(data.Score), 48, true)+8, w.y-48, 14, pdfGray, false, "/ 100 score")
+	w.y -= 80
+	for _, item := range [][2]string{
+		{"Result", data.Name},
+		{"Plan", data.Plan},
+		{"Inspected at", formatReportTime(data)},
+		{"Issues", fmt.Sprintf("%d (danger %d, warning %d, ignore %d)", data.Total, data.Levels["danger"], data.Levels["warning"], data.Levels["ignore"])},
+	} {
+		if item[1] == "" {
+			continue
+		}
+		w.text(pdfMargin, w.y, 11, pdfGray, false, item[0])
+		w.text(pdfMargin+100, w.y, 11, pdfBlack, false, item[1])
+		w.y -= 20
+	}
+
+	// summary
+	w.addPage()
+	w.paragraph(18, pdfBlack, true, "Summary")
+	w.y -= 6
+	barWidth := pdfPageWidth - 2*pdfMargin
+	x := pdfMargin
+	for _, level := range summaryLevels {
+		if data.Total == 0 || data.Levels[level] == 0 {
+			continue
+		}
+		width := barWidth * float64(data.Levels[level]) / float64(data.Total)
+		w.rect(x, w.y-14, width, 14, pdfLevelColors[level])
+		if width > 30 {
+			w.text(x+4, w.y-11, 8, pdfWhite, true, fmt.Sprintf("%s %d", level, data.Levels[level]))
+		}
+		x += width
+	}
+	if data.Total == 0 {
+		w.paragraph(11, pdfGray, false, "No issues found.")
+	} else {
+		w.y -= 28
+	}
+	var summaryRows [][]string
+	for _, section := range data.RuleTypes {
+		summaryRows = append(summaryRows, []string{section.Name, fmt.Sprint(section.Levels["danger"]), fmt.Sprint(section.Levels["warning"]),
+			fmt.Sprint(section.Levels["ignore"]), fmt.Sprint(section.Total)})
+	}
+	if len(summaryRows) > 0 {
+		w.table([]pdfColumn{{"Category", 0.4}, {"Danger", 0.15}, {"Warning", 0.15}, {"Ignore", 0.15}, {"Total", 0.15}}, summaryRows, -1)
+	}
+	if len(data.Nodes) > 0 {
+		w.paragraph(12, pdfBlack, true, "Nodes")
+		var nodeRows [][]string
+		for _, section := range data.Nodes {
+			nodeRows = append(nodeRows, []string{section.Name, fmt.Sprint(section.Levels["danger"]), fmt.Sprint(section.Levels["warning"]),
+				fmt.Sprint(section.Levels["ignore"]), fmt.Sprint(section.Total)})
+		}
+		w.table([]pdfColumn{{"Node", 0.4}, {"Danger", 0.15}, {"Warning", 0.15}, {"Ignore", 0.15}, {"Total", 0.15}}, nodeRows, -1)
+	}
+
+	// findings
+	for _, section := range data.RuleTypes {
+		w.addPage()
+		w.paragraph(16, pdfBlack, true, fmt.Sprintf("%s (%d)", section.Name, section.Total))
+		w.y -= 4
+		var rows [][]string
+		for _, row := range section.Findings {
+			target := strings.TrimSpace(strings.Join([]string{row.Node, row.Namespace}, " "))
+			rows = append(rows, []string{NormalizeLevel(row.Level), row.Name, target, row.Resource, row.Message, row.Value})
+		}
+		w.table([]pdfColumn{{"Level", 0.09}, {"Name", 0.2}, {"Node/Namespace", 0.15}, {"Resource", 0.18}, {"Message", 0.28}, {"Value", 0.1}}, rows, 0)
+	}
+
+	w.footer(fmt.Sprintf("KubeEye - %s - %s", data.Cluster, data.Name))
+	return w.writeTo(out)
+}
+
+func scoreColor(score int) pdfColor {
+	switch {
+	case score >= 80:
+		return pdfColor{0.33, 0.74, 0.54}
+	case score >= 60:
+		return pdfLevelColors["warning"]
+	}
+	return pdfLevelColors["danger"]
+}
+
+func formatReportTime(data *ReportData) string {
+	if data.CreatedAt.IsZero() {
+		return ""
+	}
+	return data.CreatedAt.Format("2006-01-02 15:04:05")
+}
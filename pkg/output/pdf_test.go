@@ -0,0 +1,154 @@
+package output
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	pdfStartXref = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	pdfTrailer   = regexp.MustCompile(`trailer\n<< /Size (\d+) /Root 1 0 R >>`)
+	pdfStream    = regexp.MustCompile(`(?s)<< /Length (\d+) /Filter /FlateDecode >>\nstream\n`)
+	pdfPageCount = regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`)
+)
+
+func TestPdfStructure(t *testing.T) {
+	var buf bytes.Buffer
+	if err := PdfOut(&buf, testResult()); err != nil {
+		t.Fatal(err)
+	}
+	pdf := buf.Bytes()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) {
+		t.Fatal("missing pdf header")
+	}
+
+	match := pdfStartXref.FindSubmatch(pdf)
+	if match == nil {
+		t.Fatal("missing startxref or EOF marker at the end of the file")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d doesn't point at the xref table", xref)
+	}
+	trailer := pdfTrailer.FindSubmatch(pdf)
+	if trailer == nil {
+		t.Fatal("missing trailer")
+	}
+	size, _ := strconv.Atoi(string(trailer[1]))
+
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	if lines[1] != fmt.Sprintf("0 %d", size) {
+		t.Fatalf("xref subsection %q, want %d objects", lines[1], size)
+	}
+	for object := 1; object < size; object++ {
+		entry := lines[2+object]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("object %d has xref entry %q", object, entry)
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj\n", object); !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("xref offset %d of object %d points at %q", offset, object, pdf[offset:offset+len(want)])
+		}
+	}
+
+	streams := pdfStream.FindAllSubmatchIndex(pdf, -1)
+	pages := pdfPageCount.FindSubmatch(pdf)
+	if pages == nil || string(pages[1]) != strconv.Itoa(len(streams)) {
+		t.Fatalf("page count %s, %d content streams", pages, len(streams))
+	}
+	for _, stream := range streams {
+		length, _ := strconv.Atoi(string(pdf[stream[2]:stream[3]]))
+		end := stream[1] + length
+		if !bytes.HasPrefix(pdf[end:], []byte("\nendstream")) {
+			t.Fatalf("stream at %d is not %d bytes long", stream[0], length)
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(pdf[stream[1]:end]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("stream at %d doesn't inflate: %s", stream[0], err)
+		}
+		if !bytes.Contains(content, []byte(" Tj ET")) {
+			t.Errorf("page at %d has no text", stream[0])
+		}
+	}
+
+	for _, font := range []string{"/BaseFont /Helvetica ", "/BaseFont /Helvetica-Bold ", "/BaseFont /STSong-Light "} {
+		if !bytes.Contains(pdf, []byte(font)) {
+			t.Errorf("missing font %s", font)
+		}
+	}
+}
+
+func TestPdfText(t *testing.T) {
+	w := newPdfWriter()
+	w.text(10, 20, 8, pdfBlack, false, "Pod (a)")
+	w.text(10, 20, 8, pdfBlack, true, "巡检 ok")
+	want := "BT 0.140 0.180 0.260 rg 0.140 0.180 0.260 RG 0.3 w 10.00 20.00 Td 0 Tr /F1 8.0 Tf (Pod \\(a\\)) Tj ET\n" +
+		"BT 0.140 0.180 0.260 rg 0.140 0.180 0.260 RG 0.3 w 10.00 20.00 Td 2 Tr /F3 8.0 Tf <5DE168C0> Tj 0 Tr /F2 8.0 Tf ( ok) Tj ET\n"
+	if got := w.page.String(); got != want {
+		t.Errorf("unexpected content stream:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPdfRuns(t *testing.T) {
+	for text, want := range map[string][]pdfRun{
+		"":          nil,
+		"abc":       {{Latin: true, Text: "abc"}},
+		"巡检":        {{Text: "巡检"}},
+		"a巡检 b":     {{Latin: true, Text: "a"}, {Text: "巡检"}, {Latin: true, Text: " b"}},
+		"café\tbar": {{Latin: true, Text: "caf"}, {Text: "é"}, {Latin: true, Text: "\tbar"}},
+	} {
+		if got := pdfRuns(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("pdfRuns(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestPdfTextWidth(t *testing.T) {
+	if got := textWidth("Ab", 10, false); got != 12.23 {
+		t.Errorf("regular width %v, want 12.23", got)
+	}
+	if got := textWidth("Ab", 10, true); got != 13.33 {
+		t.Errorf("bold width %v, want 13.33", got)
+	}
+	if got := textWidth("巡\t", 10, false); got != 12.78 {
+		t.Errorf("mixed width %v, want 12.78", got)
+	}
+}
+
+func TestPdfLiteralString(t *testing.T) {
+	for text, want := range map[string]string{
+		"Ab":      "(Ab)",
+		"f(x)\\y": "(f\\(x\\)\\\\y)",
+		"a\tb":    "(a b)",
+		"":        "()",
+	} {
+		if got := pdfLiteralString(text); got != want {
+			t.Errorf("pdfLiteralString(%q) = %s, want %s", text, got, want)
+		}
+	}
+}
+
+func TestPdfHexString(t *testing.T) {
+	for text, want := range map[string]string{
+		"Ab":   "<00410062>",
+		"巡检":   "<5DE168C0>",
+		"a\tb": "<006100200062>",
+		"x😀":   "<0078003F>",
+		"":     "<>",
+	} {
+		if got := pdfHexString(text); got != want {
+			t.Errorf("pdfHexString(%q) = %s, want %s", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package output
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// A minimal pdf writer for the reports. Latin text uses the standard Helvetica fonts with the WinAnsi encoding,
+// which every reader provides. Runs of other characters use the STSong-Light CID font with the UniGB-UCS2-H
+// encoding, one of the Adobe standard Asian fonts, so CJK text renders without embedding a font file. That font
+// is not embedded: readers without the Adobe Asian font pack, such as many Linux and headless viewers, show only
+// the CJK runs blank, the rest of the report stays readable.
+
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 40.0
+)
+
+// pdfHelveticaWidths and pdfHelveticaBoldWidths are the glyph widths of the printable ASCII characters,
+// other characters use the full width CJK font.
+var (
+	pdfHelveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	pdfHelveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+type pdfColor [3]float64
+
+var (
+	pdfBlack     = pdfColor{0.14, 0.18, 0.26}
+	pdfGray      = pdfColor{0.47, 0.53, 0.61}
+	pdfLightGray = pdfColor{0.93, 0.94, 0.96}
+	pdfWhite     = pdfColor{1, 1, 1}
+)
+
+// pdfLevelColors mirror the colors of the html report.
+var pdfLevelColors = map[string]pdfColor{
+	"danger":  {0.84, 0.27, 0.27},
+	"warning": {0.94, 0.63, 0.13},
+	"ignore":  {0.48, 0.55, 0.63},
+}
+
+type pdfWriter struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	// y is the baseline of the next line, measured from the bottom of the page like pdf coordinates
+	y float64
+}
+
+func newPdfWriter() *pdfWriter {
+	w := &pdfWriter{}
+	w.addPage()
+	return w
+}
+
+func (w *pdfWriter) addPage() {
+	w.page = &bytes.Buffer{}
+	w.pages = append(w.pages, w.page)
+	w.y = pdfPageHeight - pdfMargin
+}
+
+// ensure starts a new page when less than height is left.
+func (w *pdfWriter) ensure(height float64) bool {
+	if w.y-height < pdfMargin {
+		w.addPage()
+		return true
+	}
+	return false
+}
+
+// isLatin reports whether r is drawn with the Helvetica fonts, control characters are drawn as spaces.
+func isLatin(r rune) bool {
+	return r < 127
+}
+
+func textWidth(text string, size float64, bold bool) float64 {
+	widths := &pdfHelveticaWidths
+	if bold {
+		widths = &pdfHelveticaBoldWidths
+	}
+	width := 0
+	for _, r := range text {
+		switch {
+		case r < 32:
+			width += widths[0]
+		case isLatin(r):
+			width += widths[r-32]
+		default:
+			width += 1000
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// pdfLiteralString encodes latin text as a literal string, control characters become spaces.
+func pdfLiteralString(text string) string {
+	b := &strings.Builder{}
+	b.WriteString("(")
+	for _, r := range text {
+		switch {
+		case r < 32:
+			r = ' '
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// pdfHexString encodes text as UCS-2 big endian, characters outside the basic multilingual plane become '?'.
+func pdfHexString(text string) string {
+	b := &strings.Builder{}
+	b.WriteString("<")
+	for _, r := range text {
+		if r < 32 {
+			r = ' '
+		}
+		if r > 0xFFFF || utf16.IsSurrogate(r) {
+			r = '?'
+		}
+		fmt.Fprintf(b, "%04X", r)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfRun is a part of a line drawn with a single font.
+type pdfRun struct {
+	Latin bool
+	Text  string
+}
+
+// pdfRuns splits text into runs of latin and of other characters.
+func pdfRuns(text string) []pdfRun {
+	var runs []pdfRun
+	start := 0
+	for i, r := range text {
+		if i > 0 && runs[len(runs)-1].Latin == isLatin(r) {
+			continue
+		}
+		if i > 0 {
+			runs[len(runs)-1].Text = text[start:i]
+		}
+		runs = append(runs, pdfRun{Latin: isLatin(r)})
+		start = i
+	}
+	if len(runs) > 0 {
+		runs[len(runs)-1].Text = text[start:]
+	}
+	return runs
+}
+
+// text draws a line of text, switching between the latin and the CJK font for each run. The text position
+// advances by the width of each run, so the runs follow each other.
+func (w *pdfWriter) text(x, y, size float64, color pdfColor, bold bool, text string) {
+	fmt.Fprintf(w.page, "BT %.3f %.3f %.3f rg %.3f %.3f %.3f RG 0.3 w %.2f %.2f Td",
+		color[0], color[1], color[2], color[0], color[1], color[2], x, y)
+	for _, run := range pdfRuns(text) {
+		if run.Latin {
+			font := "F1"
+			if bold {
+				font = "F2"
+			}
+			fmt.Fprintf(w.page, " 0 Tr /%s %.1f Tf %s Tj", font, size, pdfLiteralString(run.Text))
+			continue
+		}
+		mode := 0
+		if bold {
+			// fill and stroke the glyphs, the CJK font has no bold variant
+			mode = 2
+		}
+		fmt.Fprintf(w.page, " %d Tr /F3 %.1f Tf %s Tj", mode, size, pdfHexString(run.Text))
+	}
+	w.page.WriteString(" ET\n")
+}
+
+func (w *pdfWriter) rect(x, y, width, height float64, color pdfColor) {
+	fmt.Fprintf(w.page, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n", color[0], color[1], color[2], x, y, width, height)
+}
+
+func (w *pdfWriter) line(x1, y1, x2, y2 float64, color pdfColor) {
+	fmt.Fprintf(w.page, "%.3f %.3f %.3f RG 0.5 w %.2f %.2f m %.2f %.2f l S\n", color[0], color[1], color[2], x1, y1, x2, y2)
+}
+
+// paragraph writes wrapped text at the cursor and moves it down.
+func (w *pdfWriter) paragraph(size float64, color pdfColor, bold bool, text string) {
+	for _, line := range wrapText(text, pdfPageWidth-2*pdfMargin, size, bold) {
+		w.ensure(size * 1.5)
+		w.y -= size
+		w.text(pdfMargin, w.y, size, color, bold, line)
+		w.y -= size * 0.5
+	}
+}
+
+// wrapText breaks text into lines no wider than width, at spaces when possible and between any CJK characters.
+func wrapText(text string, width float64, size float64, bold bool) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		runes := []rune(paragraph)
+		for len(runes) > 0 {
+			end, lineWidth, lastSpace := 0, 0.0, -1
+			for end < len(runes) {
+				lineWidth += textWidth(string(runes[end]), size, bold)
+				if lineWidth > width && end > 0 {
+					break
+				}
+				if runes[end] == ' ' {
+					lastSpace = end
+				}
+				end++
+			}
+			// do not split a latin word when it can move to the next line
+			if end < len(runes) && lastSpace > 0 && isWordRune(runes[end-1]) && isWordRune(runes[end]) {
+				end = lastSpace + 1
+			}
+			lines = append(lines, strings.TrimRight(string(runes[:end]), " "))
+			runes = runes[end:]
+		}
+		if len(paragraph) == 0 {
+			lines = append(lines, "")
+		}
+	}
+	return lines
+}
+
+func isWordRune(r rune) bool {
+	return r != ' ' && r < 0x2E80
+}
+
+// pdfColumn is a table column, Width is a fraction of the printable width.
+type pdfColumn struct {
+	Title string
+	Width float64
+}
+
+// table writes a table with wrapped cells, repeating the header on every page. The level column, if any, is colored.
+func (w *pdfWriter) table(columns []pdfColumn, rows [][]string, levelColumn int) {
+	const size, padding = 8.0, 3.0
+	lineHeight := size * 1.3
+	total := pdfPageWidth - 2*pdfMargin
+	header := func() {
+		height := lineHeight + 2*padding
+		w.rect(pdfMargin, w.y-height, total, height, pdfLightGray)
+		x := pdfMargin
+		for _, column := range columns {
+			w.text(x+padding, w.y-padding-size, size, pdfBlack, true, column.Title)
+			x += column.Width * total
+		}
+		w.y -= height
+	}
+	w.ensure(3 * (lineHeight + 2*padding))
+	header()
+	for _, row := range rows {
+		cells := make([][]string, len(columns))
+		lines := 1
+		for i := range columns {
+			cells[i] = wrapText(row[i], columns[i].Width*total-2*padding, size, i == levelColumn)
+			lines = max(lines, len(cells[i]))
+		}
+		height := float64(lines)*lineHeight + 2*padding
+		if w.ensure(height) {
+			header()
+		}
+		x := pdfMargin
+		for i, column := range columns {
+			color := pdfBlack
+			if i == levelColumn {
+				if c, exist := pdfLevelColors[row[i]]; exist {
+					color = c
+				}
+			}
+			for l, line := range cells[i] {
+				w.text(x+padding, w.y-padding-size-float64(l)*lineHeight, size, color, i == levelColumn, line)
+			}
+			x += column.Width * total
+		}
+		w.y -= height
+		w.line(pdfMargin, w.y, pdfMargin+total, w.y, pdfLightGray)
+	}
+	w.y -= 12
+}
+
+// footer numbers every page.
+func (w *pdfWriter) footer(title string) {
+	for i, page := range w.pages {
+		w.page = page
+		w.text(pdfMargin, pdfMargin/2, 8, pdfGray, false, title)
+		number := fmt.Sprintf("%d / %d", i+1, len(w.pages))
+		w.text(pdfPageWidth-pdfMargin-textWidth(number, 8, false), pdfMargin/2, 8, pdfGray, false, number)
+	}
+}
+
+// writeTo serializes the document: catalog, page tree, fonts and one page object and content stream per page.
+func (w *pdfWriter) writeTo(out io.Writer) error {
+	buffer := &bytes.Buffer{}
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buffer.Len())
+		fmt.Fprintf(buffer, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	buffer.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	const firstPage = 8
+	var kids []string
+	for i := range w.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [6 0 R] >>")
+	object("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /FontDescriptor 7 0 R /DW 1000 >>")
+	object("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 /MissingWidth 1000 >>")
+	for i, page := range w.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, firstPage+2*i+1))
+		compressed := &bytes.Buffer{}
+		zw := zlib.NewWriter(compressed)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()))
+	}
+
+	xref := buffer.Len()
+	fmt.Fprintf(buffer, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(buffer, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := buffer.WriteTo(out)
+	return err
+}
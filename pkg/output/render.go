@@ -0,0 +1,102 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/template"
+	"io"
+	"strings"
+)
+
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatHTML  = "html"
+	FormatCSV   = "csv"
+	FormatTSV   = "tsv"
+	FormatSARIF = "sarif"
+	FormatJUnit = "junit"
+	// FormatMarkdown and FormatText are summaries meant for tickets and chat.
+	FormatMarkdown = "markdown"
+	FormatText     = "text"
+	// FormatReport is the standalone html report with charts.
+	FormatReport = "report"
+	FormatPDF    = "pdf"
+	FormatExcel  = "xlsx"
+)
+
+// Render writes the inspect result to w in the given format.
+func Render(w io.Writer, format string, result *v1alpha2.InspectResult) error {
+	switch strings.ToLower(format) {
+	case FormatTable, "":
+		return TableOut(w, result)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	case FormatHTML:
+		htmlTemplate, err := template.GetInspectResultHtmlTemplate()
+		if err != nil {
+			return err
+		}
+		return htmlTemplate.Execute(w, HtmlData(result))
+	case FormatCSV:
+		return CSVOut(w, ResultRows(result))
+	case FormatTSV:
+		return TSVOut(w, ResultRows(result))
+	case FormatSARIF:
+		return ResultSarif(result).Write(w)
+	case FormatJUnit:
+		return ResultJUnit(result).Write(w)
+	case FormatMarkdown, "md":
+		return MarkdownOut(w, result, DefaultTopFindings)
+	case FormatText:
+		return TextOut(w, result, DefaultTopFindings)
+	case FormatReport:
+		return HtmlReportOut(w, result, nil)
+	case FormatPDF:
+		return PdfOut(w, result)
+	case FormatExcel:
+		return ExcelOut(w, result, nil, nil)
+	}
+	return fmt.Errorf("unsupported output format %s", format)
+}
+
+var formatContentTypes = map[string]string{
+	FormatJSON:     "application/json",
+	FormatHTML:     "text/html; charset=utf-8",
+	FormatReport:   "text/html; charset=utf-8",
+	FormatCSV:      "text/csv; charset=utf-8",
+	FormatTSV:      "text/tab-separated-values; charset=utf-8",
+	FormatSARIF:    "application/sarif+json",
+	FormatJUnit:    "application/xml",
+	FormatMarkdown: "text/markdown; charset=utf-8",
+	FormatText:     "text/plain; charset=utf-8",
+	FormatPDF:      "application/pdf",
+	FormatExcel:    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+var formatExtensions = map[string]string{
+	FormatReport:   ".html",
+	FormatSARIF:    ".sarif",
+	FormatJUnit:    ".xml",
+	FormatMarkdown: ".md",
+	FormatText:     ".txt",
+}
+
+// FormatContentType returns the MIME type of a rendered format.
+func FormatContentType(format string) string {
+	if contentType, exist := formatContentTypes[format]; exist {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// FormatFileName returns the file name of a result rendered in format, e.g. name.csv.
+func FormatFileName(name string, format string) string {
+	if extension, exist := formatExtensions[format]; exist {
+		return name + extension
+	}
+	return name + "." + format
+}
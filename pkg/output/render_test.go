@@ -0,0 +1,142 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testResult has a finding of most rule types, values that need quoting and checks that passed.
+func testResult() *v1alpha2.InspectResult {
+	return &v1alpha2.InspectResult{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "cluster-task-result",
+			CreationTimestamp: metav1.NewTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+		},
+		Spec: v1alpha2.InspectResultSpec{
+			InspectCluster: v1alpha2.Cluster{Name: "host"},
+			OpaResult: v1alpha2.KubeeyeOpaResult{
+				ResourceResults: []v1alpha2.ResourceResult{{
+					NameSpace:    "default",
+					ResourceType: "Deployment",
+					Name:         "nginx",
+					ResultItems: []v1alpha2.ResultItem{
+						{Level: "danger", Message: "PrivilegeEscalationAllowed", Reason: `container "nginx", sidecar allow escalation`},
+						{Level: "warning", Message: "NoCPULimits"},
+					},
+				}},
+			},
+			PrometheusResult: []v1alpha2.PrometheusResult{{
+				BaseResult: v1alpha2.BaseResult{Name: "node-down", Level: v1alpha2.DangerLevel},
+				Result:     `{"instance"="10.0.0.1:9100","node"="node1"}`,
+			}},
+			SysctlResult: []v1alpha2.NodeMetricsResultItem{
+				{BaseResult: v1alpha2.BaseResult{Name: "net.ipv4.ip_forward", Assert: true, Level: v1alpha2.WarningLevel}, Value: ptr.To("0"), NodeName: "node1"},
+				{BaseResult: v1alpha2.BaseResult{Name: "vm.swappiness", Level: v1alpha2.WarningLevel}, Value: ptr.To("0"), NodeName: "node1"},
+			},
+			FileFilterResult: []v1alpha2.FileChangeResultItem{{
+				BaseResult: v1alpha2.BaseResult{Name: "syslog-errors", Assert: true, Level: v1alpha2.IgnoreLevel},
+				Path:       "/var/log/syslog",
+				NodeName:   "node2",
+				Issues:     []string{"kernel: error\twith a tab", "second line\nof the log"},
+			}},
+			NodeInfo: []v1alpha2.NodeInfoResultItem{{
+				BaseResult:    v1alpha2.BaseResult{Name: "root-disk", Assert: true, Level: v1alpha2.DangerLevel},
+				ResourcesType: v1alpha2.ResourcesType{Type: "filesystem", Mount: "/"},
+				Value:         "92%",
+				NodeName:      "node2",
+			}},
+			ComponentResult: []v1alpha2.ComponentResultItem{{
+				BaseResult: v1alpha2.BaseResult{Name: "kube-scheduler", Assert: true, Level: v1alpha2.DangerLevel},
+			}},
+		},
+	}
+}
+
+// checkGolden compares the output with testdata/name, go test -update rewrites the file.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	file := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(file, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("%s differs from the golden file, run go test -update after an intended change:\n%s", name, got)
+	}
+}
+
+func render(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Render(&buf, format, testResult()); err != nil {
+		t.Fatalf("Render(%s) failed: %s", format, err)
+	}
+	return buf.Bytes()
+}
+
+// checkRenderGolden renders the test result in the format and compares it with its golden file.
+func checkRenderGolden(t *testing.T, format string) {
+	t.Helper()
+	got := render(t, format)
+	if again := render(t, format); !bytes.Equal(got, again) {
+		t.Fatalf("%s output is not stable", format)
+	}
+	checkGolden(t, FormatFileName("result", format), got)
+}
+
+func TestRenderGolden(t *testing.T) {
+	for _, format := range []string{FormatCSV, FormatTSV} {
+		t.Run(format, func(t *testing.T) {
+			checkRenderGolden(t, format)
+		})
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(render(t, FormatCSV))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %s", err)
+	}
+	rows := ResultRows(testResult())
+	if len(records) != len(rows)+1 {
+		t.Fatalf("read %d records, want a header and %d rows", len(records), len(rows))
+	}
+	if strings.Join(records[0], ",") != strings.Join(ResultColumns, ",") {
+		t.Errorf("header = %v", records[0])
+	}
+	for i, row := range rows {
+		if strings.Join(records[i+1], "\x00") != strings.Join(row.Values(), "\x00") {
+			t.Errorf("record %d = %q, want %q", i+1, records[i+1], row.Values())
+		}
+	}
+}
+
+func TestTSVColumns(t *testing.T) {
+	lines := strings.Split(strings.TrimSuffix(string(render(t, FormatTSV)), "\n"), "\n")
+	if len(lines) != len(ResultRows(testResult()))+1 {
+		t.Fatalf("got %d lines, a value with a line break was not flattened", len(lines))
+	}
+	for _, line := range lines {
+		if columns := strings.Count(line, "\t") + 1; columns != len(ResultColumns) {
+			t.Errorf("line %q has %d columns, want %d", line, columns, len(ResultColumns))
+		}
+	}
+}
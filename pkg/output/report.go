@@ -0,0 +1,157 @@
+package output
+
+import (
+	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportData is the data model passed to report templates. Fields are only added, never renamed or removed,
+// so templates kept in ConfigMaps keep working across upgrades. See docs/report-template.md.
+type ReportData struct {
+	// Name is the InspectResult name.
+	Name    string
+	Cluster string
+	Plan    string
+	Task    string
+	// CreatedAt is the time the result was created.
+	CreatedAt time.Time
+	// Total is the number of findings, Levels counts them by level (danger, warning, ignore).
+	Total  int
+	Levels map[string]int
+	// RuleTypes holds one section per rule type with findings, sorted by name.
+	RuleTypes []ReportSection
+	// Nodes holds the node scoped findings grouped by node, sorted by name.
+	Nodes []ReportSection
+	// Namespaces holds the namespaced findings grouped by namespace, sorted by name.
+	Namespaces []ReportSection
+	// Findings are all findings, most severe first.
+	Findings []ResultRow
+	// Score is the 0-100 score of the result, see ResultScore.
+	Score int
+	// Trend holds the scores of the previous results of the same plan and this one, oldest first. It may be empty.
+	Trend []ScorePoint
+	// Result is the raw inspect result.
+	Result *v1alpha2.InspectResult
+}
+
+// ReportSection is a group of findings.
+type ReportSection struct {
+	Name     string
+	Total    int
+	Levels   map[string]int
+	Findings []ResultRow
+}
+
+// NewReportData builds the template data model of a result.
+func NewReportData(result *v1alpha2.InspectResult) *ReportData {
+	rows := TopFindings(ResultRows(result), 0)
+	data := &ReportData{
+		Name:      result.Name,
+		Cluster:   clusterName(result),
+		Plan:      result.Labels[constant.LabelPlanName],
+		Task:      result.Labels[constant.LabelTaskName],
+		CreatedAt: result.CreationTimestamp.Time,
+		Total:     len(rows),
+		Levels:    map[string]int{},
+		Findings:  rows,
+		Score:     ResultScore(result),
+		Result:    result,
+	}
+	for _, level := range summaryLevels {
+		data.Levels[level] = 0
+	}
+	for _, row := range rows {
+		data.Levels[NormalizeLevel(row.Level)]++
+	}
+	data.RuleTypes = groupRows(rows, func(row ResultRow) string { return row.RuleType })
+	data.Nodes = groupRows(rows, func(row ResultRow) string { return row.Node })
+	data.Namespaces = groupRows(rows, func(row ResultRow) string { return row.Namespace })
+	return data
+}
+
+// groupRows groups rows by key, rows with an empty key are left out.
+func groupRows(rows []ResultRow, key func(row ResultRow) string) []ReportSection {
+	index := map[string]int{}
+	var sections []ReportSection
+	for _, row := range rows {
+		k := key(row)
+		if k == "" {
+			continue
+		}
+		i, exist := index[k]
+		if !exist {
+			sections = append(sections, ReportSection{Name: k, Levels: map[string]int{}})
+			i = len(sections) - 1
+			index[k] = i
+		}
+		sections[i].Total++
+		sections[i].Levels[NormalizeLevel(row.Level)]++
+		sections[i].Findings = append(sections[i].Findings, row)
+	}
+	sort.Slice(sections, func(i, j int) bool { return sections[i].Name < sections[j].Name })
+	return sections
+}
+
+// ReportFuncs are the helper functions available in report templates.
+func ReportFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		// level normalizes a level spelling to danger, warning or ignore
+		"level": NormalizeLevel,
+		// levelRank orders levels by severity: danger 3, warning 2, ignore 1
+		"levelRank": LevelRank,
+		// top returns the n most severe findings
+		"top": func(n int, rows []ResultRow) []ResultRow { return TopFindings(rows, n) },
+		// byLevel keeps the findings at the given level
+		"byLevel": func(level string, rows []ResultRow) []ResultRow {
+			return filterRows(rows, func(row ResultRow) bool { return NormalizeLevel(row.Level) == NormalizeLevel(level) })
+		},
+		// byRuleType keeps the findings of the given rule type
+		"byRuleType": func(ruleType string, rows []ResultRow) []ResultRow {
+			return filterRows(rows, func(row ResultRow) bool { return row.RuleType == ruleType })
+		},
+		// summary describes a finding in one line
+		"summary": func(row ResultRow) string { return row.Summary() },
+		// formatTime formats a time with a Go layout, e.g. formatTime "2006-01-02" .CreatedAt
+		"formatTime": func(layout string, t time.Time) string { return t.Format(layout) },
+		// truncate shortens s to n runes
+		"truncate": func(n int, s string) string {
+			r := []rune(s)
+			if len(r) <= n {
+				return s
+			}
+			return string(r[:n]) + "..."
+		},
+		// default returns def when value is empty
+		"default": func(def string, value string) string {
+			if value == "" {
+				return def
+			}
+			return value
+		},
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"join":     func(sep string, s []string) string { return strings.Join(s, sep) },
+		"contains": strings.Contains,
+		"add":      func(a, b int) int { return a + b },
+		"percent": func(part, total int) string {
+			if total == 0 {
+				return "0%"
+			}
+			return fmt.Sprintf("%d%%", part*100/total)
+		},
+	}
+}
+
+func filterRows(rows []ResultRow, keep func(row ResultRow) bool) []ResultRow {
+	var filtered []ResultRow
+	for _, row := range rows {
+		if keep(row) {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered
+}
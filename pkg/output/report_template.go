@@ -0,0 +1,95 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	htmltemplate "html/template"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	texttemplate "text/template"
+)
+
+const (
+	// ReportTemplateKey is the ConfigMap data key holding the template.
+	ReportTemplateKey = "template"
+	// ReportTemplateTypeKey is the ConfigMap data key selecting the engine: html (html/template, default) or text (text/template).
+	ReportTemplateTypeKey = "type"
+	// ReportTemplateContentTypeKey optionally overrides the MIME type of the rendered report.
+	ReportTemplateContentTypeKey = "contentType"
+)
+
+// ReportTemplate is a user template loaded from a ConfigMap labelled kubeeye.kubesphere.io/config-type=report-template.
+type ReportTemplate struct {
+	Name        string
+	Type        string
+	ContentType string
+
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// ParseReportTemplate parses the template stored in a report template ConfigMap.
+func ParseReportTemplate(cm *corev1.ConfigMap) (*ReportTemplate, error) {
+	if cm.Labels[constant.LabelConfigType] != constant.ReportTemplate {
+		return nil, fmt.Errorf("configmap %s is not labelled %s=%s", cm.Name, constant.LabelConfigType, constant.ReportTemplate)
+	}
+	content, exist := cm.Data[ReportTemplateKey]
+	if !exist {
+		return nil, fmt.Errorf("report template %s has no %q key", cm.Name, ReportTemplateKey)
+	}
+	t := &ReportTemplate{Name: cm.Name, Type: cm.Data[ReportTemplateTypeKey], ContentType: cm.Data[ReportTemplateContentTypeKey]}
+	var err error
+	switch t.Type {
+	case "", FormatHTML:
+		t.Type = FormatHTML
+		t.html, err = htmltemplate.New(cm.Name).Funcs(ReportFuncs()).Parse(content)
+		if t.ContentType == "" {
+			t.ContentType = "text/html"
+		}
+	case FormatText:
+		t.text, err = texttemplate.New(cm.Name).Funcs(ReportFuncs()).Parse(content)
+		if t.ContentType == "" {
+			t.ContentType = "text/plain"
+		}
+	default:
+		return nil, fmt.Errorf("report template %s has unknown type %q, expected html or text", cm.Name, t.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse report template %s: %s", cm.Name, err)
+	}
+	return t, nil
+}
+
+// LoadReportTemplate loads the named report template from the namespace.
+func LoadReportTemplate(ctx context.Context, clientSet kubernetes.Interface, namespace string, name string) (*ReportTemplate, error) {
+	cm, err := clientSet.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return ParseReportTemplate(cm)
+}
+
+// ListReportTemplates returns the names of the report templates in the namespace.
+func ListReportTemplates(ctx context.Context, clientSet kubernetes.Interface, namespace string) ([]string, error) {
+	list, err := clientSet.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", constant.LabelConfigType, constant.ReportTemplate),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, item := range list.Items {
+		names = append(names, item.Name)
+	}
+	return names, nil
+}
+
+func (t *ReportTemplate) Execute(w io.Writer, data *ReportData) error {
+	if t.html != nil {
+		return t.html.Execute(w, data)
+	}
+	return t.text.Execute(w, data)
+}
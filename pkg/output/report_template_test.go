@@ -0,0 +1,119 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func reportTemplateConfigMap(name string, labelled bool, data map[string]string) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kubeeye-system"}, Data: data}
+	if labelled {
+		cm.Labels = map[string]string{constant.LabelConfigType: constant.ReportTemplate}
+	}
+	return cm
+}
+
+func TestParseReportTemplate(t *testing.T) {
+	result := testResult()
+	result.Spec.InspectCluster.Name = "<host>"
+	tests := []struct {
+		name            string
+		cm              *corev1.ConfigMap
+		wantErr         string
+		wantType        string
+		wantContentType string
+		wantOutput      string
+	}{{
+		name:            "html by default",
+		cm:              reportTemplateConfigMap("default", true, map[string]string{ReportTemplateKey: "<p>{{ .Cluster }}</p>"}),
+		wantType:        FormatHTML,
+		wantContentType: "text/html",
+		wantOutput:      "<p>&lt;host&gt;</p>",
+	}, {
+		name:            "text engine does not escape",
+		cm:              reportTemplateConfigMap("text", true, map[string]string{ReportTemplateKey: "cluster {{ .Cluster }}", ReportTemplateTypeKey: FormatText}),
+		wantType:        FormatText,
+		wantContentType: "text/plain",
+		wantOutput:      "cluster <host>",
+	}, {
+		name:            "content type override",
+		cm:              reportTemplateConfigMap("markdown", true, map[string]string{ReportTemplateKey: "# {{ .Cluster }}", ReportTemplateTypeKey: FormatText, ReportTemplateContentTypeKey: "text/markdown"}),
+		wantType:        FormatText,
+		wantContentType: "text/markdown",
+		wantOutput:      "# <host>",
+	}, {
+		name:    "not labelled",
+		cm:      reportTemplateConfigMap("unlabelled", false, map[string]string{ReportTemplateKey: "{{ .Cluster }}"}),
+		wantErr: "is not labelled",
+	}, {
+		name:    "missing template key",
+		cm:      reportTemplateConfigMap("empty", true, map[string]string{ReportTemplateTypeKey: FormatText}),
+		wantErr: `has no "template" key`,
+	}, {
+		name:    "unknown type",
+		cm:      reportTemplateConfigMap("pdf", true, map[string]string{ReportTemplateKey: "{{ .Cluster }}", ReportTemplateTypeKey: "pdf"}),
+		wantErr: `unknown type "pdf"`,
+	}, {
+		name:    "parse error",
+		cm:      reportTemplateConfigMap("broken", true, map[string]string{ReportTemplateKey: "{{ .Cluster "}),
+		wantErr: "failed to parse report template broken",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reportTemplate, err := ParseReportTemplate(tt.cm)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseReportTemplate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reportTemplate.Type != tt.wantType || reportTemplate.ContentType != tt.wantContentType {
+				t.Errorf("type = %s, content type = %s, want %s, %s", reportTemplate.Type, reportTemplate.ContentType, tt.wantType, tt.wantContentType)
+			}
+			var buf bytes.Buffer
+			if err = reportTemplate.Execute(&buf, NewReportData(result)); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.wantOutput {
+				t.Errorf("output = %q, want %q", buf.String(), tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestLoadReportTemplate(t *testing.T) {
+	clientSet := kubefake.NewSimpleClientset(
+		reportTemplateConfigMap("weekly", true, map[string]string{ReportTemplateKey: "{{ .Cluster }}"}),
+		reportTemplateConfigMap("other", false, map[string]string{ReportTemplateKey: "{{ .Cluster }}"}),
+	)
+	ctx := context.Background()
+
+	reportTemplate, err := LoadReportTemplate(ctx, clientSet, "kubeeye-system", "weekly")
+	if err != nil || reportTemplate.Name != "weekly" {
+		t.Fatalf("LoadReportTemplate() = %v, %v", reportTemplate, err)
+	}
+	if _, err = LoadReportTemplate(ctx, clientSet, "kubeeye-system", "missing"); err == nil {
+		t.Error("a missing template is loaded")
+	}
+	if _, err = LoadReportTemplate(ctx, clientSet, "kubeeye-system", "other"); err == nil {
+		t.Error("a ConfigMap without the label is loaded")
+	}
+
+	names, err := ListReportTemplates(ctx, clientSet, "kubeeye-system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "weekly" {
+		t.Errorf("ListReportTemplates() = %v, want [weekly]", names)
+	}
+}
@@ -0,0 +1,252 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// SarifLog is the subset of the SARIF 2.1.0 format understood by code scanning dashboards.
+type SarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool SarifTool `json:"tool"`
+	// AutomationDetails tells the runs of a multi-cluster log apart.
+	AutomationDetails *SarifAutomationDetails `json:"automationDetails,omitempty"`
+	Results           []SarifResult           `json:"results"`
+}
+
+type SarifAutomationDetails struct {
+	ID string `json:"id"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SarifRule `json:"rules,omitempty"`
+}
+
+type SarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name,omitempty"`
+	ShortDescription     *SarifMessage      `json:"shortDescription,omitempty"`
+	DefaultConfiguration *SarifRuleDefaults `json:"defaultConfiguration,omitempty"`
+}
+
+type SarifRuleDefaults struct {
+	Level string `json:"level"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    SarifMessage      `json:"message"`
+	Locations  []SarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation *SarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []SarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           *SarifRegion          `json:"region,omitempty"`
+}
+
+type SarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type SarifLogicalLocation struct {
+	Name               string `json:"name,omitempty"`
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+// NewSarifLog returns a log with a single kubeeye run.
+func NewSarifLog(rules []SarifRule, results []SarifResult) *SarifLog {
+	if results == nil {
+		results = []SarifResult{}
+	}
+	return &SarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []SarifRun{{
+			Tool: SarifTool{Driver: SarifDriver{
+				Name:           "kubeeye",
+				InformationURI: "https://github.com/kubesphere/kubeeye",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+func (s *SarifLog) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// SarifLevel maps a kubeeye level to a SARIF result level.
+func SarifLevel(level string) string {
+	switch NormalizeLevel(level) {
+	case "danger":
+		return "error"
+	case "warning":
+		return "warning"
+	case "ignore":
+		return "note"
+	}
+	return "none"
+}
+
+// ResultSarif maps every executed rule of the inspect result to a SARIF rule and every finding to a result.
+func ResultSarif(result *v1alpha2.InspectResult) *SarifLog {
+	var rules []SarifRule
+	ruleIndex := map[string]bool{}
+	var results []SarifResult
+	for _, row := range flattenResult(result, true) {
+		id := row.ruleID()
+		if !ruleIndex[id] {
+			ruleIndex[id] = true
+			rule := SarifRule{
+				ID:               id,
+				Name:             row.Name,
+				ShortDescription: &SarifMessage{Text: fmt.Sprintf("%s rule %s", row.RuleType, row.Name)},
+			}
+			if row.Level != "" {
+				rule.DefaultConfiguration = &SarifRuleDefaults{Level: SarifLevel(row.Level)}
+			}
+			rules = append(rules, rule)
+		}
+		if row.passed {
+			continue
+		}
+		properties := map[string]string{"ruleType": row.RuleType}
+		for k, v := range map[string]string{"cluster": row.Cluster, "node": row.Node, "namespace": row.Namespace, "value": row.Value} {
+			if v != "" {
+				properties[k] = v
+			}
+		}
+		results = append(results, SarifResult{
+			RuleID:  id,
+			Level:   SarifLevel(row.Level),
+			Message: SarifMessage{Text: row.Summary()},
+			Locations: []SarifLocation{{
+				PhysicalLocation: &SarifPhysicalLocation{
+					ArtifactLocation: SarifArtifactLocation{URI: row.artifactURI()},
+					Region:           &SarifRegion{StartLine: 1},
+				},
+				LogicalLocations: []SarifLogicalLocation{row.logicalLocation()},
+			}},
+			Properties: properties,
+		})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return NewSarifLog(rules, results)
+}
+
+func (r ResultRow) ruleID() string {
+	return r.RuleType + "/" + r.Name
+}
+
+// Summary describes the finding in one line, e.g. "Deployment/nginx in default: NoCPULimits".
+func (r ResultRow) Summary() string {
+	var target []string
+	if r.Resource != "" {
+		target = append(target, r.Resource)
+	}
+	if r.Namespace != "" {
+		target = append(target, "in "+r.Namespace)
+	}
+	if r.Node != "" {
+		target = append(target, "on "+r.Node)
+	}
+	text := r.Name
+	if r.Message != "" && r.Message != r.Name {
+		text = fmt.Sprintf("%s: %s", r.Name, r.Message)
+	}
+	if r.Value != "" {
+		text = fmt.Sprintf("%s (value: %s)", text, r.Value)
+	}
+	if len(target) == 0 {
+		return text
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(target, " "), text)
+}
+
+// locationParts are the path of the finding in the cluster, e.g. host, namespaces, default, Deployment/nginx.
+func (r ResultRow) locationParts() (parts []string, kind string) {
+	if r.Cluster != "" {
+		parts = append(parts, r.Cluster)
+	}
+	kind = "resource"
+	switch {
+	case r.Node != "":
+		parts = append(parts, "nodes", r.Node)
+		kind = "node"
+	case r.Namespace != "":
+		parts = append(parts, "namespaces", r.Namespace)
+	}
+	if r.Resource != "" {
+		parts = append(parts, r.Resource)
+	}
+	return parts, kind
+}
+
+func (r ResultRow) logicalLocation() SarifLogicalLocation {
+	parts, kind := r.locationParts()
+	name := r.Resource
+	if name == "" {
+		name = r.Node
+	}
+	return SarifLogicalLocation{
+		Name:               name,
+		FullyQualifiedName: strings.Join(parts, "/"),
+		Kind:               kind,
+	}
+}
+
+// artifactURI is a synthetic relative uri of the finding, e.g. host/namespaces/default/Deployment/nginx. Code
+// scanning rejects results without a physical location, and a cluster finding has no file.
+func (r ResultRow) artifactURI() string {
+	parts, _ := r.locationParts()
+	if len(parts) == 0 || (len(parts) == 1 && r.Cluster != "") {
+		parts = append(parts, "rules", r.RuleType, r.Name)
+	}
+	segments := make([]string, 0, len(parts))
+	for _, part := range parts {
+		for _, segment := range strings.Split(part, "/") {
+			segments = append(segments, url.PathEscape(segment))
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,63 @@
+package output
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSarifGolden(t *testing.T) {
+	checkRenderGolden(t, FormatSARIF)
+}
+
+func TestSarifParses(t *testing.T) {
+	var log SarifLog
+	if err := json.Unmarshal(render(t, FormatSARIF), &log); err != nil {
+		t.Fatalf("invalid sarif: %s", err)
+	}
+	if log.Version != sarifVersion || len(log.Runs) != 1 {
+		t.Fatalf("sarif version %s with %d runs", log.Version, len(log.Runs))
+	}
+	rules := map[string]bool{}
+	for _, rule := range log.Runs[0].Tool.Driver.Rules {
+		rules[rule.ID] = true
+	}
+	results := log.Runs[0].Results
+	if len(results) != len(ResultRows(testResult())) {
+		t.Errorf("sarif has %d results, want one per finding", len(results))
+	}
+	for _, result := range results {
+		if !rules[result.RuleID] {
+			t.Errorf("result %s has no rule", result.RuleID)
+		}
+		switch result.Level {
+		case "error", "warning", "note":
+		default:
+			t.Errorf("result %s has level %q", result.RuleID, result.Level)
+		}
+		if len(result.Locations) != 1 || result.Locations[0].PhysicalLocation == nil || result.Locations[0].PhysicalLocation.ArtifactLocation.URI == "" {
+			t.Errorf("result %s has no physical location", result.RuleID)
+		}
+	}
+}
+
+func TestSarifArtifactURI(t *testing.T) {
+	tests := []struct {
+		name string
+		row  ResultRow
+		want string
+	}{
+		{name: "namespaced resource", row: ResultRow{Cluster: "host", Namespace: "default", Resource: "Deployment/nginx"}, want: "host/namespaces/default/Deployment/nginx"},
+		{name: "cluster resource", row: ResultRow{Cluster: "host", Resource: "ClusterRole/admin"}, want: "host/ClusterRole/admin"},
+		{name: "node", row: ResultRow{Cluster: "host", Node: "node1"}, want: "host/nodes/node1"},
+		{name: "cluster finding", row: ResultRow{Cluster: "host", RuleType: "prometheus", Name: "node-down"}, want: "host/rules/prometheus/node-down"},
+		{name: "no cluster", row: ResultRow{RuleType: "component", Name: "kube-scheduler"}, want: "rules/component/kube-scheduler"},
+		{name: "escaped", row: ResultRow{Cluster: "host", Node: "node 1"}, want: "host/nodes/node%201"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.row.artifactURI(); got != tt.want {
+				t.Errorf("artifactURI() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
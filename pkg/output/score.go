@@ -0,0 +1,43 @@
+package output
+
+import (
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"sort"
+	"time"
+)
+
+// ScorePoint is the score of one result, used for the trend chart of the html report.
+type ScorePoint struct {
+	Name  string
+	Time  time.Time
+	Score int
+}
+
+// ResultScore returns the 0-100 score of a result. The opa score is used when opa ran, otherwise the score is
+// derived from the checks: each failed check costs its level rank out of the worst case of every check being danger.
+func ResultScore(result *v1alpha2.InspectResult) int {
+	if result.Spec.OpaResult.ScoreInfo.Total > 0 {
+		return result.Spec.OpaResult.ScoreInfo.Score
+	}
+	checks := flattenResult(result, true)
+	if len(checks) == 0 {
+		return 100
+	}
+	penalty := 0
+	for _, check := range checks {
+		if !check.passed {
+			penalty += max(LevelRank(check.Level), 1)
+		}
+	}
+	return 100 - penalty*100/(len(checks)*LevelRank(string(v1alpha2.DangerLevel)))
+}
+
+// ScoreTrend returns the scores of the results ordered by creation time.
+func ScoreTrend(results []*v1alpha2.InspectResult) []ScorePoint {
+	var points []ScorePoint
+	for _, result := range results {
+		points = append(points, ScorePoint{Name: result.Name, Time: result.CreationTimestamp.Time, Score: ResultScore(result)})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	return points
+}
@@ -0,0 +1,101 @@
+package output
+
+import (
+	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"html"
+	"html/template"
+	"math"
+	"strings"
+)
+
+// levelColors are the chart colors of each level.
+var levelColors = map[string]string{
+	string(v1alpha2.DangerLevel):  "#d64545",
+	string(v1alpha2.WarningLevel): "#f0a020",
+	string(v1alpha2.IgnoreLevel):  "#7a8ba0",
+}
+
+// LevelDonutSVG draws the findings by level as a donut chart with the total in the middle.
+func LevelDonutSVG(levels map[string]int) template.HTML {
+	const size, radius, stroke = 160.0, 60.0, 24.0
+	total := 0
+	for _, level := range summaryLevels {
+		total += levels[level]
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %.0f %.0f" width="%.0f" height="%.0f" role="img" aria-label="findings by level">`, size, size, size, size)
+	fmt.Fprintf(b, `<circle cx="%.0f" cy="%.0f" r="%.0f" fill="none" stroke="#e6e9ef" stroke-width="%.0f"/>`, size/2, size/2, radius, stroke)
+	circumference := 2 * math.Pi * radius
+	offset := 0.0
+	for _, level := range summaryLevels {
+		if total == 0 || levels[level] == 0 {
+			continue
+		}
+		length := circumference * float64(levels[level]) / float64(total)
+		fmt.Fprintf(b, `<circle cx="%.0f" cy="%.0f" r="%.0f" fill="none" stroke="%s" stroke-width="%.0f" stroke-dasharray="%.2f %.2f" stroke-dashoffset="%.2f" transform="rotate(-90 %.0f %.0f)"><title>%s: %d</title></circle>`,
+			size/2, size/2, radius, levelColors[level], stroke, length, circumference-length, -offset, size/2, size/2, level, levels[level])
+		offset += length
+	}
+	fmt.Fprintf(b, `<text x="%.0f" y="%.0f" text-anchor="middle" font-size="28" font-weight="bold">%d</text>`, size/2, size/2+4, total)
+	fmt.Fprintf(b, `<text x="%.0f" y="%.0f" text-anchor="middle" font-size="12" fill="#666">issues</text>`, size/2, size/2+22)
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// CategoryBarsSVG draws one stacked bar per section, split by level.
+func CategoryBarsSVG(sections []ReportSection) template.HTML {
+	const width, labelWidth, barHeight, gap = 520.0, 130.0, 18.0, 8.0
+	maxTotal := 0
+	for _, section := range sections {
+		maxTotal = max(maxTotal, section.Total)
+	}
+	height := math.Max(float64(len(sections))*(barHeight+gap), barHeight+gap)
+	b := &strings.Builder{}
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %.0f %.0f" width="%.0f" height="%.0f" role="img" aria-label="findings by category">`, width, height, width, height)
+	for i, section := range sections {
+		y := float64(i) * (barHeight + gap)
+		fmt.Fprintf(b, `<text x="%.0f" y="%.1f" text-anchor="end" font-size="12">%s</text>`, labelWidth-8, y+barHeight-5, html.EscapeString(section.Name))
+		x := labelWidth
+		for _, level := range summaryLevels {
+			count := section.Levels[level]
+			if count == 0 {
+				continue
+			}
+			w := (width - labelWidth - 40) * float64(count) / float64(maxTotal)
+			fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.0f" fill="%s"><title>%s %s: %d</title></rect>`,
+				x, y, w, barHeight, levelColors[level], html.EscapeString(section.Name), level, count)
+			x += w
+		}
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" font-size="12" fill="#666">%d</text>`, x+4, y+barHeight-5, section.Total)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// TrendSVG draws the scores as a line chart, it is empty with less than two points.
+func TrendSVG(points []ScorePoint) template.HTML {
+	if len(points) < 2 {
+		return ""
+	}
+	const width, height, pad = 520.0, 160.0, 24.0
+	b := &strings.Builder{}
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %.0f %.0f" width="%.0f" height="%.0f" role="img" aria-label="score trend">`, width, height, width, height)
+	for _, score := range []int{0, 50, 100} {
+		y := pad + (height-2*pad)*(1-float64(score)/100)
+		fmt.Fprintf(b, `<line x1="%.0f" y1="%.1f" x2="%.0f" y2="%.1f" stroke="#e6e9ef"/><text x="%.0f" y="%.1f" font-size="10" text-anchor="end" fill="#666">%d</text>`, pad, y, width-pad, y, pad-4, y+3, score)
+	}
+	var coordinates []string
+	circles := &strings.Builder{}
+	step := (width - 2*pad) / float64(len(points)-1)
+	for i, point := range points {
+		x := pad + step*float64(i)
+		y := pad + (height-2*pad)*(1-float64(point.Score)/100)
+		coordinates = append(coordinates, fmt.Sprintf("%.1f,%.1f", x, y))
+		fmt.Fprintf(circles, `<circle cx="%.1f" cy="%.1f" r="3" fill="#3468c0"><title>%s %s: %d</title></circle>`, x, y, html.EscapeString(point.Name), point.Time.Format("2006-01-02 15:04"), point.Score)
+	}
+	fmt.Fprintf(b, `<polyline points="%s" fill="none" stroke="#3468c0" stroke-width="2"/>`, strings.Join(coordinates, " "))
+	b.WriteString(circles.String())
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
@@ -0,0 +1,43 @@
+package output
+
+import (
+	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// TableOut prints the same issue tables as the html report, one section per rule type.
+func TableOut(w io.Writer, result *v1alpha2.InspectResult) error {
+	details, _ := HtmlData(result)["details"].(map[string][]renderNode)
+	var ruleTypes []string
+	for ruleType := range details {
+		ruleTypes = append(ruleTypes, ruleType)
+	}
+	sort.Strings(ruleTypes)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i, ruleType := range ruleTypes {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "[%s]\n", strings.ToUpper(ruleType))
+		for _, row := range details[ruleType] {
+			var cells []string
+			for _, cell := range row.Children {
+				text := strings.ReplaceAll(strings.TrimSpace(cell.Text), "\n", " ")
+				if row.Header {
+					text = strings.ToUpper(text)
+				}
+				cells = append(cells, text)
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+	}
+	if len(ruleTypes) == 0 {
+		fmt.Fprintln(tw, "no issues found")
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,50 @@
+package rules
+
+import (
+	"fmt"
+	"github.com/ghodss/yaml"
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/utils"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LoadRuleFiles reads InspectRule manifests from files or directories. Documents of other kinds are skipped.
+func LoadRuleFiles(paths ...string) ([]kubeeyev1alpha2.InspectRule, error) {
+	var inspectRules []kubeeyev1alpha2.InspectRule
+	for _, p := range paths {
+		err := filepath.WalkDir(p, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if file != p && !utils.IsManifestFile(file) {
+				return nil
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			for _, doc := range utils.SplitYamlDocuments(data) {
+				var rule kubeeyev1alpha2.InspectRule
+				if err = yaml.Unmarshal(doc, &rule); err != nil {
+					return fmt.Errorf("failed to parse rule %s: %s", file, err)
+				}
+				if rule.Kind == "InspectRule" {
+					inspectRules = append(inspectRules, rule)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(inspectRules) == 0 {
+		return nil, fmt.Errorf("no InspectRule found in %v", paths)
+	}
+	return inspectRules, nil
+}
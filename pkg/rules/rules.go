@@ -319,3 +319,19 @@ func sortRuleOpaToAtLast(rule []kubeeyev1alpha2.JobRule) []kubeeyev1alpha2.JobRu
 func (e *ExecuteRule) GetRuleTotal() map[string]int {
 	return e.ruleTotal
 }
+
+// GetDeploySchedule returns the node a job rule has to run on, empty when any node will do.
+func GetDeploySchedule(r []byte) (string, error) {
+	var data []map[string]interface{}
+	err := json.Unmarshal(r, &data)
+	if len(data) == 0 || err != nil {
+		return "", fmt.Errorf("rule is empty")
+	}
+	v := data[0]["nodeName"]
+
+	if !utils.IsEmptyValue(v) {
+		return v.(string), nil
+	}
+
+	return "", nil
+}
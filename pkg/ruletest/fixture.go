@@ -0,0 +1,159 @@
+package ruletest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/ghodss/yaml"
+	"github.com/kubesphere/kubeeye/pkg/conf"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	"github.com/kubesphere/kubeeye/pkg/inspect"
+	"github.com/kubesphere/kubeeye/pkg/kube"
+	"github.com/kubesphere/kubeeye/pkg/template"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// listKinds are the resources the opa inspector lists through the dynamic client.
+var listKinds = map[schema.GroupVersionResource]string{
+	{Version: conf.APIVersionV1, Resource: conf.Nodes}:                               "NodeList",
+	{Version: conf.APIVersionV1, Resource: conf.Namespaces}:                          "NamespaceList",
+	{Version: conf.APIVersionV1, Resource: conf.Pods}:                                "PodList",
+	{Version: conf.APIVersionV1, Resource: conf.Events}:                              "EventList",
+	{Group: conf.AppsGroup, Version: conf.APIVersionV1, Resource: conf.Deployments}:  "DeploymentList",
+	{Group: conf.AppsGroup, Version: conf.APIVersionV1, Resource: conf.Daemonsets}:   "DaemonSetList",
+	{Group: conf.AppsGroup, Version: conf.APIVersionV1, Resource: conf.Statefulsets}: "StatefulSetList",
+	{Group: conf.BatchGroup, Version: conf.APIVersionV1, Resource: conf.Jobs}:        "JobList",
+	{Group: conf.BatchGroup, Version: conf.APIVersionV1, Resource: conf.Cronjobs}:    "CronJobList",
+	{Group: conf.RoleGroup, Version: conf.APIVersionV1, Resource: conf.Roles}:        "RoleList",
+	{Group: conf.RoleGroup, Version: conf.APIVersionV1, Resource: conf.Clusterroles}: "ClusterRoleList",
+}
+
+type fixture struct {
+	clients    *kube.KubernetesClient
+	prometheus *httptest.Server
+
+	hostPaths inspect.HostPaths
+}
+
+// newFixture builds fake clients from the case objects and points the node inspectors at the fixture trees.
+func (s *Suite) newFixture(ctx context.Context, c Case, nodeName string) (*fixture, error) {
+	var objects []unstructured.Unstructured
+	for _, file := range c.Objects {
+		docs, err := readDocuments(s.path(file))
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			var obj unstructured.Unstructured
+			if err = yaml.Unmarshal(doc, &obj.Object); err != nil {
+				return nil, fmt.Errorf("failed to parse object %s: %s", file, err)
+			}
+			if obj.GetKind() == "List" {
+				list, err := obj.ToList()
+				if err != nil {
+					return nil, err
+				}
+				objects = append(objects, list.Items...)
+				continue
+			}
+			objects = append(objects, obj)
+		}
+	}
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+	typedObjects := []runtime.Object{node}
+	dynamicObjects := []runtime.Object{}
+	for i := range objects {
+		dynamicObjects = append(dynamicObjects, &objects[i])
+		typed, err := scheme.Scheme.New(objects[i].GroupVersionKind())
+		if err != nil {
+			continue
+		}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(objects[i].Object, typed); err != nil {
+			return nil, fmt.Errorf("failed to convert %s/%s: %s", objects[i].GetKind(), objects[i].GetName(), err)
+		}
+		if objects[i].GetKind() == "Node" && objects[i].GetName() == nodeName {
+			typedObjects[0] = typed
+			continue
+		}
+		typedObjects = append(typedObjects, typed)
+	}
+
+	clientSet := fake.NewSimpleClientset(typedObjects...)
+	for name, file := range c.BaseFiles {
+		data, err := os.ReadFile(s.path(file))
+		if err != nil {
+			return nil, err
+		}
+		baseFile := template.BinaryFileConfigMapTemplate(fmt.Sprintf("%s-%s", constant.BaseFilePrefix, name), os.Getenv("KUBERNETES_POD_NAMESPACE"), data, true)
+		if _, err = clientSet.CoreV1().ConfigMaps(baseFile.Namespace).Create(ctx, baseFile, metav1.CreateOptions{}); err != nil {
+			return nil, err
+		}
+	}
+
+	f := &fixture{
+		clients: &kube.KubernetesClient{
+			KubeConfig:    &rest.Config{},
+			ClientSet:     clientSet,
+			DynamicClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, dynamicObjects...),
+		},
+		hostPaths: inspect.DefaultHostPaths(),
+	}
+	if c.Procfs != "" {
+		f.hostPaths.Proc = s.path(c.Procfs)
+	}
+	if c.Rootfs != "" {
+		f.hostPaths.Root = s.path(c.Rootfs)
+	}
+
+	if c.Prometheus != "" {
+		server, err := newPrometheusServer(s.path(c.Prometheus))
+		if err != nil {
+			f.close()
+			return nil, err
+		}
+		f.prometheus = server
+	}
+	return f, nil
+}
+
+func (f *fixture) close() {
+	if f.prometheus != nil {
+		f.prometheus.Close()
+	}
+}
+
+// newPrometheusServer replays recorded /api/v1/query responses keyed by the query expression.
+func newPrometheusServer(file string) (*httptest.Server, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var recorded map[string]interface{}
+	if err = yaml.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("failed to parse prometheus responses %s: %s", file, err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, exist := recorded[r.FormValue("query")]
+		if !exist {
+			response = map[string]interface{}{
+				"status": "success",
+				"data":   map[string]interface{}{"resultType": "vector", "result": []interface{}{}},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	})), nil
+}
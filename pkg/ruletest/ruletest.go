@@ -0,0 +1,315 @@
+package ruletest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	"github.com/kubesphere/kubeeye/pkg/inspect"
+	"github.com/kubesphere/kubeeye/pkg/rules"
+	"github.com/kubesphere/kubeeye/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const defaultNodeName = "ruletest-node"
+
+// resultKeys maps a rule type to the InspectResultSpec field its findings are merged into.
+var resultKeys = map[string]string{
+	constant.Opa:            "opaResult",
+	constant.Prometheus:     "prometheusResult",
+	constant.FileChange:     "fileChangeResult",
+	constant.FileFilter:     "fileFilterResult",
+	constant.Sysctl:         "sysctlResult",
+	constant.Systemd:        "systemdResult",
+	constant.CustomCommand:  "commandResult",
+	constant.NodeInfo:       "nodeInfo",
+	constant.Component:      "componentResult",
+	constant.ServiceConnect: "serviceConnectResult",
+}
+
+// Suite is a rule test file. Paths inside it are relative to the suite file.
+type Suite struct {
+	// Rules are InspectRule manifests shared by every case.
+	Rules []string `json:"rules,omitempty"`
+	Cases []Case   `json:"cases"`
+
+	dir string
+}
+
+// Case runs the rules of one rule type against a set of fixtures and compares the findings with Expected.
+type Case struct {
+	Name     string `json:"name"`
+	RuleType string `json:"ruleType"`
+	// Rules overrides the suite rules for this case.
+	Rules []string `json:"rules,omitempty"`
+	// Objects are Kubernetes manifests served by the fake cluster (opa, component, serviceConnect).
+	Objects []string `json:"objects,omitempty"`
+	// Procfs is a fake /proc tree (sysctl, nodeInfo).
+	Procfs string `json:"procfs,omitempty"`
+	// Rootfs is a fake host root tree (fileChange, fileFilter).
+	Rootfs string `json:"rootfs,omitempty"`
+	// BaseFiles maps a fileChange rule name to the baseline file it is compared with.
+	BaseFiles map[string]string `json:"baseFiles,omitempty"`
+	// Prometheus is a recorded response file mapping each query to the body returned by /api/v1/query.
+	Prometheus string `json:"prometheus,omitempty"`
+	NodeName   string `json:"nodeName,omitempty"`
+	// Expected holds the expected findings, i.e. the InspectResultSpec field of the rule type.
+	Expected string `json:"expected"`
+}
+
+type CaseResult struct {
+	Name   string
+	Passed bool
+	Diff   string
+	Err    error
+}
+
+func LoadSuite(file string) (*Suite, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var suite Suite
+	if err = yaml.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("failed to parse suite %s: %s", file, err)
+	}
+	suite.dir = filepath.Dir(file)
+	return &suite, nil
+}
+
+// Run runs every case whose name matches filter. With update set, the expected files are rewritten from the actual findings.
+func (s *Suite) Run(ctx context.Context, filter *regexp.Regexp, update bool) []CaseResult {
+	var results []CaseResult
+	for _, c := range s.Cases {
+		if filter != nil && !filter.MatchString(c.Name) {
+			continue
+		}
+		result := CaseResult{Name: c.Name}
+		actual, err := s.runCase(ctx, c)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		expectedPath := s.path(c.Expected)
+		if update {
+			data, err := yaml.JSONToYAML(actual)
+			if err == nil {
+				err = os.WriteFile(expectedPath, data, 0644)
+			}
+			result.Err = err
+			result.Passed = err == nil
+			results = append(results, result)
+			continue
+		}
+		expected, err := readExpected(expectedPath)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		result.Passed = bytes.Equal(expected, actual)
+		if !result.Passed {
+			result.Diff = diffLines(string(expected), string(actual))
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (s *Suite) runCase(ctx context.Context, c Case) ([]byte, error) {
+	key, exist := resultKeys[c.RuleType]
+	if !exist {
+		return nil, fmt.Errorf("unknown rule type %q", c.RuleType)
+	}
+	if c.RuleType == constant.Systemd || c.RuleType == constant.CustomCommand {
+		return nil, fmt.Errorf("rule type %s depends on the live host and can not run against fixtures", c.RuleType)
+	}
+	ruleFiles := s.Rules
+	if len(c.Rules) > 0 {
+		ruleFiles = c.Rules
+	}
+	inspectRules, err := s.loadRules(ruleFiles)
+	if err != nil {
+		return nil, err
+	}
+	nodeName := c.NodeName
+	if nodeName == "" {
+		nodeName = defaultNodeName
+	}
+
+	f, err := s.newFixture(ctx, c, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.close()
+
+	if f.prometheus != nil {
+		for i := range inspectRules {
+			inspectRules[i].Spec.PrometheusEndpoint = f.prometheus.URL
+			for p := range inspectRules[i].Spec.Prometheus {
+				inspectRules[i].Spec.Prometheus[p].Endpoint = f.prometheus.URL
+			}
+		}
+	}
+
+	task := &kubeeyev1alpha2.InspectTask{ObjectMeta: metav1.ObjectMeta{Name: "ruletest"}}
+	for _, r := range inspectRules {
+		task.Spec.RuleNames = append(task.Spec.RuleNames, kubeeyev1alpha2.InspectRuleNames{Name: r.Name})
+	}
+	executeRule := rules.NewExecuteRuleOptions(f.clients, task)
+	ruleSpec, err := executeRule.MergeRule(inspectRules)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &kubeeyev1alpha2.InspectResult{}
+	for _, jobRule := range executeRule.GenerateJob(ctx, ruleSpec) {
+		if jobRule.RuleType != c.RuleType {
+			continue
+		}
+		result, err = inspect.RunLocalInspect(ctx, f.clients, jobRule, nodeName, f.hostPaths, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	specData, err := json.Marshal(result.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var spec map[string]interface{}
+	if err = json.Unmarshal(specData, &spec); err != nil {
+		return nil, err
+	}
+	return canonicalJSON(spec[key])
+}
+
+func (s *Suite) loadRules(files []string) ([]kubeeyev1alpha2.InspectRule, error) {
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, s.path(file))
+	}
+	return rules.LoadRuleFiles(paths...)
+}
+
+func (s *Suite) path(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(s.dir, p)
+}
+
+func readExpected(file string) ([]byte, error) {
+	if file == "" {
+		return nil, fmt.Errorf("expected file is not set")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err = json.Unmarshal(jsonData, &v); err != nil {
+		return nil, err
+	}
+	return canonicalJSON(v)
+}
+
+// readDocuments splits a multi-document YAML file.
+func readDocuments(file string) ([][]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return utils.SplitYamlDocuments(data), nil
+}
+
+// canonicalJSON renders v with sorted map keys and sorted arrays, so findings produced concurrently compare equal.
+func canonicalJSON(v interface{}) ([]byte, error) {
+	return json.MarshalIndent(canonical(v), "", "  ")
+}
+
+func canonical(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k := range val {
+			val[k] = canonical(val[k])
+		}
+		return val
+	case []interface{}:
+		keys := make([]string, len(val))
+		for i := range val {
+			val[i] = canonical(val[i])
+			data, err := json.Marshal(val[i])
+			if err != nil {
+				klog.Error("failed to marshal finding", err)
+			}
+			keys[i] = string(data)
+		}
+		sort.Sort(byKey{items: val, keys: keys})
+		return val
+	}
+	return v
+}
+
+type byKey struct {
+	items []interface{}
+	keys  []string
+}
+
+func (b byKey) Len() int           { return len(b.items) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.items[i], b.items[j] = b.items[j], b.items[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+// diffLines returns a line diff of expected and actual, prefixing removed lines with "-" and added lines with "+".
+func diffLines(expected string, actual string) string {
+	a := strings.Split(strings.TrimSuffix(expected, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(actual, "\n"), "\n")
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,65 @@
+package ruletest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const exampleSuite = "../../examples/ruletest/suite.yaml"
+
+func TestExampleSuite(t *testing.T) {
+	suite, err := LoadSuite(exampleSuite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := suite.Run(context.TODO(), nil, false)
+	if len(results) != len(suite.Cases) {
+		t.Fatalf("ran %d cases, want %d", len(results), len(suite.Cases))
+	}
+	for _, result := range results {
+		t.Run(result.Name, func(t *testing.T) {
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if !result.Passed {
+				t.Errorf("findings differ from the expected file:\n%s", result.Diff)
+			}
+		})
+	}
+}
+
+func TestSuiteReportsDiff(t *testing.T) {
+	suite, err := LoadSuite(exampleSuite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range suite.Cases {
+		if suite.Cases[i].Name != "sysctl-values" {
+			continue
+		}
+		expected := filepath.Join(t.TempDir(), "sysctl.yaml")
+		if err = os.WriteFile(expected, []byte("[]\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		suite.Cases[i].Expected = expected
+	}
+
+	var found bool
+	for _, result := range suite.Run(context.TODO(), nil, false) {
+		if result.Name != "sysctl-values" {
+			continue
+		}
+		found = true
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		if result.Passed || result.Diff == "" {
+			t.Errorf("a case with other findings passed, diff %q", result.Diff)
+		}
+	}
+	if !found {
+		t.Fatal("the example suite has no sysctl-values case")
+	}
+}
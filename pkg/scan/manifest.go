@@ -0,0 +1,116 @@
+package scan
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ghodss/yaml"
+	"github.com/kubesphere/kubeeye/pkg/utils"
+	"io"
+	"io/fs"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Stdin is the path that makes ReadManifests read from standard input.
+const Stdin = "-"
+
+// Manifest is a Kubernetes object together with where it was read from.
+type Manifest struct {
+	File   string
+	Line   int
+	Object unstructured.Unstructured
+}
+
+// ReadManifests parses multi-document YAML files, directories or stdin into objects.
+// Documents without apiVersion and kind, such as helm values files, are skipped.
+func ReadManifests(paths []string, stdin io.Reader) ([]Manifest, error) {
+	var manifests []Manifest
+	for _, p := range paths {
+		if p == Stdin {
+			data, err := io.ReadAll(stdin)
+			if err != nil {
+				return nil, err
+			}
+			m, err := parseManifests("stdin", data)
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, m...)
+			continue
+		}
+		err := filepath.WalkDir(p, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if file != p && !utils.IsManifestFile(file) {
+				return nil
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			m, err := parseManifests(filepath.ToSlash(file), data)
+			if err != nil {
+				return err
+			}
+			manifests = append(manifests, m...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifests, nil
+}
+
+func parseManifests(file string, data []byte) ([]Manifest, error) {
+	var manifests []Manifest
+	docs := utils.SplitYamlDocuments(data)
+	lines := documentLines(data, docs)
+	for i, doc := range docs {
+		var obj unstructured.Unstructured
+		if err := yaml.Unmarshal(doc, &obj.Object); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", file, lines[i], err)
+		}
+		if obj.Object == nil || obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+			continue
+		}
+		if obj.IsList() {
+			list, err := obj.ToList()
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", file, lines[i], err)
+			}
+			for _, item := range list.Items {
+				manifests = append(manifests, Manifest{File: file, Line: lines[i], Object: item})
+			}
+			continue
+		}
+		manifests = append(manifests, Manifest{File: file, Line: lines[i], Object: obj})
+	}
+	return manifests, nil
+}
+
+// documentLines returns the first content line of each document split from data, which the documents are
+// substrings of in order.
+func documentLines(data []byte, docs [][]byte) []int {
+	lines := make([]int, len(docs))
+	offset := 0
+	for i, doc := range docs {
+		offset += bytes.Index(data[offset:], doc)
+		lines[i] = bytes.Count(data[:offset], []byte("\n")) + 1
+		for _, line := range strings.Split(string(doc), "\n") {
+			trimmed := strings.TrimSpace(line)
+			if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				break
+			}
+			lines[i]++
+		}
+		offset += len(doc)
+	}
+	return lines
+}
@@ -0,0 +1,188 @@
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	kubeeyev1alpha2 "github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/inspect"
+	"github.com/kubesphere/kubeeye/pkg/output"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+	FormatJUnit = "junit"
+)
+
+// Finding is one rego rule violation of a manifest.
+type Finding struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type Report struct {
+	Resources int       `json:"resources"`
+	Findings  []Finding `json:"findings"`
+
+	manifests []Manifest
+}
+
+// RegoRules collects the opa rules of the given InspectRules.
+func RegoRules(inspectRules []kubeeyev1alpha2.InspectRule) []string {
+	var regoRules []string
+	for _, rule := range inspectRules {
+		for _, opa := range rule.Spec.Opas {
+			regoRules = append(regoRules, opa.Rule)
+		}
+	}
+	return regoRules
+}
+
+// Scan validates every manifest with the rego rules, the same way the opa inspector validates cluster resources.
+func Scan(ctx context.Context, manifests []Manifest, regoRules []string) *Report {
+	report := &Report{Resources: len(manifests), Findings: []Finding{}, manifests: manifests}
+	for _, m := range manifests {
+		result, found := inspect.ValidateResource(ctx, m.Object, regoRules)
+		if !found {
+			continue
+		}
+		for _, item := range result.ResultItems {
+			report.Findings = append(report.Findings, Finding{
+				File:      m.File,
+				Line:      m.Line,
+				Kind:      m.Object.GetKind(),
+				Name:      m.Object.GetName(),
+				Namespace: m.Object.GetNamespace(),
+				Level:     output.NormalizeLevel(item.Level),
+				Message:   item.Message,
+				Reason:    item.Reason,
+			})
+		}
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Message < b.Message
+	})
+	return report
+}
+
+// CountAtLeast returns how many findings are at least as severe as level.
+func (r *Report) CountAtLeast(level string) int {
+	threshold := output.LevelRank(level)
+	count := 0
+	for _, f := range r.Findings {
+		if output.LevelRank(f.Level) >= threshold {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *Report) Write(w io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case FormatJSON, "":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case FormatSARIF:
+		return r.sarif().Write(w)
+	case FormatJUnit:
+		return r.junit().Write(w)
+	}
+	return fmt.Errorf("unsupported output format %s", format)
+}
+
+func (r *Report) sarif() *output.SarifLog {
+	var rules []output.SarifRule
+	ruleIndex := map[string]bool{}
+	var results []output.SarifResult
+	for _, f := range r.Findings {
+		if !ruleIndex[f.Message] {
+			ruleIndex[f.Message] = true
+			rules = append(rules, output.SarifRule{
+				ID:                   f.Message,
+				Name:                 f.Message,
+				ShortDescription:     &output.SarifMessage{Text: f.Message},
+				DefaultConfiguration: &output.SarifRuleDefaults{Level: output.SarifLevel(f.Level)},
+			})
+		}
+		text := fmt.Sprintf("%s %s: %s", f.Kind, resourceName(f.Namespace, f.Name), f.Message)
+		if f.Reason != "" {
+			text = fmt.Sprintf("%s (%s)", text, f.Reason)
+		}
+		results = append(results, output.SarifResult{
+			RuleID:  f.Message,
+			Level:   output.SarifLevel(f.Level),
+			Message: output.SarifMessage{Text: text},
+			Locations: []output.SarifLocation{{
+				PhysicalLocation: &output.SarifPhysicalLocation{
+					ArtifactLocation: output.SarifArtifactLocation{URI: f.File},
+					Region:           &output.SarifRegion{StartLine: f.Line},
+				},
+				LogicalLocations: []output.SarifLogicalLocation{{
+					Name:               f.Name,
+					FullyQualifiedName: fmt.Sprintf("%s/%s", f.Kind, resourceName(f.Namespace, f.Name)),
+					Kind:               f.Kind,
+				}},
+			}},
+		})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return output.NewSarifLog(rules, results)
+}
+
+// junit reports one suite per file and one test case per resource, failing with all of its findings.
+func (r *Report) junit() *output.JUnitTestSuites {
+	report := &output.JUnitTestSuites{Name: "kubeeye"}
+	findings := map[string][]Finding{}
+	for _, f := range r.Findings {
+		key := fmt.Sprintf("%s:%d/%s/%s/%s", f.File, f.Line, f.Kind, f.Namespace, f.Name)
+		findings[key] = append(findings[key], f)
+	}
+	var files []string
+	suites := map[string]*output.JUnitTestSuite{}
+	for _, m := range r.manifests {
+		suite, exist := suites[m.File]
+		if !exist {
+			suite = &output.JUnitTestSuite{Name: m.File}
+			suites[m.File] = suite
+			files = append(files, m.File)
+		}
+		obj := m.Object
+		testCase := output.JUnitTestCase{
+			Name:      fmt.Sprintf("%s/%s", obj.GetKind(), resourceName(obj.GetNamespace(), obj.GetName())),
+			ClassName: fmt.Sprintf("%s:%d", m.File, m.Line),
+		}
+		for _, f := range findings[fmt.Sprintf("%s:%d/%s/%s/%s", m.File, m.Line, obj.GetKind(), obj.GetNamespace(), obj.GetName())] {
+			testCase.Fail(f.Level, f.Message, strings.TrimSpace(fmt.Sprintf("%s:%d %s %s", f.File, f.Line, f.Message, f.Reason)))
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	for _, file := range files {
+		report.AddSuite(*suites[file])
+	}
+	return report
+}
+
+func resourceName(namespace string, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
@@ -0,0 +1,139 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubeeye/pkg/output"
+	"github.com/kubesphere/kubeeye/pkg/rules"
+)
+
+const podManifests = `# pods of the app
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  containers:
+    - name: nginx
+      image: nginx:1.25
+---
+# values without a kind are skipped
+replicas: 2
+---
+
+apiVersion: v1
+kind: Pod
+metadata:
+  name: secured
+  namespace: default
+spec:
+  securityContext:
+    runAsNonRoot: true
+  containers:
+    - name: app
+      image: busybox:1.36
+`
+
+func scanPods(t *testing.T) *Report {
+	manifests, err := ReadManifests([]string{Stdin}, strings.NewReader(podManifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inspectRules, err := rules.LoadRuleFiles("../../examples/ruletest/rules/opa.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Scan(context.TODO(), manifests, RegoRules(inspectRules))
+}
+
+func TestReadManifests(t *testing.T) {
+	manifests, err := ReadManifests([]string{Stdin}, strings.NewReader(podManifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		name string
+		line int
+	}{{"nginx", 2}, {"secured", 16}}
+	if len(manifests) != len(want) {
+		t.Fatalf("read %d manifests, want %d", len(manifests), len(want))
+	}
+	for i, m := range manifests {
+		if m.Object.GetName() != want[i].name || m.Line != want[i].line || m.File != "stdin" {
+			t.Errorf("manifest %d = %s at %s:%d, want %s at stdin:%d", i, m.Object.GetName(), m.File, m.Line, want[i].name, want[i].line)
+		}
+	}
+}
+
+func TestScanFailOnLevels(t *testing.T) {
+	report := scanPods(t)
+	if len(report.Findings) != 1 {
+		t.Fatalf("findings = %+v, want the pod not running as non-root", report.Findings)
+	}
+	if f := report.Findings[0]; f.Name != "nginx" || f.Line != 2 || f.Level != "warning" || f.Message != "NotRunAsNonRoot" {
+		t.Errorf("finding = %+v", f)
+	}
+	for level, want := range map[string]int{"danger": 0, "warning": 1, "ignore": 1} {
+		if got := report.CountAtLeast(level); got != want {
+			t.Errorf("CountAtLeast(%s) = %d, want %d", level, got, want)
+		}
+	}
+}
+
+func TestWriteSarif(t *testing.T) {
+	var buf bytes.Buffer
+	if err := scanPods(t).Write(&buf, FormatSARIF); err != nil {
+		t.Fatal(err)
+	}
+	var log output.SarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid sarif: %s", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("sarif version %s with %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != "NotRunAsNonRoot" {
+		t.Errorf("rules = %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("results = %+v", run.Results)
+	}
+	result := run.Results[0]
+	location := result.Locations[0].PhysicalLocation
+	if result.Level != "warning" || location.ArtifactLocation.URI != "stdin" || location.Region.StartLine != 2 {
+		t.Errorf("result = %+v at %+v", result, location)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := scanPods(t).Write(&buf, FormatJUnit); err != nil {
+		t.Fatal(err)
+	}
+	var suites output.JUnitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("invalid junit: %s", err)
+	}
+	if suites.Tests != 2 || suites.Failures != 1 || len(suites.Suites) != 1 {
+		t.Fatalf("junit has %d tests, %d failures in %d suites", suites.Tests, suites.Failures, len(suites.Suites))
+	}
+	for _, testCase := range suites.Suites[0].TestCases {
+		failed := testCase.Failure != nil
+		if failed != (testCase.Name == "Pod/default/nginx") {
+			t.Errorf("test case %s failed: %v", testCase.Name, failed)
+		}
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := scanPods(t).Write(&bytes.Buffer{}, "yaml"); err == nil {
+		t.Error("an unknown format is accepted")
+	}
+}
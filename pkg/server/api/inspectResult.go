@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"github.com/gin-gonic/gin"
@@ -17,6 +18,8 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -108,13 +111,19 @@ func (i *InspectResult) ListInspectResult(gin *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        name path string true "name"
-// @Param        type query string false "type"
+// @Param        type query string false "type=html|json|customized|markdown|text|report"
+// @Param        top query int false "number of top findings in the markdown/text summary, top=10"
+// @Param        template query string false "render with a report template ConfigMap, template=my-report"
 // @Success      200 {object} v1alpha2.InspectResult
 // @Router       /inspectresults/{name} [get]
 func (i *InspectResult) GetInspectResult(gin *gin.Context) {
 	name := gin.Param("name")
 	query.ParseQuery(gin)
 	outType, _ := gin.GetQuery("type")
+	if templateName, ok := gin.GetQuery("template"); ok {
+		i.renderReportTemplate(gin, name, templateName)
+		return
+	}
 	switch outType {
 	case "html":
 		err, m := output.HtmlOut(name)
@@ -130,6 +139,45 @@ func (i *InspectResult) GetInspectResult(gin *gin.Context) {
 			return
 		}
 		gin.JSON(http.StatusOK, data)
+	case output.FormatMarkdown, output.FormatText:
+		data, err := i.GetFileResultData(name)
+		if err != nil {
+			gin.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "InspectResult"))
+			return
+		}
+		topN := output.DefaultTopFindings
+		if top, ok := gin.GetQuery("top"); ok {
+			if n, err := strconv.Atoi(top); err == nil && n > 0 {
+				topN = n
+			}
+		}
+		buffer := &bytes.Buffer{}
+		if outType == output.FormatMarkdown {
+			err = output.MarkdownOut(buffer, data, topN)
+		} else {
+			err = output.TextOut(buffer, data, topN)
+		}
+		if err != nil {
+			gin.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "InspectResult"))
+			return
+		}
+		contentType := "text/markdown; charset=utf-8"
+		if outType == output.FormatText {
+			contentType = "text/plain; charset=utf-8"
+		}
+		gin.Data(http.StatusOK, contentType, buffer.Bytes())
+	case output.FormatReport:
+		data, err := i.GetFileResultData(name)
+		if err != nil {
+			gin.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "InspectResult"))
+			return
+		}
+		buffer := &bytes.Buffer{}
+		if err = output.HtmlReportOut(buffer, data, i.getHistory(name)); err != nil {
+			gin.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "InspectResult"))
+			return
+		}
+		gin.Data(http.StatusOK, "text/html; charset=utf-8", buffer.Bytes())
 	case "customized":
 		data, err := i.GetFileResultData(name)
 		if err != nil {
@@ -164,8 +212,75 @@ func (i *InspectResult) GetInspectResult(gin *gin.Context) {
 
 }
 
+func (i *InspectResult) renderReportTemplate(gin *gin.Context, name string, templateName string) {
+	reportTemplate, err := output.LoadReportTemplate(i.Ctx, i.Clients.ClientSet, os.Getenv("KUBERNETES_POD_NAMESPACE"), templateName)
+	if err != nil {
+		gin.JSON(http.StatusBadRequest, NewErrors(err.Error(), "InspectResult"))
+		return
+	}
+	data, err := i.GetFileResultData(name)
+	if err != nil {
+		gin.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "InspectResult"))
+		return
+	}
+	buffer := &bytes.Buffer{}
+	if err = reportTemplate.Execute(buffer, output.NewReportData(data)); err != nil {
+		gin.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "InspectResult"))
+		return
+	}
+	gin.Data(http.StatusOK, reportTemplate.ContentType+"; charset=utf-8", buffer.Bytes())
+}
+
+// ListReportTemplates godoc
+// @Summary      List report templates
+// @Description  list the names of the report template ConfigMaps
+// @Tags         InspectResult
+// @Produce      json
+// @Success      200 {array} string
+// @Router       /reporttemplates [get]
+func (i *InspectResult) ListReportTemplates(gin *gin.Context) {
+	names, err := output.ListReportTemplates(i.Ctx, i.Clients.ClientSet, os.Getenv("KUBERNETES_POD_NAMESPACE"))
+	if err != nil {
+		gin.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "ConfigMap"))
+		return
+	}
+	gin.JSON(http.StatusOK, query.Result{TotalItems: len(names), Items: names})
+}
+
+// DownloadInspectResult godoc
+// @Summary      Download an InspectResult
+// @Description  download the inspect result as xlsx, as flattened csv/tsv rows, as sarif/junit for CI pipelines, or as a pdf report. CJK text in the pdf needs a reader with the Adobe Asian font pack
+// @Tags         InspectResult
+// @Produce      octet-stream
+// @Param        name path string true "name"
+// @Param        format query string false "format=xlsx|csv|tsv|sarif|junit|pdf"
+// @Success      200 {file} file
+// @Router       /inspectresults/{name}/download [get]
 func (i *InspectResult) DownloadInspectResult(c *gin.Context) {
 	name := c.Param("name")
+	format := strings.ToLower(c.DefaultQuery("format", output.FormatExcel))
+	switch format {
+	case output.FormatCSV, output.FormatTSV, output.FormatSARIF, output.FormatJUnit, output.FormatPDF:
+		data, err := i.GetFileResultData(name)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "InspectResult"))
+			return
+		}
+		// render first, so that a failure is reported before any header or body is sent
+		var buffer bytes.Buffer
+		if err = output.Render(&buffer, format, data); err != nil {
+			klog.Error("failed to render inspect result, err:", err)
+			c.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "InspectResult"))
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename="+output.FormatFileName(path.Base(name), format))
+		c.Data(http.StatusOK, output.FormatContentType(format), buffer.Bytes())
+		return
+	case output.FormatExcel:
+	default:
+		c.JSON(http.StatusBadRequest, NewErrors("unsupported download format "+format, "InspectResult"))
+		return
+	}
 	filePath := path.Join(constant.ResultPathPrefix, name)
 	c.Header("Content-Type", "application/octet-stream")
 	c.Header("Content-Disposition", "attachment; filename="+path.Base(filePath))
@@ -197,6 +312,39 @@ func (i *InspectResult) filter(data map[string]interface{}, f *query.Filter) boo
 	return false
 }
 
+// maxHistory is the number of previous results shown in the score trend of the report.
+const maxHistory = 9
+
+// getHistory returns the results created by the same plan before the named result, at most maxHistory.
+func (i *InspectResult) getHistory(name string) []*v1alpha2.InspectResult {
+	current, err := i.Factory.Lister().Get(name)
+	if err != nil || current.Labels[constant.LabelPlanName] == "" {
+		return nil
+	}
+	list, err := i.Factory.Lister().List(labels.SelectorFromSet(map[string]string{constant.LabelPlanName: current.Labels[constant.LabelPlanName]}))
+	if err != nil {
+		klog.Error("failed to list inspect results, err:", err)
+		return nil
+	}
+	sort.Slice(list, func(a, b int) bool { return list[b].CreationTimestamp.Before(&list[a].CreationTimestamp) })
+	var history []*v1alpha2.InspectResult
+	for _, item := range list {
+		if len(history) == maxHistory {
+			break
+		}
+		if !item.CreationTimestamp.Before(&current.CreationTimestamp) {
+			continue
+		}
+		data, err := i.GetFileResultData(item.Name)
+		if err != nil {
+			continue
+		}
+		data.ObjectMeta = item.ObjectMeta
+		history = append(history, data)
+	}
+	return history
+}
+
 func (i *InspectResult) GetFileResultData(name string) (*v1alpha2.InspectResult, error) {
 	var results v1alpha2.InspectResult
 	file, err := os.ReadFile(path.Join(constant.ResultPathPrefix, name))
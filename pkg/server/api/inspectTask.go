@@ -1,31 +1,43 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
 	versionsv1alpha2 "github.com/kubesphere/kubeeye/clients/informers/externalversions/kubeeye/v1alpha2"
 	"github.com/kubesphere/kubeeye/pkg/constant"
 	"github.com/kubesphere/kubeeye/pkg/kube"
+	"github.com/kubesphere/kubeeye/pkg/output"
 	"github.com/kubesphere/kubeeye/pkg/server/query"
+	"github.com/kubesphere/kubeeye/pkg/template"
 	"github.com/kubesphere/kubeeye/pkg/utils"
+	"io"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
 	"net/http"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
 )
 
 type InspectTask struct {
-	Clients *kube.KubernetesClient
-	Ctx     context.Context
-	Factory versionsv1alpha2.InspectTaskInformer
+	Clients       *kube.KubernetesClient
+	Ctx           context.Context
+	Factory       versionsv1alpha2.InspectTaskInformer
+	ResultFactory versionsv1alpha2.InspectResultInformer
 }
 
-func NewInspectTask(ctx context.Context, clients *kube.KubernetesClient, f versionsv1alpha2.InspectTaskInformer) *InspectTask {
+func NewInspectTask(ctx context.Context, clients *kube.KubernetesClient, f versionsv1alpha2.InspectTaskInformer, r versionsv1alpha2.InspectResultInformer) *InspectTask {
 	return &InspectTask{
-		Clients: clients,
-		Ctx:     ctx,
-		Factory: f,
+		Clients:       clients,
+		Ctx:           ctx,
+		Factory:       f,
+		ResultFactory: r,
 	}
 }
 
@@ -96,6 +108,175 @@ func (i *InspectTask) DeleteInspectTask(gin *gin.Context) {
 	gin.String(http.StatusOK, "success")
 }
 
+// CancelInspectTask  godoc
+// @Summary      Cancel an InspectTask
+// @Description  delete the outstanding jobs of the task and mark it Cancelled, the results of the finished jobs are kept. A finished task can not be cancelled, 409 is returned
+// @Tags         InspectTask
+// @Produce      json
+// @Param        name path string true "name"
+// @Success      200 {object} v1alpha2.InspectTask
+// @Router       /inspecttasks/{name}/cancel [put]
+func (i *InspectTask) CancelInspectTask(gin *gin.Context) {
+	name := gin.Param("name")
+	task, err := i.Clients.VersionClientSet.KubeeyeV1alpha2().InspectTasks().Get(i.Ctx, name, metav1.GetOptions{})
+	if err != nil {
+		gin.JSON(http.StatusNotFound, NewErrors(err.Error(), "InspectTask"))
+		return
+	}
+	if task.Status.Status.IsFinished() {
+		gin.JSON(http.StatusConflict, NewErrors(fmt.Sprintf("inspect task %s has already finished", name), "InspectTask"))
+		return
+	}
+	task, err = i.Clients.VersionClientSet.KubeeyeV1alpha2().InspectTasks().Patch(i.Ctx, name, types.MergePatchType, []byte(`{"spec":{"cancel":true}}`), metav1.PatchOptions{})
+	if err != nil {
+		gin.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "InspectTask"))
+		return
+	}
+	gin.JSON(http.StatusOK, task)
+}
+
+// RerunInspectTask  godoc
+// @Summary      Rerun an InspectTask
+// @Description  create a new task with the spec of the task under the same plan, optionally with only its failed jobs
+// @Tags         InspectTask
+// @Produce      json
+// @Param        name path string true "name"
+// @Param        failedOnly query bool false "failedOnly=true"
+// @Success      200 {object} v1alpha2.InspectTask
+// @Router       /inspecttasks/{name}/rerun [post]
+func (i *InspectTask) RerunInspectTask(gin *gin.Context) {
+	name := gin.Param("name")
+	task, err := i.Clients.VersionClientSet.KubeeyeV1alpha2().InspectTasks().Get(i.Ctx, name, metav1.GetOptions{})
+	if err != nil {
+		gin.JSON(http.StatusNotFound, NewErrors(err.Error(), "InspectTask"))
+		return
+	}
+	rerun, err := template.RerunTaskTemplate(task, gin.Query("failedOnly") == "true")
+	if err != nil {
+		gin.JSON(http.StatusBadRequest, NewErrors(err.Error(), "InspectTask"))
+		return
+	}
+	rerun, err = i.Clients.VersionClientSet.KubeeyeV1alpha2().InspectTasks().Create(i.Ctx, rerun, metav1.CreateOptions{})
+	if err != nil {
+		gin.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "InspectTask"))
+		return
+	}
+	gin.JSON(http.StatusOK, rerun)
+}
+
+// TaskProgressEvent is the data of the progress events of a task
+type TaskProgressEvent struct {
+	Name     string                 `json:"name"`
+	Status   v1alpha2.Phase         `json:"status,omitempty"`
+	Progress *v1alpha2.TaskProgress `json:"progress,omitempty"`
+}
+
+var (
+	// progressInterval is how often the progress of a task is checked for changes
+	progressInterval = 2 * time.Second
+	// heartbeatInterval is how often a comment is sent on the progress stream, so that proxies closing idle
+	// connections, often after 60s, keep it open while the progress doesn't change
+	heartbeatInterval = 15 * time.Second
+)
+
+// StreamInspectTaskProgress  godoc
+// @Summary      Stream the progress of an InspectTask
+// @Description  Server-Sent Events with the job counts, the progress per rule type and the estimated completion time of the task, sent when they change, with a heartbeat comment every 15s. The stream ends when the task has finished.
+// @Tags         InspectTask
+// @Produce      text/event-stream
+// @Param        name path string true "name"
+// @Success      200 {object} TaskProgressEvent
+// @Router       /inspecttasks/{name}/progress [get]
+func (i *InspectTask) StreamInspectTaskProgress(c *gin.Context) {
+	name := c.Param("name")
+	if _, err := i.Factory.Lister().Get(name); err != nil {
+		c.JSON(http.StatusNotFound, NewErrors(err.Error(), "InspectTask"))
+		return
+	}
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	var last *TaskProgressEvent
+	c.Stream(func(w io.Writer) bool {
+		task, err := i.Factory.Lister().Get(name)
+		if err != nil {
+			c.SSEvent("error", NewErrors(err.Error(), "InspectTask"))
+			return false
+		}
+		event := &TaskProgressEvent{Name: task.Name, Status: task.Status.Status, Progress: task.Status.Progress}
+		if last == nil || !reflect.DeepEqual(last, event) {
+			c.SSEvent("progress", event)
+			last = event
+		}
+		if task.Status.Status.IsFinished() {
+			return false
+		}
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-ticker.C:
+			return true
+		case <-heartbeat.C:
+			// a comment line, ignored by EventSource clients
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		}
+	})
+}
+
+// GetInspectTaskReport godoc
+// @Summary      Get the aggregated report of an InspectTask
+// @Description  merge the results of every cluster of the task: scores side by side, findings shared by several clusters, the worst clusters per category and the findings of each cluster
+// @Tags         InspectTask
+// @Produce      json
+// @Param        name path string true "name"
+// @Param        format query string false "format=json|markdown|text|html|csv|tsv|sarif|junit|pdf, CJK text in the pdf needs a reader with the Adobe Asian font pack"
+// @Param        download query bool false "download=true"
+// @Success      200 {object} output.AggregateReport
+// @Router       /inspecttasks/{name}/report [get]
+func (i *InspectTask) GetInspectTaskReport(c *gin.Context) {
+	name := c.Param("name")
+	format := strings.ToLower(c.DefaultQuery("format", output.FormatJSON))
+	if !output.IsAggregateFormat(format) {
+		c.JSON(http.StatusBadRequest, NewErrors("unsupported report format "+format, "InspectTask"))
+		return
+	}
+	if _, err := i.Factory.Lister().Get(name); err != nil {
+		c.JSON(http.StatusNotFound, NewErrors(err.Error(), "InspectTask"))
+		return
+	}
+	list, err := i.ResultFactory.Lister().List(labels.SelectorFromSet(map[string]string{constant.LabelTaskName: name}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "InspectResult"))
+		return
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Name < list[b].Name })
+	var results []*v1alpha2.InspectResult
+	for _, item := range list {
+		data, err := output.ReadResult(item.Name)
+		if err != nil {
+			klog.Errorf("failed to read inspect result %s, err: %s", item.Name, err)
+			continue
+		}
+		data.ObjectMeta = item.ObjectMeta
+		results = append(results, data)
+	}
+
+	// render first, so that a failure is reported before any header or body is sent
+	var report bytes.Buffer
+	if err = output.RenderAggregate(&report, format, output.Aggregate(name, results)); err != nil {
+		klog.Error("failed to render inspect task report, err:", err)
+		c.JSON(http.StatusInternalServerError, NewErrors(err.Error(), "InspectTask"))
+		return
+	}
+	if c.Query("download") == "true" || format == output.FormatPDF {
+		c.Header("Content-Disposition", "attachment; filename="+output.FormatFileName(name+"-report", format))
+	}
+	c.Data(http.StatusOK, output.FormatContentType(format), report.Bytes())
+}
+
 func (i *InspectTask) compare(a, b map[string]interface{}, orderBy string) bool {
 	left := utils.MapToStruct[v1alpha2.InspectTask](a)[0]
 	right := utils.MapToStruct[v1alpha2.InspectTask](b)[0]
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	versionedfake "github.com/kubesphere/kubeeye/clients/clientset/versioned/fake"
+	listersv1alpha2 "github.com/kubesphere/kubeeye/clients/listers/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	"github.com/kubesphere/kubeeye/pkg/kube"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// taskInformer serves the tasks of an indexer.
+type taskInformer struct {
+	indexer cache.Indexer
+}
+
+func (i taskInformer) Informer() cache.SharedIndexInformer {
+	return nil
+}
+
+func (i taskInformer) Lister() listersv1alpha2.InspectTaskLister {
+	return listersv1alpha2.NewInspectTaskLister(i.indexer)
+}
+
+func TestStreamInspectTaskProgress(t *testing.T) {
+	defer func(progress, heartbeat time.Duration) {
+		progressInterval, heartbeatInterval = progress, heartbeat
+	}(progressInterval, heartbeatInterval)
+	progressInterval, heartbeatInterval = 20*time.Millisecond, 50*time.Millisecond
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	task := &v1alpha2.InspectTask{
+		ObjectMeta: metav1.ObjectMeta{Name: "task"},
+		Status: v1alpha2.InspectTaskStatus{
+			Status:   v1alpha2.PhaseRunning,
+			Progress: &v1alpha2.TaskProgress{JobCounts: v1alpha2.JobCounts{Total: 2, Running: 2}},
+		},
+	}
+	if err := indexer.Add(task); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/inspecttasks/:name/progress", (&InspectTask{Factory: taskInformer{indexer: indexer}}).StreamInspectTaskProgress)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/inspecttasks/missing/progress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status of a missing task = %d, want 404", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/inspecttasks/task/progress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Errorf("content type = %s, want text/event-stream", resp.Header.Get("Content-Type"))
+	}
+
+	var events, heartbeats int
+	finished := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "event:progress":
+			events++
+		case line == ": heartbeat":
+			heartbeats++
+			if heartbeats == 2 && !finished {
+				// the progress is sent once while it doesn't change
+				if events != 1 {
+					t.Errorf("progress events = %d before the task changed, want 1", events)
+				}
+				finished = true
+				done := task.DeepCopy()
+				done.Status.Status = v1alpha2.PhaseSucceeded
+				done.Status.Progress.JobCounts = v1alpha2.JobCounts{Total: 2, Succeeded: 2}
+				if err = indexer.Update(done); err != nil {
+					t.Fatal(err)
+				}
+			}
+		case strings.HasPrefix(line, "data:") && events == 2:
+			if !strings.Contains(line, `"status":"Succeeded"`) || !strings.Contains(line, `"succeeded":2`) {
+				t.Errorf("unexpected last event %s", line)
+			}
+		}
+	}
+	if events != 2 || heartbeats < 2 {
+		t.Errorf("progress events = %d, heartbeats = %d, want 2 events and the stream closed once the task finished", events, heartbeats)
+	}
+}
+
+// taskOperationsRouter serves the cancel and rerun operations of the tasks. The tasks are created through the
+// clientset, the generated fake tracks them under its own group.
+func taskOperationsRouter(t *testing.T, tasks ...*v1alpha2.InspectTask) (*gin.Engine, *versionedfake.Clientset) {
+	clientSet := versionedfake.NewSimpleClientset()
+	for _, task := range tasks {
+		if _, err := clientSet.KubeeyeV1alpha2().InspectTasks().Create(context.TODO(), task, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	i := &InspectTask{Clients: &kube.KubernetesClient{VersionClientSet: clientSet}, Ctx: context.TODO()}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PUT("/inspecttasks/:name/cancel", i.CancelInspectTask)
+	router.POST("/inspecttasks/:name/rerun", i.RerunInspectTask)
+	return router, clientSet
+}
+
+func TestCancelInspectTask(t *testing.T) {
+	running := &v1alpha2.InspectTask{ObjectMeta: metav1.ObjectMeta{Name: "running"}, Status: v1alpha2.InspectTaskStatus{Status: v1alpha2.PhaseRunning}}
+	finished := &v1alpha2.InspectTask{ObjectMeta: metav1.ObjectMeta{Name: "finished"}, Status: v1alpha2.InspectTaskStatus{Status: v1alpha2.PhaseSucceeded}}
+	tests := []struct {
+		name       string
+		wantStatus int
+		wantCancel bool
+	}{
+		{name: "running", wantStatus: http.StatusOK, wantCancel: true},
+		{name: "finished", wantStatus: http.StatusConflict},
+		{name: "missing", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, clientSet := taskOperationsRouter(t, running.DeepCopy(), finished.DeepCopy())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/inspecttasks/"+tt.name+"/cancel", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			task, err := clientSet.KubeeyeV1alpha2().InspectTasks().Get(context.TODO(), tt.name, metav1.GetOptions{})
+			if err == nil && task.Spec.Cancel != tt.wantCancel {
+				t.Errorf("cancel = %v, want %v", task.Spec.Cancel, tt.wantCancel)
+			}
+		})
+	}
+}
+
+func TestRerunInspectTask(t *testing.T) {
+	task := &v1alpha2.InspectTask{
+		ObjectMeta: metav1.ObjectMeta{Name: "plan-20240101-10-00", Labels: map[string]string{constant.LabelPlanName: "plan"}},
+		Status: v1alpha2.InspectTaskStatus{Status: v1alpha2.PhasePartiallySucceeded, JobPhase: []v1alpha2.JobPhase{
+			{JobName: "sysctl-node1", Cluster: "default", RuleType: constant.Sysctl, NodeName: "node1", Phase: v1alpha2.PhaseFailed},
+			{JobName: "systemd-node1", Cluster: "default", RuleType: constant.Systemd, NodeName: "node1", Phase: v1alpha2.PhaseSucceeded},
+		}},
+	}
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantJobs   int
+	}{
+		{name: "all jobs", target: "/inspecttasks/plan-20240101-10-00/rerun", wantStatus: http.StatusOK},
+		{name: "failed jobs", target: "/inspecttasks/plan-20240101-10-00/rerun?failedOnly=true", wantStatus: http.StatusOK, wantJobs: 1},
+		{name: "missing", target: "/inspecttasks/missing/rerun", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, clientSet := taskOperationsRouter(t, task.DeepCopy())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var rerun v1alpha2.InspectTask
+			if err := json.Unmarshal(w.Body.Bytes(), &rerun); err != nil {
+				t.Fatal(err)
+			}
+			if len(rerun.Spec.Jobs) != tt.wantJobs || rerun.Labels[constant.LabelPlanName] != "plan" {
+				t.Errorf("unexpected rerun task %+v", rerun.ObjectMeta)
+			}
+			if _, err := clientSet.KubeeyeV1alpha2().InspectTasks().Get(context.TODO(), rerun.Name, metav1.GetOptions{}); err != nil {
+				t.Errorf("the rerun task should be created: %s", err)
+			}
+		})
+	}
+}
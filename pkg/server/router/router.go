@@ -23,7 +23,7 @@ func RegisterRouter(ctx context.Context, r *gin.Engine, clients *kube.Kubernetes
 		r.SetHTMLTemplate(htmlTemplate)
 	}
 	result := api.NewInspectResult(ctx, clients, factory.V1alpha2().InspectResults())
-	task := api.NewInspectTask(ctx, clients, factory.V1alpha2().InspectTasks())
+	task := api.NewInspectTask(ctx, clients, factory.V1alpha2().InspectTasks(), factory.V1alpha2().InspectResults())
 	plan := api.NewInspectPlan(ctx, clients, factory.V1alpha2().InspectPlans())
 	rule := api.NewInspectRule(ctx, clients, factory.V1alpha2().InspectRules())
 
@@ -33,10 +33,15 @@ func RegisterRouter(ctx context.Context, r *gin.Engine, clients *kube.Kubernetes
 		v1alpha1.GET("/inspectresults", result.ListInspectResult)
 		v1alpha1.GET("/inspectresults/:name", result.GetInspectResult)
 		v1alpha1.GET("/inspectresults/:name/download", result.DownloadInspectResult)
+		v1alpha1.GET("/reporttemplates", result.ListReportTemplates)
 
 		v1alpha1.GET("/inspecttasks", task.ListInspectTask)
 		v1alpha1.GET("/inspecttasks/:name", task.GetInspectTask)
+		v1alpha1.GET("/inspecttasks/:name/report", task.GetInspectTaskReport)
+		v1alpha1.GET("/inspecttasks/:name/progress", task.StreamInspectTaskProgress)
 		v1alpha1.DELETE("/inspecttasks/:name", task.DeleteInspectTask)
+		v1alpha1.PUT("/inspecttasks/:name/cancel", task.CancelInspectTask)
+		v1alpha1.POST("/inspecttasks/:name/rerun", task.RerunInspectTask)
 
 		v1alpha1.GET("/inspectplans", plan.ListInspectPlan)
 		v1alpha1.GET("/inspectplans/:name", plan.GetInspectPlan)
@@ -0,0 +1,141 @@
+package template
+
+import hemltemplate "html/template"
+
+const AggregateReportTemplate = "aggregateReport.tpl"
+
+// GetAggregateReportHtmlTemplate returns the standalone html report of a multi-cluster task.
+func GetAggregateReportHtmlTemplate(funcs map[string]interface{}) (*hemltemplate.Template, error) {
+	return hemltemplate.New(AggregateReportTemplate).Funcs(funcs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>KubeEye report - {{ .Task }}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; margin: 0; color: #242e42; background: #f5f7fa; }
+  header { background: #242e42; color: #fff; padding: 16px 32px; }
+  header h1 { margin: 0; font-size: 22px; }
+  header .meta { font-size: 13px; color: #c3cad6; }
+  main { padding: 16px 32px; }
+  h2 { font-size: 17px; margin: 24px 0 12px; }
+  .card { background: #fff; border-radius: 4px; box-shadow: 0 1px 3px rgba(0,0,0,.08); padding: 16px; }
+  .legend span { display: inline-block; margin-right: 12px; font-size: 12px; }
+  .legend i { display: inline-block; width: 10px; height: 10px; margin-right: 4px; }
+  table { width: 100%; border-collapse: collapse; background: #fff; font-size: 13px; }
+  th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #eef0f3; vertical-align: top; word-break: break-word; }
+  th { background: #eef1f5; }
+  details { background: #fff; margin-bottom: 8px; border-radius: 4px; box-shadow: 0 1px 3px rgba(0,0,0,.08); }
+  details details { box-shadow: none; margin: 0 12px 8px; border: 1px solid #eef0f3; }
+  summary { padding: 10px 12px; cursor: pointer; font-weight: bold; }
+  summary .counts { font-weight: normal; font-size: 12px; color: #79879c; margin-left: 8px; }
+  .level { display: inline-block; padding: 1px 6px; border-radius: 2px; color: #fff; font-size: 12px; }
+  .level-danger { background: #d64545; }
+  .level-warning { background: #f0a020; }
+  .level-ignore { background: #7a8ba0; }
+  .score-good { color: #55bc8a; font-weight: bold; }
+  .score-fair { color: #f0a020; font-weight: bold; }
+  .score-poor { color: #d64545; font-weight: bold; }
+  .empty { padding: 24px; text-align: center; color: #79879c; }
+</style>
+</head>
+<body>
+<header>
+  <h1>KubeEye inspection report: {{ .Task }}</h1>
+  <div class="meta">{{ len .Clusters }} clusters</div>
+</header>
+<main>
+  <div class="card">
+    {{ .ClusterChart }}
+    <div class="legend">
+      <span><i class="level-danger"></i>danger</span>
+      <span><i class="level-warning"></i>warning</span>
+      <span><i class="level-ignore"></i>ignore</span>
+    </div>
+  </div>
+
+  <h2>Scores</h2>
+  <table>
+    <thead><tr><th>Cluster</th><th>Score</th><th>Danger</th><th>Warning</th><th>Ignore</th><th>Total</th></tr></thead>
+    <tbody>
+    {{ range .Clusters }}
+    <tr>
+      <td><a href="#cluster-{{ .Name }}">{{ .Name }}</a></td>
+      <td class="{{ scoreClass .Score }}">{{ .Score }}</td>
+      <td>{{ index .Levels "danger" }}</td><td>{{ index .Levels "warning" }}</td><td>{{ index .Levels "ignore" }}</td><td>{{ .Total }}</td>
+    </tr>
+    {{ end }}
+    </tbody>
+  </table>
+
+  <h2>Shared findings</h2>
+  {{ if .SharedFindings }}
+  <table>
+    <thead><tr><th>Level</th><th>Category</th><th>Name</th><th>Clusters</th><th>Findings</th></tr></thead>
+    <tbody>
+    {{ range .SharedFindings }}
+    <tr>
+      <td><span class="level level-{{ .Level }}">{{ .Level }}</span></td>
+      <td>{{ .RuleType }}</td><td>{{ .Name }}</td><td>{{ len .Clusters }}: {{ join ", " .Clusters }}</td><td>{{ .Count }}</td>
+    </tr>
+    {{ end }}
+    </tbody>
+  </table>
+  {{ else }}
+  <div class="card empty">No finding is shared by several clusters</div>
+  {{ end }}
+
+  <h2>Worst clusters per category</h2>
+  {{ if .WorstClusters }}
+  <table>
+    <thead><tr><th>Category</th><th>Clusters</th></tr></thead>
+    <tbody>
+    {{ range .WorstClusters }}
+    <tr><td>{{ .RuleType }}</td><td>{{ range $i, $c := .Clusters }}{{ if $i }}, {{ end }}<a href="#cluster-{{ $c.Name }}">{{ $c.Name }}</a> ({{ $c.Count }}){{ end }}</td></tr>
+    {{ end }}
+    </tbody>
+  </table>
+  {{ else }}
+  <div class="card empty">No issues found</div>
+  {{ end }}
+
+  <h2>Clusters</h2>
+  {{ range .Drilldown }}
+  <details id="cluster-{{ .Cluster }}">
+    <summary>{{ .Cluster }}<span class="counts">score {{ .Score }}, {{ .Total }} issues</span></summary>
+    {{ range .RuleTypes }}
+    <details>
+      <summary>{{ .Name }}<span class="counts">{{ .Total }} issues</span></summary>
+      <table>
+        <thead><tr><th>Level</th><th>Name</th><th>Node</th><th>Namespace</th><th>Resource</th><th>Message</th><th>Value</th></tr></thead>
+        <tbody>
+        {{ range .Findings }}
+        <tr>
+          <td><span class="level level-{{ level .Level }}">{{ level .Level }}</span></td>
+          <td>{{ .Name }}</td><td>{{ .Node }}</td><td>{{ .Namespace }}</td><td>{{ .Resource }}</td><td>{{ .Message }}</td><td>{{ .Value }}</td>
+        </tr>
+        {{ end }}
+        </tbody>
+      </table>
+    </details>
+    {{ else }}
+    <div class="empty">No issues found</div>
+    {{ end }}
+  </details>
+  {{ end }}
+</main>
+<script>
+(function () {
+  // open the drilldown of a cluster linked from the tables
+  function open() {
+    var target = document.getElementById(decodeURIComponent(location.hash.slice(1)));
+    if (target && target.tagName === "DETAILS") { target.open = true; }
+  }
+  window.addEventListener("hashchange", open);
+  open();
+})();
+</script>
+</body>
+</html>
+`)
+}
@@ -23,7 +23,7 @@ func GeneratorJobTemplate(Job JobTemplateOptions) *v1.Job {
 				Controller:         &ownerController,
 				BlockOwnerDeletion: &ownerController,
 			}},
-			Labels: map[string]string{constant.LabelRuleType: Job.RuleType, constant.LabelPlanName: Job.Task.Labels[constant.LabelPlanName]},
+			Labels: map[string]string{constant.LabelRuleType: Job.RuleType, constant.LabelPlanName: Job.Task.Labels[constant.LabelPlanName], constant.LabelTaskName: Job.Task.Name},
 		},
 		Spec: v1.JobSpec{
 			BackoffLimit:            Job.JobConfig.BackLimit,
@@ -33,7 +33,7 @@ func GeneratorJobTemplate(Job JobTemplateOptions) *v1.Job {
 				ObjectMeta: metav1.ObjectMeta{
 					Name:        "inspect-job-pod",
 					Namespace:   os.Getenv("KUBERNETES_POD_NAMESPACE"),
-					Labels:      map[string]string{constant.LabelPlanName: Job.Task.Labels[constant.LabelPlanName]},
+					Labels:      map[string]string{constant.LabelPlanName: Job.Task.Labels[constant.LabelPlanName], constant.LabelTaskName: Job.Task.Name},
 					Annotations: map[string]string{"container.apparmor.security.beta.kubernetes.io/inspect-task-kubeeye": "unconfined"},
 				},
 
@@ -0,0 +1,195 @@
+package template
+
+import hemltemplate "html/template"
+
+const InspectReportTemplate = "inspectReport.tpl"
+
+// GetInspectReportHtmlTemplate returns the standalone html report, it has no external assets so it can be mailed or archived as one file.
+func GetInspectReportHtmlTemplate(funcs map[string]interface{}) (*hemltemplate.Template, error) {
+	return hemltemplate.New(InspectReportTemplate).Funcs(funcs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>KubeEye report - {{ .Cluster }}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; margin: 0; color: #242e42; background: #f5f7fa; }
+  header { background: #242e42; color: #fff; padding: 16px 32px; display: flex; justify-content: space-between; align-items: center; }
+  header h1 { margin: 0; font-size: 22px; }
+  header .meta { font-size: 13px; color: #c3cad6; }
+  .score { font-size: 36px; font-weight: bold; }
+  .score small { font-size: 13px; font-weight: normal; color: #c3cad6; display: block; }
+  main { padding: 16px 32px; }
+  .cards { display: flex; flex-wrap: wrap; gap: 16px; }
+  .card { background: #fff; border-radius: 4px; box-shadow: 0 1px 3px rgba(0,0,0,.08); padding: 16px; }
+  .card h2 { font-size: 15px; margin: 0 0 12px; }
+  .legend span { display: inline-block; margin-right: 12px; font-size: 12px; }
+  .legend i { display: inline-block; width: 10px; height: 10px; margin-right: 4px; }
+  .toolbar { margin: 16px 0; display: flex; gap: 8px; flex-wrap: wrap; }
+  .toolbar input, .toolbar select { padding: 6px 8px; border: 1px solid #ccd3db; border-radius: 4px; font-size: 13px; }
+  .toolbar input { flex: 1; min-width: 240px; }
+  .tabs button { border: 0; background: none; padding: 8px 16px; font-size: 14px; cursor: pointer; border-bottom: 2px solid transparent; }
+  .tabs button.active { border-bottom-color: #3468c0; color: #3468c0; }
+  .view { display: none; }
+  .view.active { display: block; }
+  table { width: 100%; border-collapse: collapse; background: #fff; font-size: 13px; }
+  th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #eef0f3; vertical-align: top; word-break: break-word; }
+  th { background: #eef1f5; }
+  details { background: #fff; margin-bottom: 8px; border-radius: 4px; box-shadow: 0 1px 3px rgba(0,0,0,.08); }
+  summary { padding: 10px 12px; cursor: pointer; font-weight: bold; }
+  summary .counts { font-weight: normal; font-size: 12px; color: #79879c; margin-left: 8px; }
+  .level { display: inline-block; padding: 1px 6px; border-radius: 2px; color: #fff; font-size: 12px; }
+  .level-danger { background: #d64545; }
+  .level-warning { background: #f0a020; }
+  .level-ignore { background: #7a8ba0; }
+  .passed { color: #55bc8a; }
+  .empty { padding: 24px; text-align: center; color: #79879c; }
+</style>
+</head>
+<body>
+<header>
+  <div>
+    <h1>KubeEye inspection report: {{ .Cluster }}</h1>
+    <div class="meta">{{ .Name }}{{ if not .CreatedAt.IsZero }} · {{ formatTime "2006-01-02 15:04:05" .CreatedAt }}{{ end }}{{ if .Plan }} · plan {{ .Plan }}{{ end }}</div>
+  </div>
+  <div class="score">{{ .Score }}<small>score</small></div>
+</header>
+<main>
+  <div class="cards">
+    <div class="card">
+      <h2>Issues by level</h2>
+      {{ .LevelChart }}
+      <div class="legend">
+        <span><i class="level-danger"></i>danger {{ index .Levels "danger" }}</span>
+        <span><i class="level-warning"></i>warning {{ index .Levels "warning" }}</span>
+        <span><i class="level-ignore"></i>ignore {{ index .Levels "ignore" }}</span>
+      </div>
+    </div>
+    <div class="card">
+      <h2>Issues by category</h2>
+      {{ if .RuleTypes }}{{ .CategoryChart }}{{ else }}<div class="empty">No issues found</div>{{ end }}
+    </div>
+    {{ if .TrendChart }}
+    <div class="card">
+      <h2>Score trend</h2>
+      {{ .TrendChart }}
+    </div>
+    {{ end }}
+  </div>
+
+  <div class="toolbar">
+    <input id="search" type="search" placeholder="Search name, resource, node, namespace or message">
+    <select id="level">
+      <option value="">All levels</option>
+      <option value="danger">danger</option>
+      <option value="warning">warning</option>
+      <option value="ignore">ignore</option>
+    </select>
+    <select id="ruleType">
+      <option value="">All categories</option>
+      {{ range .RuleTypes }}<option value="{{ .Name }}">{{ .Name }}</option>{{ end }}
+    </select>
+  </div>
+
+  <div class="tabs">
+    <button class="active" data-view="findings">Findings ({{ .Total }})</button>
+    <button data-view="nodes">Nodes ({{ len .NodeViews }})</button>
+    <button data-view="namespaces">Namespaces ({{ len .NamespaceViews }})</button>
+  </div>
+
+  <div id="findings" class="view active">
+    {{ range .RuleTypes }}
+    <details open>
+      <summary>{{ .Name }}<span class="counts">{{ .Total }} issues</span></summary>
+      <table>
+        <thead><tr><th>Level</th><th>Name</th><th>Node</th><th>Namespace</th><th>Resource</th><th>Message</th><th>Value</th></tr></thead>
+        <tbody>
+        {{ range .Findings }}
+        <tr data-level="{{ level .Level }}" data-type="{{ .RuleType }}">
+          <td><span class="level level-{{ level .Level }}">{{ level .Level }}</span></td>
+          <td>{{ .Name }}</td><td>{{ .Node }}</td><td>{{ .Namespace }}</td><td>{{ .Resource }}</td><td>{{ .Message }}</td><td>{{ .Value }}</td>
+        </tr>
+        {{ end }}
+        </tbody>
+      </table>
+    </details>
+    {{ else }}
+    <div class="empty">No issues found</div>
+    {{ end }}
+  </div>
+
+  <div id="nodes" class="view">
+    {{ range .NodeViews }}
+    <details{{ if .Failed }} open{{ end }}>
+      <summary>{{ .Name }}<span class="counts">{{ .Failed }} failed, {{ .Passed }} passed</span></summary>
+      <table>
+        <thead><tr><th>Status</th><th>Category</th><th>Name</th><th>Resource</th><th>Message</th><th>Value</th></tr></thead>
+        <tbody>
+        {{ range .Checks }}
+        <tr data-level="{{ if .Passed }}passed{{ else }}{{ level .Level }}{{ end }}" data-type="{{ .RuleType }}">
+          <td>{{ if .Passed }}<span class="passed">passed</span>{{ else }}<span class="level level-{{ level .Level }}">{{ level .Level }}</span>{{ end }}</td>
+          <td>{{ .RuleType }}</td><td>{{ .Name }}</td><td>{{ .Resource }}</td><td>{{ if not .Passed }}{{ .Message }}{{ end }}</td><td>{{ .Value }}</td>
+        </tr>
+        {{ end }}
+        </tbody>
+      </table>
+    </details>
+    {{ else }}
+    <div class="empty">No node level checks</div>
+    {{ end }}
+  </div>
+
+  <div id="namespaces" class="view">
+    {{ range .NamespaceViews }}
+    <details>
+      <summary>{{ .Name }}<span class="counts">{{ .Total }} issues</span></summary>
+      <table>
+        <thead><tr><th>Level</th><th>Resource</th><th>Name</th><th>Message</th></tr></thead>
+        <tbody>
+        {{ range .Findings }}
+        <tr data-level="{{ level .Level }}" data-type="{{ .RuleType }}">
+          <td><span class="level level-{{ level .Level }}">{{ level .Level }}</span></td>
+          <td>{{ .Resource }}</td><td>{{ .Name }}</td><td>{{ .Message }}</td>
+        </tr>
+        {{ end }}
+        </tbody>
+      </table>
+    </details>
+    {{ else }}
+    <div class="empty">No namespaced issues</div>
+    {{ end }}
+  </div>
+</main>
+<script>
+(function () {
+  var search = document.getElementById("search");
+  var level = document.getElementById("level");
+  var ruleType = document.getElementById("ruleType");
+  function filter() {
+    var text = search.value.toLowerCase();
+    document.querySelectorAll("tr[data-level]").forEach(function (row) {
+      var show = (!text || row.textContent.toLowerCase().indexOf(text) >= 0) &&
+        (!level.value || row.dataset.level === level.value) &&
+        (!ruleType.value || row.dataset.type === ruleType.value);
+      row.style.display = show ? "" : "none";
+    });
+    document.querySelectorAll("details").forEach(function (section) {
+      var rows = section.querySelectorAll("tr[data-level]");
+      var visible = Array.prototype.some.call(rows, function (row) { return row.style.display !== "none"; });
+      section.style.display = visible || rows.length === 0 ? "" : "none";
+    });
+  }
+  [search, level, ruleType].forEach(function (input) { input.addEventListener("input", filter); });
+  document.querySelectorAll(".tabs button").forEach(function (button) {
+    button.addEventListener("click", function () {
+      document.querySelectorAll(".tabs button, .view").forEach(function (el) { el.classList.remove("active"); });
+      button.classList.add("active");
+      document.getElementById(button.dataset.view).classList.add("active");
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`)
+}
@@ -0,0 +1,48 @@
+package template
+
+import (
+	"fmt"
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+// RerunTaskTemplate returns a new task with the spec of the task under the same plan. With failedOnly it only runs
+// the jobs that failed in the task.
+func RerunTaskTemplate(task *v1alpha2.InspectTask, failedOnly bool) (*v1alpha2.InspectTask, error) {
+	spec := task.Spec.DeepCopy()
+	spec.Cancel = false
+	if failedOnly {
+		spec.Jobs = nil
+		for _, job := range task.Status.JobPhase {
+			if job.Phase.IsFailed() && job.RuleType != "" {
+				spec.Jobs = append(spec.Jobs, v1alpha2.JobSelector{Cluster: job.Cluster, RuleType: job.RuleType, NodeName: job.NodeName})
+			}
+		}
+		if len(spec.Jobs) == 0 {
+			return nil, fmt.Errorf("inspect task %s has no failed jobs to rerun", task.Name)
+		}
+	}
+
+	name := task.Name
+	labels := map[string]string{}
+	if plan, ok := task.Labels[constant.LabelPlanName]; ok {
+		name = plan
+		labels[constant.LabelPlanName] = plan
+	}
+	annotations := map[string]string{constant.AnnotationRerunOf: task.Name}
+	if inspectType, ok := task.Annotations[constant.AnnotationInspectType]; ok {
+		annotations[constant.AnnotationInspectType] = inspectType
+	}
+
+	return &v1alpha2.InspectTask{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf("%s-%s", name, time.Now().Format("20060102-15-04-05")),
+			Labels:          labels,
+			Annotations:     annotations,
+			OwnerReferences: task.OwnerReferences,
+		},
+		Spec: *spec,
+	}, nil
+}
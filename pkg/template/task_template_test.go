@@ -0,0 +1,94 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubeeye/apis/kubeeye/v1alpha2"
+	"github.com/kubesphere/kubeeye/pkg/constant"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func rerunTask(labels map[string]string) *v1alpha2.InspectTask {
+	return &v1alpha2.InspectTask{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "plan-20240101-10-00",
+			Labels:      labels,
+			Annotations: map[string]string{constant.AnnotationInspectType: "instant"},
+		},
+		Spec: v1alpha2.InspectTaskSpec{
+			Cancel: true,
+			Jobs:   []v1alpha2.JobSelector{{RuleType: constant.Sysctl}},
+		},
+		Status: v1alpha2.InspectTaskStatus{JobPhase: []v1alpha2.JobPhase{
+			{JobName: "sysctl-node1", Cluster: "default", RuleType: constant.Sysctl, NodeName: "node1", Phase: v1alpha2.PhaseFailed},
+			{JobName: "sysctl-node2", Cluster: "default", RuleType: constant.Sysctl, NodeName: "node2", Phase: v1alpha2.PhaseSucceeded},
+			{JobName: "systemd-node1", Cluster: "member", RuleType: constant.Systemd, NodeName: "node1", Phase: v1alpha2.PhaseFailed},
+			{JobName: "unknown", Cluster: "default", Phase: v1alpha2.PhaseFailed},
+		}},
+	}
+}
+
+func TestRerunTaskTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		labels     map[string]string
+		failedOnly bool
+		wantPrefix string
+		wantJobs   []v1alpha2.JobSelector
+	}{{
+		name:       "plan task",
+		labels:     map[string]string{constant.LabelPlanName: "plan"},
+		wantPrefix: "plan-",
+		wantJobs:   []v1alpha2.JobSelector{{RuleType: constant.Sysctl}},
+	}, {
+		name:       "task without plan",
+		wantPrefix: "plan-20240101-10-00-",
+		wantJobs:   []v1alpha2.JobSelector{{RuleType: constant.Sysctl}},
+	}, {
+		name:       "failed jobs only",
+		labels:     map[string]string{constant.LabelPlanName: "plan"},
+		failedOnly: true,
+		wantPrefix: "plan-",
+		wantJobs: []v1alpha2.JobSelector{
+			{Cluster: "default", RuleType: constant.Sysctl, NodeName: "node1"},
+			{Cluster: "member", RuleType: constant.Systemd, NodeName: "node1"},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := rerunTask(tt.labels)
+			rerun, err := RerunTaskTemplate(task, tt.failedOnly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(rerun.Name, tt.wantPrefix) || rerun.Name == task.Name {
+				t.Errorf("name = %s, want a new name starting with %s", rerun.Name, tt.wantPrefix)
+			}
+			if rerun.Labels[constant.LabelPlanName] != tt.labels[constant.LabelPlanName] {
+				t.Errorf("labels = %v, want the plan of the task", rerun.Labels)
+			}
+			if rerun.Annotations[constant.AnnotationRerunOf] != task.Name || rerun.Annotations[constant.AnnotationInspectType] != "instant" {
+				t.Errorf("annotations = %v", rerun.Annotations)
+			}
+			if rerun.Spec.Cancel {
+				t.Error("the rerun task should not be cancelled")
+			}
+			if !reflect.DeepEqual(rerun.Spec.Jobs, tt.wantJobs) {
+				t.Errorf("jobs = %v, want %v", rerun.Spec.Jobs, tt.wantJobs)
+			}
+			if len(task.Spec.Jobs) != 1 || !task.Spec.Cancel {
+				t.Errorf("the spec of the task was changed: %+v", task.Spec)
+			}
+		})
+	}
+}
+
+func TestRerunTaskTemplateWithoutFailedJobs(t *testing.T) {
+	task := rerunTask(nil)
+	task.Status.JobPhase = task.Status.JobPhase[1:2]
+	if _, err := RerunTaskTemplate(task, true); err == nil {
+		t.Error("rerunning the failed jobs of a task without failed jobs should fail")
+	}
+}
@@ -2,10 +2,14 @@ package utils
 
 import (
 	"encoding/json"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 )
 
+var yamlDocumentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
 func ArrayFind(s string, sub []string) (int, bool) {
 
 	index, b, _ := ArrayFinds[string](sub, func(m string) bool {
@@ -168,3 +172,23 @@ func DeepCopyMap[T any](obj map[string]T) map[string]T {
 	}
 	return result
 }
+
+// SplitYamlDocuments splits a multi-document YAML stream, dropping empty documents.
+func SplitYamlDocuments(data []byte) [][]byte {
+	var docs [][]byte
+	for _, doc := range yamlDocumentSeparator.Split(string(data), -1) {
+		if strings.TrimSpace(doc) != "" {
+			docs = append(docs, []byte(doc))
+		}
+	}
+	return docs
+}
+
+// IsManifestFile returns whether a file found in a directory holds YAML or JSON manifests.
+func IsManifestFile(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}